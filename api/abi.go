@@ -0,0 +1,491 @@
+package api
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/tidwall/gjson"
+	"gxclient-go/transaction"
+	gxcTypes "gxclient-go/types"
+	"gxclient-go/util"
+	"math"
+	"strconv"
+	"strings"
+)
+
+//characters of the base32 names, the first one is the padding
+const nameCharmap = ".12345abcdefghijklmnopqrstuvwxyz"
+
+//typedefs deeper than this are taken as a loop
+const maxTypedefDepth = 32
+
+//name of the uint64 encoding of util.StringToName
+func nameToString(value uint64) string {
+	str := make([]byte, 13)
+	for i := 0; i <= 12; i++ {
+		if i == 0 {
+			str[12-i] = nameCharmap[value&0x0f]
+			value >>= 4
+		} else {
+			str[12-i] = nameCharmap[value&0x1f]
+			value >>= 5
+		}
+	}
+	return strings.TrimRight(string(str), ".")
+}
+
+//uint64 of a name, an error for the names util.StringToName can not encode
+func stringToName(name string) (uint64, error) {
+	value := util.StringToName(name)
+	if nameToString(value) != name {
+		return 0, errors.Errorf("invalid name %q", name)
+	}
+	return value, nil
+}
+
+//abiCodec serializes the action arguments and the table rows of a contract as its ABI describes
+type abiCodec struct {
+	abi      *gxcTypes.Abi
+	typedefs map[string]string
+	structs  map[string]*gxcTypes.Struct
+}
+
+func newAbiCodec(abi *gxcTypes.Abi) *abiCodec {
+	codec := &abiCodec{
+		abi:      abi,
+		typedefs: map[string]string{},
+		structs:  map[string]*gxcTypes.Struct{},
+	}
+	for _, typedef := range abi.Types {
+		codec.typedefs[typedef.NewTypeName] = typedef.Type
+	}
+	for i := range abi.Structs {
+		codec.structs[abi.Structs[i].Name] = &abi.Structs[i]
+	}
+	return codec
+}
+
+func (codec *abiCodec) action(method string) (*gxcTypes.Action, error) {
+	for i := range codec.abi.Actions {
+		if codec.abi.Actions[i].Name == method {
+			return &codec.abi.Actions[i], nil
+		}
+	}
+	return nil, errors.Errorf("method %s not found in the abi", method)
+}
+
+func (codec *abiCodec) table(name string) (*gxcTypes.Table, error) {
+	for i := range codec.abi.Tables {
+		if codec.abi.Tables[i].Name == name {
+			return &codec.abi.Tables[i], nil
+		}
+	}
+	return nil, errors.Errorf("table %s not found in the abi", name)
+}
+
+//follow the typedefs of typ
+func (codec *abiCodec) resolve(typ string) (string, error) {
+	for depth := 0; depth < maxTypedefDepth; depth++ {
+		next, ok := codec.typedefs[typ]
+		if !ok {
+			return typ, nil
+		}
+		typ = next
+	}
+	return "", errors.Errorf("typedef loop at %s", typ)
+}
+
+//serialize args, a map, a struct or json, as the type typ
+func (codec *abiCodec) encodeArgs(typ string, args interface{}) ([]byte, error) {
+	var raw []byte
+	switch args := args.(type) {
+	case nil:
+	case string:
+		raw = []byte(args)
+	case []byte:
+		raw = args
+	case json.RawMessage:
+		raw = args
+	default:
+		var err error
+		if raw, err = json.Marshal(args); err != nil {
+			return nil, errors.Wrap(err, "failed to marshal the arguments")
+		}
+	}
+	if len(raw) == 0 {
+		raw = []byte("{}")
+	}
+	if !gjson.ValidBytes(raw) {
+		return nil, errors.New("invalid json arguments")
+	}
+
+	var b bytes.Buffer
+	if err := codec.encode(transaction.NewEncoder(&b), typ, gjson.ParseBytes(raw)); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+//text of a json number or of a number in a string
+func numberText(value gjson.Result) string {
+	if value.Type == gjson.String {
+		return value.Str
+	}
+	return value.Raw
+}
+
+func (codec *abiCodec) encode(enc *transaction.Encoder, typ string, value gjson.Result) error {
+	typ, err := codec.resolve(typ)
+	if err != nil {
+		return err
+	}
+	if strings.HasSuffix(typ, "?") {
+		if !value.Exists() || value.Type == gjson.Null {
+			return enc.EncodeUVarint(0)
+		}
+		if err := enc.EncodeUVarint(1); err != nil {
+			return err
+		}
+		return codec.encode(enc, strings.TrimSuffix(typ, "?"), value)
+	}
+	if !value.Exists() {
+		return errors.Errorf("missing value of %s", typ)
+	}
+	if strings.HasSuffix(typ, "[]") {
+		if !value.IsArray() {
+			return errors.Errorf("expected an array of %s, got %s", typ, value.Raw)
+		}
+		items := value.Array()
+		if err := enc.EncodeUVarint(uint64(len(items))); err != nil {
+			return err
+		}
+		for _, item := range items {
+			if err := codec.encode(enc, strings.TrimSuffix(typ, "[]"), item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	switch typ {
+	case "bool":
+		b, err := strconv.ParseBool(numberText(value))
+		if err != nil {
+			return errors.Errorf("expected a bool, got %s", value.Raw)
+		}
+		return enc.EncodeBool(b)
+	case "int8", "int16", "int32", "int64":
+		bits, _ := strconv.Atoi(strings.TrimPrefix(typ, "int"))
+		i, err := strconv.ParseInt(numberText(value), 10, bits)
+		if err != nil {
+			return errors.Errorf("expected an %s, got %s", typ, value.Raw)
+		}
+		switch bits {
+		case 8:
+			return enc.EncodeNumber(int8(i))
+		case 16:
+			return enc.EncodeNumber(int16(i))
+		case 32:
+			return enc.EncodeNumber(int32(i))
+		}
+		return enc.EncodeNumber(i)
+	case "uint8", "uint16", "uint32", "uint64":
+		bits, _ := strconv.Atoi(strings.TrimPrefix(typ, "uint"))
+		u, err := strconv.ParseUint(numberText(value), 10, bits)
+		if err != nil {
+			return errors.Errorf("expected a %s, got %s", typ, value.Raw)
+		}
+		switch bits {
+		case 8:
+			return enc.EncodeNumber(uint8(u))
+		case 16:
+			return enc.EncodeNumber(uint16(u))
+		case 32:
+			return enc.EncodeNumber(uint32(u))
+		}
+		return enc.EncodeNumber(u)
+	case "varint32":
+		i, err := strconv.ParseInt(numberText(value), 10, 32)
+		if err != nil {
+			return errors.Errorf("expected a varint32, got %s", value.Raw)
+		}
+		//zigzag, as read by abiReader.varint
+		return enc.EncodeUVarint(uint64((i << 1) ^ (i >> 63)))
+	case "varuint32":
+		u, err := strconv.ParseUint(numberText(value), 10, 32)
+		if err != nil {
+			return errors.Errorf("expected a varuint32, got %s", value.Raw)
+		}
+		return enc.EncodeUVarint(u)
+	case "float32", "float64":
+		f, err := strconv.ParseFloat(numberText(value), 64)
+		if err != nil {
+			return errors.Errorf("expected a %s, got %s", typ, value.Raw)
+		}
+		if typ == "float32" {
+			return enc.EncodeNumber(float32(f))
+		}
+		return enc.EncodeNumber(f)
+	case "string":
+		if value.Type != gjson.String {
+			return errors.Errorf("expected a string, got %s", value.Raw)
+		}
+		return enc.Encode(value.Str)
+	case "bytes":
+		b, err := hex.DecodeString(value.String())
+		if err != nil {
+			return errors.Errorf("expected hex bytes, got %s", value.Raw)
+		}
+		if err := enc.EncodeUVarint(uint64(len(b))); err != nil {
+			return err
+		}
+		return enc.Encode(b)
+	case "name":
+		name, err := stringToName(value.String())
+		if err != nil {
+			return err
+		}
+		return enc.EncodeNumber(name)
+	case "contract_asset":
+		amount, err := strconv.ParseInt(numberText(value.Get("amount")), 10, 64)
+		if err != nil {
+			return errors.Errorf("invalid contract_asset amount in %s", value.Raw)
+		}
+		assetId, err := strconv.ParseUint(numberText(value.Get("asset_id")), 10, 64)
+		if err != nil {
+			return errors.Errorf("invalid contract_asset asset_id in %s", value.Raw)
+		}
+		if err := enc.EncodeNumber(amount); err != nil {
+			return err
+		}
+		return enc.EncodeNumber(assetId)
+	case "checksum160", "checksum256", "checksum512":
+		b, err := hex.DecodeString(value.String())
+		if err != nil || len(b) != checksumSize(typ) {
+			return errors.Errorf("expected %d bytes of hex for %s, got %s", checksumSize(typ), typ, value.Raw)
+		}
+		return enc.Encode(b)
+	}
+
+	s, ok := codec.structs[typ]
+	if !ok {
+		return errors.Errorf("unknown type %s", typ)
+	}
+	if !value.IsObject() {
+		return errors.Errorf("expected an object of %s, got %s", typ, value.Raw)
+	}
+	if len(s.Base) > 0 {
+		if err := codec.encode(enc, s.Base, value); err != nil {
+			return err
+		}
+	}
+	fields := value.Map()
+	for _, field := range s.Fields {
+		fieldValue, ok := fields[field.Name]
+		if !ok && !strings.HasSuffix(field.Type, "?") {
+			return errors.Errorf("missing field %s of %s", field.Name, typ)
+		}
+		if err := codec.encode(enc, field.Type, fieldValue); err != nil {
+			return errors.Wrapf(err, "field %s of %s", field.Name, typ)
+		}
+	}
+	return nil
+}
+
+func checksumSize(typ string) int {
+	bits, _ := strconv.Atoi(strings.TrimPrefix(typ, "checksum"))
+	return bits / 8
+}
+
+//abiReader reads the serialized values
+type abiReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *abiReader) read(n int) ([]byte, error) {
+	if n < 0 || r.pos+n > len(r.data) {
+		return nil, errors.Errorf("unexpected end of data at %d", r.pos)
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+func (r *abiReader) uvarint() (uint64, error) {
+	u, n := binary.Uvarint(r.data[r.pos:])
+	if n <= 0 {
+		return 0, errors.Errorf("invalid varint at %d", r.pos)
+	}
+	r.pos += n
+	return u, nil
+}
+
+func (r *abiReader) varint() (int64, error) {
+	i, n := binary.Varint(r.data[r.pos:])
+	if n <= 0 {
+		return 0, errors.Errorf("invalid varint at %d", r.pos)
+	}
+	r.pos += n
+	return i, nil
+}
+
+func (r *abiReader) uint(size int) (uint64, error) {
+	b, err := r.read(size)
+	if err != nil {
+		return 0, err
+	}
+	var buf [8]byte
+	copy(buf[:], b)
+	return binary.LittleEndian.Uint64(buf[:]), nil
+}
+
+//deserialize data as the type typ to json, all the data must be used
+func (codec *abiCodec) decodeArgs(typ string, data []byte) (json.RawMessage, error) {
+	r := &abiReader{data: data}
+	value, err := codec.decode(r, typ)
+	if err != nil {
+		return nil, err
+	}
+	if r.pos != len(data) {
+		return nil, errors.Errorf("%d bytes left after %s", len(data)-r.pos, typ)
+	}
+	return json.Marshal(value)
+}
+
+func (codec *abiCodec) decode(r *abiReader, typ string) (interface{}, error) {
+	typ, err := codec.resolve(typ)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasSuffix(typ, "?") {
+		flag, err := r.read(1)
+		if err != nil {
+			return nil, err
+		}
+		if flag[0] == 0 {
+			return nil, nil
+		}
+		return codec.decode(r, strings.TrimSuffix(typ, "?"))
+	}
+	if strings.HasSuffix(typ, "[]") {
+		n, err := r.uvarint()
+		if err != nil {
+			return nil, err
+		}
+		if n > uint64(len(r.data)-r.pos) {
+			return nil, errors.Errorf("invalid length %d of %s", n, typ)
+		}
+		items := make([]interface{}, 0, n)
+		for i := uint64(0); i < n; i++ {
+			item, err := codec.decode(r, strings.TrimSuffix(typ, "[]"))
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	}
+
+	switch typ {
+	case "bool":
+		b, err := r.read(1)
+		if err != nil {
+			return nil, err
+		}
+		return b[0] != 0, nil
+	case "int8", "int16", "int32", "int64":
+		bits, _ := strconv.Atoi(strings.TrimPrefix(typ, "int"))
+		u, err := r.uint(bits / 8)
+		if err != nil {
+			return nil, err
+		}
+		//sign extension
+		shift := uint(64 - bits)
+		return int64(u<<shift) >> shift, nil
+	case "uint8", "uint16", "uint32", "uint64":
+		bits, _ := strconv.Atoi(strings.TrimPrefix(typ, "uint"))
+		return r.uint(bits / 8)
+	case "varint32":
+		return r.varint()
+	case "varuint32":
+		return r.uvarint()
+	case "float32":
+		u, err := r.uint(4)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float32frombits(uint32(u)), nil
+	case "float64":
+		u, err := r.uint(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(u), nil
+	case "string", "bytes":
+		n, err := r.uvarint()
+		if err != nil {
+			return nil, err
+		}
+		if n > uint64(len(r.data)-r.pos) {
+			return nil, errors.Errorf("invalid length %d of %s", n, typ)
+		}
+		b, err := r.read(int(n))
+		if err != nil {
+			return nil, err
+		}
+		if typ == "bytes" {
+			return hex.EncodeToString(b), nil
+		}
+		return string(b), nil
+	case "name":
+		u, err := r.uint(8)
+		if err != nil {
+			return nil, err
+		}
+		return nameToString(u), nil
+	case "contract_asset":
+		amount, err := r.uint(8)
+		if err != nil {
+			return nil, err
+		}
+		assetId, err := r.uint(8)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"amount": int64(amount), "asset_id": assetId}, nil
+	case "checksum160", "checksum256", "checksum512":
+		b, err := r.read(checksumSize(typ))
+		if err != nil {
+			return nil, err
+		}
+		return hex.EncodeToString(b), nil
+	}
+
+	s, ok := codec.structs[typ]
+	if !ok {
+		return nil, errors.Errorf("unknown type %s", typ)
+	}
+	object := map[string]interface{}{}
+	if len(s.Base) > 0 {
+		base, err := codec.decode(r, s.Base)
+		if err != nil {
+			return nil, err
+		}
+		baseObject, ok := base.(map[string]interface{})
+		if !ok {
+			return nil, errors.Errorf("base %s of %s is not a struct", s.Base, typ)
+		}
+		object = baseObject
+	}
+	for _, field := range s.Fields {
+		value, err := codec.decode(r, field.Type)
+		if err != nil {
+			return nil, errors.Wrapf(err, "field %s of %s", field.Name, typ)
+		}
+		object[field.Name] = value
+	}
+	return object, nil
+}
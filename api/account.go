@@ -0,0 +1,272 @@
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/pkg/errors"
+	"gxclient-adapter/types"
+	"gxclient-go/transaction"
+	gxcTypes "gxclient-go/types"
+	"sort"
+	"strings"
+)
+
+//KeyAuth is a public key of an authority with its weight, [key, weight] in json
+type KeyAuth struct {
+	Key    *gxcTypes.PublicKey
+	Weight uint16
+}
+
+func (a KeyAuth) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{a.Key.String(), a.Weight})
+}
+
+func (a *KeyAuth) UnmarshalJSON(b []byte) error {
+	var pair []json.RawMessage
+	if err := json.Unmarshal(b, &pair); err != nil || len(pair) != 2 {
+		return errors.Errorf("invalid key auth %s", string(b))
+	}
+	a.Key = &gxcTypes.PublicKey{}
+	if err := a.Key.UnmarshalJSON(pair[0]); err != nil {
+		return errors.Wrapf(err, "invalid key auth %s", string(b))
+	}
+	return json.Unmarshal(pair[1], &a.Weight)
+}
+
+//AccountAuth is an account of an authority with its weight, [account, weight] in json
+type AccountAuth struct {
+	Account gxcTypes.ObjectID
+	Weight  uint16
+}
+
+func (a AccountAuth) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{a.Account.String(), a.Weight})
+}
+
+func (a *AccountAuth) UnmarshalJSON(b []byte) error {
+	var pair []json.RawMessage
+	if err := json.Unmarshal(b, &pair); err != nil || len(pair) != 2 {
+		return errors.Errorf("invalid account auth %s", string(b))
+	}
+	if err := a.Account.UnmarshalJSON(pair[0]); err != nil {
+		return err
+	}
+	return json.Unmarshal(pair[1], &a.Weight)
+}
+
+//Authority is an authority of an account as the chain serializes it, unlike
+//gxcTypes.Authority it has no extensions and its auths are sorted
+type Authority struct {
+	WeightThreshold uint32            `json:"weight_threshold"`
+	AccountAuths    []AccountAuth     `json:"account_auths"`
+	KeyAuths        []KeyAuth         `json:"key_auths"`
+	AddressAuths    []json.RawMessage `json:"address_auths"`
+}
+
+func (a Authority) MarshalTransaction(encoder *transaction.Encoder) error {
+	if len(a.AddressAuths) > 0 {
+		return errors.New("address auths are not supported")
+	}
+	a.sort()
+	enc := transaction.NewRollingEncoder(encoder)
+	enc.Encode(a.WeightThreshold)
+	enc.EncodeUVarint(uint64(len(a.AccountAuths)))
+	for _, auth := range a.AccountAuths {
+		enc.Encode(auth.Account)
+		enc.Encode(auth.Weight)
+	}
+	enc.EncodeUVarint(uint64(len(a.KeyAuths)))
+	for _, auth := range a.KeyAuths {
+		enc.Encode(*auth.Key)
+		enc.Encode(auth.Weight)
+	}
+	//AddressAuths
+	enc.EncodeUVarint(0)
+	return enc.Err()
+}
+
+//sort the auths in the order of the chain maps, the keys like gxclient-go sorts them
+func (a *Authority) sort() {
+	sort.Slice(a.AccountAuths, func(i, j int) bool { return a.AccountAuths[i].Account.ID < a.AccountAuths[j].Account.ID })
+	sort.Slice(a.KeyAuths, func(i, j int) bool {
+		s, _ := gxcTypes.PublicKeyComparator(a.KeyAuths[i].Key, a.KeyAuths[j].Key)
+		return s < 0
+	})
+}
+
+//the authority with the key strings and the account ids
+func (a *Authority) toTx() *types.Authority {
+	if a == nil {
+		return nil
+	}
+	auth := &types.Authority{Threshold: a.WeightThreshold, Keys: map[string]uint16{}, Accounts: map[string]uint16{}}
+	for _, key := range a.KeyAuths {
+		auth.Keys[key.Key.String()] = key.Weight
+	}
+	for _, account := range a.AccountAuths {
+		auth.Accounts[account.Account.String()] = account.Weight
+	}
+	return auth
+}
+
+//AccountUpdateOperation changes the owner and active authorities or the options of an account
+type AccountUpdateOperation struct {
+	Fee     gxcTypes.AssetAmount `json:"fee"`
+	Account gxcTypes.ObjectID    `json:"account"`
+	//nil to keep the authority
+	Owner  *Authority `json:"owner,omitempty"`
+	Active *Authority `json:"active,omitempty"`
+	//nil to keep the options
+	NewOptions *gxcTypes.AccountOptions   `json:"new_options,omitempty"`
+	Extensions map[string]json.RawMessage `json:"extensions"`
+}
+
+func (op *AccountUpdateOperation) Type() gxcTypes.OpType { return AccountUpdateOpType }
+
+func (op *AccountUpdateOperation) MarshalTransaction(encoder *transaction.Encoder) error {
+	if len(op.Extensions) > 0 {
+		return errors.New("account_update extensions are not supported")
+	}
+	enc := transaction.NewRollingEncoder(encoder)
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Account)
+	for _, auth := range []*Authority{op.Owner, op.Active} {
+		if auth != nil {
+			enc.EncodeUVarint(1)
+			enc.Encode(*auth)
+		} else {
+			//Authority?
+			enc.EncodeUVarint(0)
+		}
+	}
+	if op.NewOptions != nil {
+		enc.EncodeUVarint(1)
+		enc.Encode(*op.NewOptions)
+	} else {
+		//NewOptions?
+		enc.EncodeUVarint(0)
+	}
+	//Extensions
+	enc.EncodeUVarint(0)
+	return enc.Err()
+}
+
+//a public key with the network prefix
+func (restClient *RestClient) publicKey(key string) (*gxcTypes.PublicKey, error) {
+	pubHex, err := restClient.Network().PubKeyBase58ToHex(key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid public key %s", key)
+	}
+	pubBytes, _ := hex.DecodeString(pubHex)
+	pub, err := btcec.ParsePubKey(pubBytes, btcec.S256())
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid public key %s", key)
+	}
+	return gxcTypes.NewPublicKey(pub)
+}
+
+//the chain authority of auth, an error when its threshold can not be reached,
+//a warning when the keys alone do not reach it
+func (restClient *RestClient) authority(name string, auth *types.Authority) (*Authority, error) {
+	if auth.Threshold == 0 {
+		return nil, errors.Errorf("threshold of the %s authority must be positive", name)
+	}
+	result := &Authority{
+		WeightThreshold: auth.Threshold,
+		AccountAuths:    []AccountAuth{},
+		KeyAuths:        []KeyAuth{},
+		AddressAuths:    []json.RawMessage{},
+	}
+	var keyWeights, accountWeights uint64
+	keys := map[string]bool{}
+	for key, weight := range auth.Keys {
+		if weight == 0 {
+			return nil, errors.Errorf("weight of %s in the %s authority must be positive", key, name)
+		}
+		pub, err := restClient.publicKey(key)
+		if err != nil {
+			return nil, err
+		}
+		if keys[pub.String()] {
+			return nil, errors.Errorf("key %s is twice in the %s authority", key, name)
+		}
+		keys[pub.String()] = true
+		result.KeyAuths = append(result.KeyAuths, KeyAuth{Key: pub, Weight: weight})
+		keyWeights += uint64(weight)
+	}
+	accounts := map[string]bool{}
+	for account, weight := range auth.Accounts {
+		if weight == 0 {
+			return nil, errors.Errorf("weight of %s in the %s authority must be positive", account, name)
+		}
+		id := account
+		if !strings.HasPrefix(account, "1.2.") {
+			acc, err := restClient.Database.GetAccount(account)
+			if err != nil {
+				return nil, err
+			}
+			id = acc.ID.String()
+		}
+		accountId, err := gxcTypes.ParseObjectID(id)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid account %s", account)
+		}
+		if accounts[id] {
+			return nil, errors.Errorf("account %s is twice in the %s authority", account, name)
+		}
+		accounts[id] = true
+		result.AccountAuths = append(result.AccountAuths, AccountAuth{Account: accountId, Weight: weight})
+		accountWeights += uint64(weight)
+	}
+	result.sort()
+
+	if keyWeights+accountWeights < uint64(auth.Threshold) {
+		return nil, errors.Errorf("threshold %d of the %s authority is unreachable, the weights sum to %d",
+			auth.Threshold, name, keyWeights+accountWeights)
+	}
+	if keyWeights < uint64(auth.Threshold) {
+		restClient.log().Log(LevelWarn, "authority threshold unreachable with the keys", F("method", "BuildAccountUpdate"),
+			F("authority", name), F("threshold", auth.Threshold), F("key_weights", keyWeights), F("account_weights", accountWeights))
+	}
+	return result, nil
+}
+
+//build an unsigned rotation of the keys of account. newOwner and newActive are
+//the new weighted authorities, nil to keep them, newMemoKey the new memo public
+//key, empty to keep it. An owner change must be signed with the current owner keys
+func (restClient *RestClient) BuildAccountUpdate(account string, newOwner, newActive *types.Authority, newMemoKey string) (string, error) {
+	acc, err := restClient.Database.GetAccount(account)
+	if err != nil {
+		return "", err
+	}
+	if newOwner == nil && newActive == nil && len(newMemoKey) == 0 {
+		return "", errors.New("nothing to update")
+	}
+	op := &AccountUpdateOperation{
+		Account:    gxcTypes.MustParseObjectID(acc.ID.String()),
+		Extensions: map[string]json.RawMessage{},
+	}
+	if newOwner != nil {
+		if op.Owner, err = restClient.authority("owner", newOwner); err != nil {
+			return "", err
+		}
+	}
+	if newActive != nil {
+		if op.Active, err = restClient.authority("active", newActive); err != nil {
+			return "", err
+		}
+	}
+	if len(newMemoKey) > 0 {
+		memoKey, err := restClient.publicKey(newMemoKey)
+		if err != nil {
+			return "", err
+		}
+		options := acc.Options
+		options.MemoKey = *memoKey
+		op.NewOptions = &options
+	}
+	return restClient.buildOperation("BuildAccountUpdate", op, &op.Fee, F("account", account), F("owner", newOwner != nil),
+		F("active", newActive != nil), F("memo", len(newMemoKey) > 0))
+}
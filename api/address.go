@@ -0,0 +1,87 @@
+package api
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/tidwall/gjson"
+	"strings"
+)
+
+//graphene account name length limits
+const (
+	MinAddressLength = 1
+	MaxAddressLength = 63
+)
+
+//base58 of the null public key without the prefix, set as memo key by accounts without one
+const nullKeySuffix = "1111111111111111111111111111111114T1Anm"
+
+//AddressInfo is the on-chain state of an account name
+type AddressInfo struct {
+	Address    string `json:"address"`
+	AccountId  string `json:"account_id,omitempty"`
+	Exists     bool   `json:"exists"`
+	HasMemoKey bool   `json:"has_memo_key"`
+	MemoKey    string `json:"memo_key,omitempty"`
+}
+
+//check the graphene account name rules offline: 1 to 63 characters, labels
+//separated by dots, each starting with a lowercase letter, ending with a
+//lowercase letter or digit, with only lowercase letters, digits and hyphens
+func ValidateAddress(name string) error {
+	if len(name) < MinAddressLength || len(name) > MaxAddressLength {
+		return errors.Errorf("invalid address %q: length must be between %d and %d", name, MinAddressLength, MaxAddressLength)
+	}
+	for _, label := range strings.Split(name, ".") {
+		if len(label) < MinAddressLength {
+			return errors.Errorf("invalid address %q: empty label", name)
+		}
+		if label[0] < 'a' || label[0] > 'z' {
+			return errors.Errorf("invalid address %q: label %q must start with a lowercase letter", name, label)
+		}
+		last := label[len(label)-1]
+		if !(last >= 'a' && last <= 'z' || last >= '0' && last <= '9') {
+			return errors.Errorf("invalid address %q: label %q must end with a lowercase letter or digit", name, label)
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-') {
+				return errors.Errorf("invalid address %q: invalid character %q", name, c)
+			}
+		}
+	}
+	return nil
+}
+
+//validate the name, then check the account exists and has a memo key,
+//a missing account is not an error
+func (restClient *RestClient) CheckAddress(name string) (*AddressInfo, error) {
+	if err := ValidateAddress(name); err != nil {
+		return nil, err
+	}
+	info := &AddressInfo{Address: name}
+	//the node returns null for an unknown name
+	var acc json.RawMessage
+	if err := restClient.callDatabase("get_account_by_name", []interface{}{name}, &acc); err != nil {
+		return nil, errors.Wrapf(err, "failed to get account %s", name)
+	}
+	if len(acc) == 0 || string(acc) == "null" {
+		return info, nil
+	}
+	info.Exists = true
+	info.AccountId = gjson.GetBytes(acc, "id").String()
+
+	//the memo key is read from the raw object, the null key does not decode
+	var objects []json.RawMessage
+	if err := restClient.callDatabase("get_objects", []interface{}{[]string{info.AccountId}}, &objects); err != nil {
+		return nil, errors.Wrapf(err, "failed to get account %s", info.AccountId)
+	}
+	if len(objects) == 0 || gjson.ParseBytes(objects[0]).Type == gjson.Null {
+		return nil, errors.Errorf("account %s not exist", info.AccountId)
+	}
+	memoKey := gjson.GetBytes(objects[0], "options.memo_key").String()
+	if len(memoKey) > 0 && !strings.HasSuffix(memoKey, nullKeySuffix) {
+		info.HasMemoKey = true
+		info.MemoKey = memoKey
+	}
+	return info, nil
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	gxcTypes "gxclient-go/types"
+	"math"
+	"strings"
+)
+
+//parse a decimal amount followed by an asset symbol or id, like "1.5 GXC",
+//to the amount in the smallest unit of the asset
+func (restClient *RestClient) parseAmount(amount string) (*gxcTypes.AssetAmount, error) {
+	parts := strings.Fields(amount)
+	if len(parts) != 2 {
+		return nil, errors.Errorf("invalid amount %q, expected an amount and an asset like \"1.5 GXC\"", amount)
+	}
+	value, err := decimal.NewFromString(parts[0])
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid amount %q", amount)
+	}
+	asset, err := restClient.Database.GetAsset(parts[1])
+	if err != nil {
+		return nil, err
+	}
+	return decimalToAssetAmount(value, asset.ID, asset.Precision)
+}
+
+//the decimal value in the smallest unit of an asset of precision
+func decimalToAssetAmount(value decimal.Decimal, assetId gxcTypes.ObjectID, precision uint8) (*gxcTypes.AssetAmount, error) {
+	shifted := value.Shift(int32(precision))
+	if !shifted.Equal(shifted.Truncate(0)) {
+		return nil, errors.Errorf("amount %s has more than %d decimals", value, precision)
+	}
+	if shifted.Sign() < 0 || shifted.GreaterThan(decimal.New(math.MaxInt64, 0)) {
+		return nil, errors.Errorf("amount %s out of range", value)
+	}
+	return &gxcTypes.AssetAmount{AssetID: assetId, Amount: uint64(shifted.IntPart())}, nil
+}
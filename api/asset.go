@@ -0,0 +1,616 @@
+package api
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/tidwall/gjson"
+	"gxclient-adapter/types"
+	"gxclient-go/transaction"
+	gxcTypes "gxclient-go/types"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//ShareType is an amount in the smallest unit of an asset, a number or a string in json
+type ShareType int64
+
+func (s ShareType) MarshalTransaction(encoder *transaction.Encoder) error {
+	return encoder.EncodeNumber(int64(s))
+}
+
+func (s *ShareType) UnmarshalJSON(b []byte) error {
+	value, err := strconv.ParseInt(strings.Trim(string(b), `"`), 10, 64)
+	if err != nil {
+		return errors.Wrapf(err, "invalid amount %s", string(b))
+	}
+	*s = ShareType(value)
+	return nil
+}
+
+//asset flags and issuer permissions of the chain
+var assetFlags = []struct {
+	name string
+	bit  uint16
+}{
+	{"charge_market_fee", 0x01},
+	{"white_list", 0x02},
+	{"override_authority", 0x04},
+	{"transfer_restricted", 0x08},
+	{"disable_force_settle", 0x10},
+	{"global_settle", 0x20},
+	{"disable_confidential", 0x40},
+	{"witness_fed_asset", 0x80},
+	{"committee_fed_asset", 0x100},
+}
+
+//the permissions a user issued asset may have
+const uiaPermissionMask = 0x01 | 0x02 | 0x04 | 0x08 | 0x40
+
+func flagNames(bits uint16) []string {
+	var names []string
+	for _, flag := range assetFlags {
+		if bits&flag.bit != 0 {
+			names = append(names, flag.name)
+		}
+	}
+	return names
+}
+
+func flagBits(names []string) (uint16, error) {
+	var bits uint16
+	for _, name := range names {
+		found := false
+		for _, flag := range assetFlags {
+			if flag.name == name {
+				bits |= flag.bit
+				found = true
+			}
+		}
+		if !found {
+			return 0, errors.Errorf("unknown asset flag %s", name)
+		}
+	}
+	return bits, nil
+}
+
+//AssetOptions are the options of an asset shared by asset_create and asset_update
+type AssetOptions struct {
+	MaxSupply         ShareType `json:"max_supply"`
+	MarketFeePercent  uint16    `json:"market_fee_percent"`
+	MaxMarketFee      ShareType `json:"max_market_fee"`
+	IssuerPermissions uint16    `json:"issuer_permissions"`
+	Flags             uint16    `json:"flags"`
+	//price of the asset in the core asset, paid by the fee pool
+	CoreExchangeRate     gxcTypes.Price      `json:"core_exchange_rate"`
+	WhitelistAuthorities []gxcTypes.ObjectID `json:"whitelist_authorities"`
+	BlacklistAuthorities []gxcTypes.ObjectID `json:"blacklist_authorities"`
+	WhitelistMarkets     []gxcTypes.ObjectID `json:"whitelist_markets"`
+	BlacklistMarkets     []gxcTypes.ObjectID `json:"blacklist_markets"`
+	Description          string              `json:"description"`
+	Extensions           []json.RawMessage   `json:"extensions"`
+}
+
+func (o AssetOptions) MarshalTransaction(encoder *transaction.Encoder) error {
+	enc := transaction.NewRollingEncoder(encoder)
+	enc.Encode(o.MaxSupply)
+	enc.Encode(o.MarketFeePercent)
+	enc.Encode(o.MaxMarketFee)
+	enc.Encode(o.IssuerPermissions)
+	enc.Encode(o.Flags)
+	enc.Encode(o.CoreExchangeRate.Base)
+	enc.Encode(o.CoreExchangeRate.Quote)
+	for _, ids := range [][]gxcTypes.ObjectID{o.WhitelistAuthorities, o.BlacklistAuthorities, o.WhitelistMarkets, o.BlacklistMarkets} {
+		//flat_set, sorted by instance
+		sorted := append([]gxcTypes.ObjectID{}, ids...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+		enc.EncodeUVarint(uint64(len(sorted)))
+		for _, id := range sorted {
+			enc.Encode(id)
+		}
+	}
+	enc.Encode(o.Description)
+	//Extensions
+	enc.EncodeUVarint(0)
+	return enc.Err()
+}
+
+//AssetCreateOperation creates a user issued asset
+type AssetCreateOperation struct {
+	Fee           gxcTypes.AssetAmount `json:"fee"`
+	Issuer        gxcTypes.ObjectID    `json:"issuer"`
+	Symbol        string               `json:"symbol"`
+	Precision     uint8                `json:"precision"`
+	CommonOptions AssetOptions         `json:"common_options"`
+	//market issued assets are not supported
+	BitassetOpts       *json.RawMessage  `json:"bitasset_opts,omitempty"`
+	IsPredictionMarket bool              `json:"is_prediction_market"`
+	Extensions         []json.RawMessage `json:"extensions"`
+}
+
+func (op *AssetCreateOperation) Type() gxcTypes.OpType { return AssetCreateOpType }
+
+func (op *AssetCreateOperation) MarshalTransaction(encoder *transaction.Encoder) error {
+	if op.BitassetOpts != nil {
+		return errors.New("bitasset options are not supported")
+	}
+	enc := transaction.NewRollingEncoder(encoder)
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Issuer)
+	enc.Encode(op.Symbol)
+	enc.Encode(op.Precision)
+	enc.Encode(op.CommonOptions)
+	//BitassetOpts?
+	enc.EncodeUVarint(0)
+	enc.Encode(op.IsPredictionMarket)
+	//Extensions
+	enc.EncodeUVarint(0)
+	return enc.Err()
+}
+
+//AssetUpdateOperation changes the options or the issuer of an asset
+type AssetUpdateOperation struct {
+	Fee           gxcTypes.AssetAmount `json:"fee"`
+	Issuer        gxcTypes.ObjectID    `json:"issuer"`
+	AssetToUpdate gxcTypes.ObjectID    `json:"asset_to_update"`
+	//nil to keep the issuer
+	NewIssuer  *gxcTypes.ObjectID `json:"new_issuer,omitempty"`
+	NewOptions AssetOptions       `json:"new_options"`
+	Extensions []json.RawMessage  `json:"extensions"`
+}
+
+func (op *AssetUpdateOperation) Type() gxcTypes.OpType { return AssetUpdateOpType }
+
+func (op *AssetUpdateOperation) MarshalTransaction(encoder *transaction.Encoder) error {
+	enc := transaction.NewRollingEncoder(encoder)
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Issuer)
+	enc.Encode(op.AssetToUpdate)
+	if op.NewIssuer != nil {
+		enc.EncodeUVarint(1)
+		enc.Encode(*op.NewIssuer)
+	} else {
+		//NewIssuer?
+		enc.EncodeUVarint(0)
+	}
+	enc.Encode(op.NewOptions)
+	//Extensions
+	enc.EncodeUVarint(0)
+	return enc.Err()
+}
+
+//AssetIssueOperation issues an amount of an asset to an account
+type AssetIssueOperation struct {
+	Fee            gxcTypes.AssetAmount `json:"fee"`
+	Issuer         gxcTypes.ObjectID    `json:"issuer"`
+	AssetToIssue   gxcTypes.AssetAmount `json:"asset_to_issue"`
+	IssueToAccount gxcTypes.ObjectID    `json:"issue_to_account"`
+	Memo           *gxcTypes.Memo       `json:"memo,omitempty"`
+	Extensions     []json.RawMessage    `json:"extensions"`
+}
+
+func (op *AssetIssueOperation) Type() gxcTypes.OpType { return AssetIssueOpType }
+
+func (op *AssetIssueOperation) MarshalTransaction(encoder *transaction.Encoder) error {
+	enc := transaction.NewRollingEncoder(encoder)
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Issuer)
+	enc.Encode(op.AssetToIssue)
+	enc.Encode(op.IssueToAccount)
+	if op.Memo != nil && op.Memo.Message.Length() > 0 {
+		enc.EncodeUVarint(1)
+		enc.Encode(op.Memo)
+	} else {
+		//Memo?
+		enc.EncodeUVarint(0)
+	}
+	//Extensions
+	enc.EncodeUVarint(0)
+	return enc.Err()
+}
+
+//AssetReserveOperation takes an amount of an asset out of the supply
+type AssetReserveOperation struct {
+	Fee             gxcTypes.AssetAmount `json:"fee"`
+	Payer           gxcTypes.ObjectID    `json:"payer"`
+	AmountToReserve gxcTypes.AssetAmount `json:"amount_to_reserve"`
+	Extensions      []json.RawMessage    `json:"extensions"`
+}
+
+func (op *AssetReserveOperation) Type() gxcTypes.OpType { return AssetReserveOpType }
+
+func (op *AssetReserveOperation) MarshalTransaction(encoder *transaction.Encoder) error {
+	enc := transaction.NewRollingEncoder(encoder)
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Payer)
+	enc.Encode(op.AmountToReserve)
+	//Extensions
+	enc.EncodeUVarint(0)
+	return enc.Err()
+}
+
+//AssetFundFeePoolOperation adds an amount of the core asset to the fee pool of an asset
+type AssetFundFeePoolOperation struct {
+	Fee         gxcTypes.AssetAmount `json:"fee"`
+	FromAccount gxcTypes.ObjectID    `json:"from_account"`
+	AssetId     gxcTypes.ObjectID    `json:"asset_id"`
+	//in the core asset
+	Amount     ShareType         `json:"amount"`
+	Extensions []json.RawMessage `json:"extensions"`
+}
+
+func (op *AssetFundFeePoolOperation) Type() gxcTypes.OpType { return AssetFundFeePoolOpType }
+
+func (op *AssetFundFeePoolOperation) MarshalTransaction(encoder *transaction.Encoder) error {
+	enc := transaction.NewRollingEncoder(encoder)
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.FromAccount)
+	enc.Encode(op.AssetId)
+	enc.Encode(op.Amount)
+	//Extensions
+	enc.EncodeUVarint(0)
+	return enc.Err()
+}
+
+//AssetParams are the options of BuildAssetCreate and BuildAssetUpdate,
+//an update keeps the options left empty
+type AssetParams struct {
+	//decimal amount of the asset, e.g. "1000000"
+	MaxSupply string `json:"max_supply"`
+	//decimal amount of the core asset the fee pool pays per unit of the asset, e.g. "0.01"
+	CoreExchangeRate string `json:"core_exchange_rate"`
+	//flag names like "white_list", nil to keep them
+	Flags []string `json:"flags"`
+	//flags the issuer may change later, nil for all of them on create
+	IssuerPermissions []string `json:"issuer_permissions"`
+	Description       string   `json:"description"`
+}
+
+//asset object of the chain with its options
+type assetObject struct {
+	ID                 gxcTypes.ObjectID `json:"id"`
+	Symbol             string            `json:"symbol"`
+	Precision          uint8             `json:"precision"`
+	Issuer             gxcTypes.ObjectID `json:"issuer"`
+	Options            AssetOptions      `json:"options"`
+	DynamicAssetDataId string            `json:"dynamic_asset_data_id"`
+}
+
+//the asset by symbol or id, with the options database.Asset leaves out
+func (restClient *RestClient) getAssetObject(token string) (*assetObject, error) {
+	var assets []*assetObject
+	if err := restClient.callDatabase("lookup_asset_symbols", []interface{}{[]string{token}}, &assets); err != nil {
+		return nil, errors.Wrapf(err, "failed to get asset %s", token)
+	}
+	if len(assets) == 0 || assets[0] == nil {
+		return nil, errors.Errorf("assets %s not exist", token)
+	}
+	return assets[0], nil
+}
+
+//dynamic data of the asset, with the supply and the fee pool
+func (restClient *RestClient) assetDynamicData(asset *assetObject) (json.RawMessage, error) {
+	var objects []json.RawMessage
+	if err := restClient.callDatabase("get_objects", []interface{}{[]string{asset.DynamicAssetDataId}}, &objects); err != nil {
+		return nil, errors.Wrapf(err, "failed to get supply of %s", asset.Symbol)
+	}
+	if len(objects) == 0 || gjson.ParseBytes(objects[0]).Type == gjson.Null {
+		return nil, errors.Errorf("supply of %s not exist", asset.Symbol)
+	}
+	return objects[0], nil
+}
+
+//the asset issued by issuer
+func (restClient *RestClient) issuedAsset(issuer *gxcTypes.Account, token string) (*assetObject, error) {
+	asset, err := restClient.getAssetObject(token)
+	if err != nil {
+		return nil, err
+	}
+	if asset.Issuer.String() != issuer.ID.String() {
+		return nil, errors.Errorf("asset %s is not issued by %s", asset.Symbol, issuer.Name)
+	}
+	return asset, nil
+}
+
+//apply params to the options of the asset, the asset id is a placeholder on create
+func (restClient *RestClient) applyAssetParams(options *AssetOptions, params *AssetParams, assetId gxcTypes.ObjectID, precision uint8) error {
+	if len(params.MaxSupply) > 0 {
+		value, err := decimal.NewFromString(params.MaxSupply)
+		if err != nil {
+			return errors.Wrapf(err, "invalid max supply %s", params.MaxSupply)
+		}
+		maxSupply, err := decimalToAssetAmount(value, assetId, precision)
+		if err != nil {
+			return err
+		}
+		if maxSupply.Amount == 0 {
+			return errors.New("max supply must be positive")
+		}
+		options.MaxSupply = ShareType(maxSupply.Amount)
+	}
+	if len(params.CoreExchangeRate) > 0 {
+		coreAsset, err := restClient.Database.GetAsset(restClient.Network().CoreAsset)
+		if err != nil {
+			return err
+		}
+		value, err := decimal.NewFromString(params.CoreExchangeRate)
+		if err != nil {
+			return errors.Wrapf(err, "invalid core exchange rate %s", params.CoreExchangeRate)
+		}
+		quote, err := decimalToAssetAmount(value, coreAsset.ID, coreAsset.Precision)
+		if err != nil {
+			return err
+		}
+		if quote.Amount == 0 {
+			return errors.New("core exchange rate must be positive")
+		}
+		options.CoreExchangeRate = gxcTypes.Price{
+			Base:  gxcTypes.AssetAmount{Amount: uint64(decimal.New(1, int32(precision)).IntPart()), AssetID: assetId},
+			Quote: *quote,
+		}
+	}
+	if params.IssuerPermissions != nil {
+		permissions, err := flagBits(params.IssuerPermissions)
+		if err != nil {
+			return err
+		}
+		if permissions&^uiaPermissionMask != 0 {
+			return errors.Errorf("issuer permissions %v are not allowed to user issued assets", params.IssuerPermissions)
+		}
+		options.IssuerPermissions = permissions
+	}
+	if params.Flags != nil {
+		flags, err := flagBits(params.Flags)
+		if err != nil {
+			return err
+		}
+		options.Flags = flags
+	}
+	if options.Flags&^options.IssuerPermissions != 0 {
+		return errors.Errorf("flags %v are not in the issuer permissions %v", flagNames(options.Flags), flagNames(options.IssuerPermissions))
+	}
+	if len(params.Description) > 0 {
+		options.Description = params.Description
+	}
+	return nil
+}
+
+var assetSymbolPattern = regexp.MustCompile(`^[A-Z][A-Z0-9.]{1,14}[A-Z0-9]$`)
+
+//build an unsigned creation of the user issued asset symbol, MaxSupply and
+//CoreExchangeRate of params are required
+func (restClient *RestClient) BuildAssetCreate(issuer, symbol string, precision uint8, params *AssetParams) (string, error) {
+	acc, err := restClient.Database.GetAccount(issuer)
+	if err != nil {
+		return "", err
+	}
+	if !assetSymbolPattern.MatchString(symbol) {
+		return "", errors.Errorf("invalid asset symbol %s", symbol)
+	}
+	if precision > 12 {
+		return "", errors.Errorf("invalid precision %d", precision)
+	}
+	if params == nil || len(params.MaxSupply) == 0 || len(params.CoreExchangeRate) == 0 {
+		return "", errors.New("max supply and core exchange rate required")
+	}
+	if _, err := restClient.getAssetObject(symbol); err == nil {
+		return "", errors.Errorf("asset %s already exists", symbol)
+	}
+
+	options := AssetOptions{
+		IssuerPermissions:    uiaPermissionMask,
+		WhitelistAuthorities: []gxcTypes.ObjectID{},
+		BlacklistAuthorities: []gxcTypes.ObjectID{},
+		WhitelistMarkets:     []gxcTypes.ObjectID{},
+		BlacklistMarkets:     []gxcTypes.ObjectID{},
+		Extensions:           []json.RawMessage{},
+	}
+	//the rate is the new asset as base and the core asset as quote, the evaluator replaces
+	//the base with the id of the new asset when its instance is not 0, or else the quote,
+	//so the placeholder is the id after the core asset
+	core := gxcTypes.MustParseObjectID(restClient.Network().CoreAssetId)
+	placeholder := gxcTypes.ObjectID{Space: core.Space, Type: core.Type, ID: core.ID + 1}
+	if err := restClient.applyAssetParams(&options, params, placeholder, precision); err != nil {
+		return "", err
+	}
+	op := &AssetCreateOperation{
+		Issuer:        gxcTypes.MustParseObjectID(acc.ID.String()),
+		Symbol:        symbol,
+		Precision:     precision,
+		CommonOptions: options,
+		Extensions:    []json.RawMessage{},
+	}
+	return restClient.buildOperation("BuildAssetCreate", op, &op.Fee, F("account", issuer), F("symbol", symbol),
+		F("max_supply", params.MaxSupply))
+}
+
+//build an unsigned update of the options of an asset of issuer, newIssuer
+//is the account to transfer the asset to, empty to keep it
+func (restClient *RestClient) BuildAssetUpdate(issuer, symbol, newIssuer string, params *AssetParams) (string, error) {
+	acc, err := restClient.Database.GetAccount(issuer)
+	if err != nil {
+		return "", err
+	}
+	asset, err := restClient.issuedAsset(acc, symbol)
+	if err != nil {
+		return "", err
+	}
+	options := asset.Options
+	if params != nil {
+		if err := restClient.applyAssetParams(&options, params, asset.ID, asset.Precision); err != nil {
+			return "", err
+		}
+	}
+	op := &AssetUpdateOperation{
+		Issuer:        gxcTypes.MustParseObjectID(acc.ID.String()),
+		AssetToUpdate: asset.ID,
+		NewOptions:    options,
+		Extensions:    []json.RawMessage{},
+	}
+	if len(newIssuer) > 0 {
+		newAcc, err := restClient.Database.GetAccount(newIssuer)
+		if err != nil {
+			return "", err
+		}
+		id := gxcTypes.MustParseObjectID(newAcc.ID.String())
+		op.NewIssuer = &id
+	}
+	return restClient.buildOperation("BuildAssetUpdate", op, &op.Fee, F("account", issuer), F("symbol", asset.Symbol),
+		F("new_issuer", newIssuer))
+}
+
+//build an unsigned issue of amount, a decimal amount and an asset like "100 LOY",
+//to the account to. The memo is encrypted by the caller with EncryptMemo
+func (restClient *RestClient) BuildAssetIssue(issuer, to, amount string, memoOb *gxcTypes.Memo) (string, error) {
+	acc, err := restClient.Database.GetAccount(issuer)
+	if err != nil {
+		return "", err
+	}
+	toAccount, err := restClient.Database.GetAccount(to)
+	if err != nil {
+		return "", err
+	}
+	issueAmount, err := restClient.parseAmount(amount)
+	if err != nil {
+		return "", err
+	}
+	if issueAmount.Amount == 0 {
+		return "", errors.Errorf("amount %s must be positive", amount)
+	}
+	asset, err := restClient.issuedAsset(acc, issueAmount.AssetID.String())
+	if err != nil {
+		return "", err
+	}
+	dynamic, err := restClient.assetDynamicData(asset)
+	if err != nil {
+		return "", err
+	}
+	if supply := gjson.GetBytes(dynamic, "current_supply").Uint(); supply+issueAmount.Amount > uint64(asset.Options.MaxSupply) {
+		return "", errors.Errorf("issue of %s exceeds the max supply of %s", amount, asset.Symbol)
+	}
+
+	op := &AssetIssueOperation{
+		Issuer:         gxcTypes.MustParseObjectID(acc.ID.String()),
+		AssetToIssue:   *issueAmount,
+		IssueToAccount: gxcTypes.MustParseObjectID(toAccount.ID.String()),
+		Memo:           memoOb,
+		Extensions:     []json.RawMessage{},
+	}
+	return restClient.buildOperation("BuildAssetIssue", op, &op.Fee, F("account", issuer), F("to", to),
+		F("symbol", asset.Symbol), F("amount", issueAmount.Amount))
+}
+
+//build an unsigned reserve of amount, a decimal amount and an asset like "100 LOY", held by payer
+func (restClient *RestClient) BuildAssetReserve(payer, amount string) (string, error) {
+	acc, err := restClient.Database.GetAccount(payer)
+	if err != nil {
+		return "", err
+	}
+	reserveAmount, err := restClient.parseAmount(amount)
+	if err != nil {
+		return "", err
+	}
+	if reserveAmount.Amount == 0 {
+		return "", errors.Errorf("amount %s must be positive", amount)
+	}
+	op := &AssetReserveOperation{
+		Payer:           gxcTypes.MustParseObjectID(acc.ID.String()),
+		AmountToReserve: *reserveAmount,
+		Extensions:      []json.RawMessage{},
+	}
+	return restClient.buildOperation("BuildAssetReserve", op, &op.Fee, F("account", payer),
+		F("symbol", reserveAmount.AssetID.String()), F("amount", reserveAmount.Amount))
+}
+
+//build an unsigned funding of the fee pool of the asset symbol with amount,
+//a decimal amount of the core asset like "100 GXC"
+func (restClient *RestClient) BuildAssetFundFeePool(from, symbol, amount string) (string, error) {
+	acc, err := restClient.Database.GetAccount(from)
+	if err != nil {
+		return "", err
+	}
+	asset, err := restClient.getAssetObject(symbol)
+	if err != nil {
+		return "", err
+	}
+	fundAmount, err := restClient.parseCoreAmount(amount)
+	if err != nil {
+		return "", err
+	}
+	op := &AssetFundFeePoolOperation{
+		FromAccount: gxcTypes.MustParseObjectID(acc.ID.String()),
+		AssetId:     asset.ID,
+		Amount:      ShareType(fundAmount.Amount),
+		Extensions:  []json.RawMessage{},
+	}
+	return restClient.buildOperation("BuildAssetFundFeePool", op, &op.Fee, F("account", from), F("symbol", asset.Symbol),
+		F("amount", fundAmount.Amount))
+}
+
+//the asset with its supply, issuer, flags and fee pool
+func (restClient *RestClient) TokenDetail(token string) (*types.Asset, error) {
+	asset, err := restClient.getAssetObject(token)
+	if err != nil {
+		return nil, err
+	}
+	issuers, err := restClient.Database.GetAccountsByIds(asset.Issuer.String())
+	if err != nil {
+		return nil, err
+	}
+	if len(issuers) == 0 || issuers[0] == nil {
+		return nil, errors.Errorf("account %s not exist", asset.Issuer.String())
+	}
+	dynamic, err := restClient.assetDynamicData(asset)
+	if err != nil {
+		return nil, err
+	}
+	rate, err := restClient.coreExchangeRate(asset)
+	if err != nil {
+		return nil, err
+	}
+	return &types.Asset{
+		TokenCode:         asset.Symbol,
+		TokenIdentifier:   asset.ID.String(),
+		TokenDecimal:      asset.Precision,
+		Balance:           0,
+		Issuer:            issuers[0].Name,
+		MaxSupply:         uint64(asset.Options.MaxSupply),
+		CurrentSupply:     gjson.GetBytes(dynamic, "current_supply").Uint(),
+		Flags:             flagNames(asset.Options.Flags),
+		IssuerPermissions: flagNames(asset.Options.IssuerPermissions),
+		Description:       asset.Options.Description,
+		FeePool: &types.FeePool{
+			Balance:          gjson.GetBytes(dynamic, "fee_pool").Uint(),
+			AccumulatedFees:  gjson.GetBytes(dynamic, "accumulated_fees").Uint(),
+			CoreExchangeRate: rate,
+		},
+	}, nil
+}
+
+//decimal amount of the core asset per unit of the asset, empty when the rate is not set
+func (restClient *RestClient) coreExchangeRate(asset *assetObject) (string, error) {
+	base, quote := asset.Options.CoreExchangeRate.Base, asset.Options.CoreExchangeRate.Quote
+	if base.AssetID.String() != asset.ID.String() {
+		base, quote = quote, base
+	}
+	if base.Amount == 0 || quote.Amount == 0 {
+		return "", nil
+	}
+	if quote.AssetID.String() == asset.ID.String() {
+		return "1", nil
+	}
+	quoteAsset, err := restClient.Database.GetAsset(quote.AssetID.String())
+	if err != nil {
+		return "", err
+	}
+	value := decimal.New(int64(quote.Amount), -int32(quoteAsset.Precision)).
+		Div(decimal.New(int64(base.Amount), -int32(asset.Precision)))
+	return value.String(), nil
+}
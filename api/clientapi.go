@@ -1,24 +1,23 @@
 package api
 
 import (
-	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
-	"fmt"
 	"github.com/btcsuite/btcd/btcec"
 	"github.com/btcsuite/btcutil"
 	"github.com/btcsuite/btcutil/base58"
 	"github.com/juju/errors"
 	"gxclient-adapter/types"
 	gxcTypes "gxclient-go/types"
-	"gxclient-go/util"
 	"strconv"
 )
 
 func Deserialize(raw_tx_hex string) ([]*types.Tx, error) {
-	var stx gxcTypes.SignedTransaction
-	json.Unmarshal([]byte(raw_tx_hex), &stx)
+	stx, err := ParseTransaction(raw_tx_hex)
+	if err != nil {
+		return nil, err
+	}
 
 	txs, err := transactionToTx(stx.Transaction)
 	if err != nil {
@@ -78,28 +77,27 @@ func DeserializeMemo(memoPriHex, from, to, message string, nonce gxcTypes.UInt64
 }
 
 func Sign(activePriHex, chainId, raw_tx_hex string) (string, error) {
-	var stx *gxcTypes.SignedTransaction
-	json.Unmarshal([]byte(raw_tx_hex), &stx)
-
-	wif, err := PriKeyHexToWif(activePriHex)
+	signer, err := NewKeySigner(activePriHex)
 	if err != nil {
 		return "", err
 	}
 
-	if err := stx.Sign([]string{wif}, chainId); err != nil {
-		return "", errors.Annotate(err, "failed to sign the transaction")
-	}
-
 	//return signature only
-	return stx.Signatures[0], nil
-	//result, _ := json.Marshal(stx)
-	//return string(result), nil
+	return SignWith(signer, signer.PubKeys()[0], chainId, raw_tx_hex)
 }
 
 func transactionToTx(transaction *gxcTypes.Transaction) ([]*types.Tx, error) {
 	var txs []*types.Tx
-	for _, op := range transaction.Operations {
+	for opIndex, op := range transaction.Operations {
 		if op.Type() != gxcTypes.TransferOpType {
+			tx, err := operationToTxOffline(op)
+			if err != nil {
+				return nil, err
+			}
+			if tx != nil {
+				tx.OpIndex = opIndex
+				txs = append(txs, tx)
+			}
 			continue
 		}
 		var transferOp gxcTypes.TransferOperation
@@ -138,7 +136,13 @@ func transactionToTx(transaction *gxcTypes.Transaction) ([]*types.Tx, error) {
 			TxAt:        "",
 			BlockNumber: 0,
 			ConfirmedAt: "",
-			Extra:       extra,
+			Fee: &types.Fee{
+				Value:           transferOp.Fee.Amount,
+				TokenIdentifier: transferOp.Fee.AssetID.String(),
+			},
+			TrxInBlock: -1,
+			OpIndex:    opIndex,
+			Extra:      extra,
 		}
 		txs = append(txs, tx)
 	}
@@ -146,16 +150,28 @@ func transactionToTx(transaction *gxcTypes.Transaction) ([]*types.Tx, error) {
 }
 
 func PriKeyHexToWif(priHex string) (string, error) {
-	h, err := hex.DecodeString(priHex)
+	pri, err := parsePriKeyHex(priHex)
 	if err != nil {
-		return "", errors.Annotate(err, "DecodeHEX")
+		return "", err
 	}
-	pri, _ := btcec.PrivKeyFromBytes(btcec.S256(), h)
-	raw := append([]byte{128}, pri.D.Bytes()...)
+	raw := append([]byte{128}, pri.Serialize()...)
 	raw = append(raw, checksum(raw)...)
 	return base58.Encode(raw), nil
 }
 
+//private key of 32 bytes in hex
+func parsePriKeyHex(priHex string) (*btcec.PrivateKey, error) {
+	h, err := hex.DecodeString(priHex)
+	if err != nil {
+		return nil, errors.Annotate(err, "DecodeHEX")
+	}
+	if len(h) != btcec.PrivKeyBytesLen {
+		return nil, errors.Errorf("invalid private key length %d, expect %d bytes", len(h), btcec.PrivKeyBytesLen)
+	}
+	pri, _ := btcec.PrivKeyFromBytes(btcec.S256(), h)
+	return pri, nil
+}
+
 func checksum(data []byte) []byte {
 	c1 := sha256.Sum256(data)
 	c2 := sha256.Sum256(c1[:])
@@ -170,52 +186,12 @@ func PriKeyWifToHex(priWif string) (string, error) {
 	return hex.EncodeToString(w.PrivKey.Serialize()), nil
 }
 
+//compressed or uncompressed public key in hex to base58 with the mainnet prefix
 func PubKeyHexToBase58(pubHex string) (string, error) {
-	h, err := hex.DecodeString(pubHex)
-	if err != nil {
-		return "", errors.Annotate(err, "DecodeHEX")
-	}
-	pubKey, _ := btcec.ParsePubKey(h, btcec.S256())
-
-	buf := pubKey.SerializeCompressed()
-	chk, err := util.Ripemd160Checksum(buf)
-	if err != nil {
-		return "", errors.Annotate(err, "Ripemd160Checksum")
-	}
-
-	b := append(pubKey.SerializeCompressed(), chk...)
-	prefixChain := "GXC"
-	return fmt.Sprintf("%s%s", prefixChain, base58.Encode(b)), err
+	return MainNet.PubKeyHexToBase58(pubHex)
 }
 
+//base58 public key with the mainnet prefix to compressed hex
 func PubKeyBase58ToHex(pubBase58 string) (string, error) {
-	prefixChain := "GXC"
-
-	prefix := pubBase58[:len(prefixChain)]
-
-	if prefix != prefixChain {
-		return "", gxcTypes.ErrPublicKeyChainPrefixMismatch
-	}
-
-	b58 := base58.Decode(pubBase58[len(prefixChain):])
-	if len(b58) < 5 {
-		return "", gxcTypes.ErrInvalidPublicKey
-	}
-	chk1 := b58[len(b58)-4:]
-
-	keyBytes := b58[:len(b58)-4]
-	chk2, err := util.Ripemd160Checksum(keyBytes)
-	if err != nil {
-		return "", errors.Annotate(err, "Ripemd160Checksum")
-	}
-	if !bytes.Equal(chk1, chk2) {
-		return "", gxcTypes.ErrInvalidPublicKey
-	}
-
-	pub, err := btcec.ParsePubKey(keyBytes, btcec.S256())
-	if err != nil {
-		return "", errors.Annotate(err, "ParsePubKey")
-	}
-
-	return hex.EncodeToString(pub.SerializeCompressed()), nil
+	return MainNet.PubKeyBase58ToHex(pubBase58)
 }
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/tidwall/gjson"
+	"gxclient-adapter/types"
+	"gxclient-go/transaction"
+	gxcTypes "gxclient-go/types"
+	"strings"
+)
+
+//CallContractOperation calls a method of a contract, the arguments are serialized with the contract ABI
+type CallContractOperation struct {
+	Fee        gxcTypes.AssetAmount `json:"fee"`
+	Account    gxcTypes.ObjectID    `json:"account"`
+	ContractId gxcTypes.ObjectID    `json:"contract_id"`
+	//sent to the contract, only by payable methods
+	Amount     *gxcTypes.AssetAmount `json:"amount,omitempty"`
+	MethodName string                `json:"method_name"`
+	//hex of the serialized arguments
+	Data       string            `json:"data"`
+	Extensions []json.RawMessage `json:"extensions"`
+}
+
+func (op *CallContractOperation) Type() gxcTypes.OpType { return gxcTypes.CallContractOpType }
+
+func (op *CallContractOperation) MarshalTransaction(encoder *transaction.Encoder) error {
+	methodName, err := stringToName(op.MethodName)
+	if err != nil {
+		return err
+	}
+	data, err := hex.DecodeString(op.Data)
+	if err != nil {
+		return errors.Wrap(err, "invalid contract call data")
+	}
+	enc := transaction.NewRollingEncoder(encoder)
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Account)
+	enc.Encode(op.ContractId)
+	if op.Amount != nil {
+		enc.EncodeUVarint(1)
+		enc.Encode(*op.Amount)
+	} else {
+		//Amount?
+		enc.EncodeUVarint(0)
+	}
+	enc.EncodeNumber(methodName)
+	enc.EncodeUVarint(uint64(len(data)))
+	enc.Encode(data)
+	//Extensions
+	enc.EncodeUVarint(0)
+	return enc.Err()
+}
+
+//contract account with its ABI
+type contractAccount struct {
+	id    string
+	name  string
+	codec *abiCodec
+}
+
+//the contract by account name or id, with the ABI read from the raw account object
+func (restClient *RestClient) getContract(contract string) (*contractAccount, error) {
+	id := contract
+	if !strings.HasPrefix(contract, "1.2.") {
+		acc, err := restClient.Database.GetAccount(contract)
+		if err != nil {
+			return nil, err
+		}
+		id = acc.ID.String()
+	}
+	var objects []json.RawMessage
+	if err := restClient.callDatabase("get_objects", []interface{}{[]string{id}}, &objects); err != nil {
+		return nil, errors.Wrapf(err, "failed to get account %s", id)
+	}
+	if len(objects) == 0 || gjson.ParseBytes(objects[0]).Type == gjson.Null {
+		return nil, errors.Errorf("account %s not exist", id)
+	}
+	object := objects[0]
+	if len(gjson.GetBytes(object, "code").String()) == 0 {
+		return nil, errors.Errorf("%s is not a contract", contract)
+	}
+	var abi gxcTypes.Abi
+	if err := json.Unmarshal([]byte(gjson.GetBytes(object, "abi").Raw), &abi); err != nil {
+		return nil, errors.Wrapf(err, "invalid abi of %s", contract)
+	}
+	return &contractAccount{
+		id:    id,
+		name:  gjson.GetBytes(object, "name").String(),
+		codec: newAbiCodec(&abi),
+	}, nil
+}
+
+//build an unsigned call of method of contract, args is a map, a struct or a json string
+//of the method arguments, serialized with the ABI of the contract. attachedAmount is a
+//decimal amount and an asset like "1.5 GXC" sent to a payable method, empty for none
+func (restClient *RestClient) BuildContractCall(from_address, contract, method string, args interface{}, attachedAmount string) (string, error) {
+	fromAccount, err := restClient.Database.GetAccount(from_address)
+	if err != nil {
+		return "", err
+	}
+	contractAcc, err := restClient.getContract(contract)
+	if err != nil {
+		return "", err
+	}
+	action, err := contractAcc.codec.action(method)
+	if err != nil {
+		return "", err
+	}
+	data, err := contractAcc.codec.encodeArgs(action.Type, args)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid arguments of %s", method)
+	}
+
+	var amount *gxcTypes.AssetAmount
+	if len(attachedAmount) > 0 {
+		if !action.Payable {
+			return "", errors.Errorf("method %s is not payable", method)
+		}
+		if amount, err = restClient.parseAmount(attachedAmount); err != nil {
+			return "", err
+		}
+	}
+
+	op := &CallContractOperation{
+		Account:    gxcTypes.MustParseObjectID(fromAccount.ID.String()),
+		ContractId: gxcTypes.MustParseObjectID(contractAcc.id),
+		Amount:     amount,
+		MethodName: method,
+		Data:       hex.EncodeToString(data),
+		Extensions: []json.RawMessage{},
+	}
+	return restClient.buildOperation("BuildContractCall", op, &op.Fee, F("account", from_address),
+		F("contract", contractAcc.name), F("contract_method", method), F("attached", attachedAmount))
+}
+
+//decode the arguments of the call with the ABI of the contract,
+//they are left undecoded when they do not match the ABI
+func (restClient *RestClient) decodeContractCall(op *CallContractOperation, call *types.ContractCall) error {
+	contractAcc, err := restClient.getContract(op.ContractId.String())
+	if err != nil {
+		return err
+	}
+	call.Contract = contractAcc.name
+	if action, err := contractAcc.codec.action(op.MethodName); err != nil {
+		restClient.log().Log(LevelWarn, "failed to decode contract call", F("method", "TransactionToTx"), F("contract", contractAcc.name), F("error", err))
+	} else if data, err := hex.DecodeString(op.Data); err != nil {
+		restClient.log().Log(LevelWarn, "failed to decode contract call", F("method", "TransactionToTx"), F("contract", contractAcc.name), F("error", err))
+	} else if call.Args, err = contractAcc.codec.decodeArgs(action.Type, data); err != nil {
+		restClient.log().Log(LevelWarn, "failed to decode contract call", F("method", "TransactionToTx"), F("contract", contractAcc.name), F("error", err))
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package api
+
+import (
+	"github.com/pkg/errors"
+	"gxclient-adapter/types"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+//default tag format, the whole trimmed memo without blanks
+const DefaultTagFormat = `^(\S+)$`
+
+//reasons of unattributed deposits
+const (
+	ReasonNoMemo       = "no memo"
+	ReasonUndecrypted  = "memo not decrypted"
+	ReasonMalformedTag = "malformed tag"
+)
+
+//Deposit is an incoming transfer to a hot account
+type Deposit struct {
+	Tx      *types.Tx `json:"tx"`
+	Account string    `json:"account"`
+	Tag     string    `json:"tag,omitempty"`
+	Memo    string    `json:"memo,omitempty"`
+	Reason  string    `json:"reason,omitempty"`
+}
+
+//DepositRouter scans irreversible blocks and attributes the transfers to
+//the hot accounts by the user tag in the decrypted memo, memo keys of the
+//hot accounts must be set with RestClient.SetMemoKeys
+type DepositRouter struct {
+	client      *RestClient
+	hotAccounts map[string]bool
+	tagFormat   *regexp.Regexp
+
+	//Deposits receives deposits with a valid tag
+	Deposits chan *Deposit
+	//Unattributed receives deposits with missing or malformed tags
+	Unattributed chan *Deposit
+	//Interval to poll new blocks
+	Interval time.Duration
+
+	//read by NextBlock while Run writes it
+	nextBlock uint32
+	//closed to stop a send on a full channel, set by Run
+	stop <-chan struct{}
+}
+
+//tagFormat is a regexp matched against the trimmed memo, the first
+//submatch (or the whole match without groups) is the tag
+func (restClient *RestClient) NewDepositRouter(hotAccounts []string, tagFormat string) (*DepositRouter, error) {
+	if len(hotAccounts) == 0 {
+		return nil, errors.New("no hot account")
+	}
+	if len(tagFormat) == 0 {
+		tagFormat = DefaultTagFormat
+	}
+	format, err := regexp.Compile(tagFormat)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid tag format")
+	}
+	accounts := map[string]bool{}
+	for _, account := range hotAccounts {
+		accounts[account] = true
+	}
+	return &DepositRouter{
+		client:       restClient,
+		hotAccounts:  accounts,
+		tagFormat:    format,
+		Deposits:     make(chan *Deposit, 100),
+		Unattributed: make(chan *Deposit, 100),
+		Interval:     3 * time.Second,
+	}, nil
+}
+
+//next block to scan
+func (router *DepositRouter) NextBlock() uint32 {
+	return atomic.LoadUint32(&router.nextBlock)
+}
+
+//send deposit to ch, false when stop is closed before ch has room
+func (router *DepositRouter) send(ch chan *Deposit, deposit *Deposit) bool {
+	select {
+	case ch <- deposit:
+		return true
+	case <-router.stop:
+		return false
+	}
+}
+
+//route the transfers of the given block. The sends wait for room in the channels,
+//when Run is stopped meanwhile the block is not done and is routed again by the next Run
+func (router *DepositRouter) RouteBlock(block_no uint32) error {
+	txs, err := router.client.GetBlockTxs(block_no)
+	if err != nil {
+		return err
+	}
+	for _, tx := range txs {
+		if tx.BlockNumber == 0 {
+			tx.BlockNumber = int64(block_no)
+		}
+		if deposit := router.Route(tx); deposit != nil {
+			if len(deposit.Reason) > 0 {
+				router.client.log().Log(LevelWarn, "unattributed deposit", F("method", "RouteBlock"), F("account", deposit.Account),
+					F("tx_hash", tx.TxHash), F("reason", deposit.Reason))
+				if !router.send(router.Unattributed, deposit) {
+					return nil
+				}
+			} else {
+				router.client.log().Log(LevelInfo, "deposit", F("method", "RouteBlock"), F("account", deposit.Account),
+					F("tx_hash", tx.TxHash), F("tag", deposit.Tag))
+				if !router.send(router.Deposits, deposit) {
+					return nil
+				}
+			}
+		}
+	}
+	atomic.StoreUint32(&router.nextBlock, block_no+1)
+	return nil
+}
+
+//attribute the transfer, nil if it is not a deposit
+func (router *DepositRouter) Route(tx *types.Tx) *Deposit {
+	//only transfers are deposits
+	if len(tx.OpType) > 0 || len(tx.Inputs) == 0 || len(tx.Outputs) == 0 {
+		return nil
+	}
+	to := tx.Outputs[0].Address
+	//transfers between hot accounts are not deposits
+	if !router.hotAccounts[to] || router.hotAccounts[tx.Inputs[0].Address] {
+		return nil
+	}
+
+	deposit := &Deposit{Tx: tx, Account: to}
+	memo, decrypted := tx.Extra["memo"]
+	switch {
+	case len(tx.Extra["message"]) == 0:
+		deposit.Reason = ReasonNoMemo
+	case !decrypted:
+		deposit.Reason = ReasonUndecrypted
+	default:
+		deposit.Memo = memo
+		deposit.Tag = router.ParseTag(memo)
+		if len(deposit.Tag) == 0 {
+			deposit.Reason = ReasonMalformedTag
+		}
+	}
+	return deposit
+}
+
+//user tag in the memo, empty if malformed
+func (router *DepositRouter) ParseTag(memo string) string {
+	match := router.tagFormat.FindStringSubmatch(strings.TrimSpace(memo))
+	if match == nil {
+		return ""
+	}
+	if len(match) > 1 {
+		return match[1]
+	}
+	return match[0]
+}
+
+//scan irreversible blocks from block_no until stop is closed
+func (router *DepositRouter) Run(block_no uint32, stop <-chan struct{}) error {
+	router.stop = stop
+	atomic.StoreUint32(&router.nextBlock, block_no)
+	for {
+		lib, err := router.client.GetBlockCount()
+		if err != nil {
+			return err
+		}
+		for router.NextBlock() <= lib {
+			select {
+			case <-stop:
+				return nil
+			default:
+			}
+			if err := router.RouteBlock(router.NextBlock()); err != nil {
+				return err
+			}
+		}
+		select {
+		case <-stop:
+			return nil
+		case <-time.After(router.Interval):
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"gxclient-adapter/types"
+)
+
+//set the direction and the net amounts of the transfer relative to address,
+//the sender pays the fee, txs not involving address are left untouched
+func SetDirection(tx *types.Tx, address string) {
+	if len(tx.Inputs) == 0 || len(tx.Outputs) == 0 {
+		return
+	}
+	in, out := tx.Inputs[0], tx.Outputs[0]
+	from, to := in.Address == address, out.Address == address
+	switch {
+	case from && to:
+		tx.Direction = types.DirectionSelf
+	case from:
+		tx.Direction = types.DirectionOutgoing
+	case to:
+		tx.Direction = types.DirectionIncoming
+	default:
+		return
+	}
+
+	var netAmounts []types.NetAmount
+	add := func(value int64, tokenCode, tokenIdentifier string, tokenDecimal uint8) {
+		for i := range netAmounts {
+			if netAmounts[i].TokenIdentifier == tokenIdentifier {
+				netAmounts[i].Value += value
+				return
+			}
+		}
+		netAmounts = append(netAmounts, types.NetAmount{
+			Value:           value,
+			TokenCode:       tokenCode,
+			TokenIdentifier: tokenIdentifier,
+			TokenDecimal:    tokenDecimal,
+		})
+	}
+	if from {
+		add(-int64(in.Value), in.TokenCode, in.TokenIdentifier, in.TokenDecimal)
+		if tx.Fee != nil {
+			add(-int64(tx.Fee.Value), tx.Fee.TokenCode, tx.Fee.TokenIdentifier, tx.Fee.TokenDecimal)
+		}
+	}
+	if to {
+		add(int64(out.Value), out.TokenCode, out.TokenIdentifier, out.TokenDecimal)
+	}
+
+	tx.NetAmounts = nil
+	for _, netAmount := range netAmounts {
+		if netAmount.Value != 0 {
+			tx.NetAmounts = append(tx.NetAmounts, netAmount)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"gxclient-adapter/types"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//export formats
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+//operation histories fetched per page, the node limit
+const historyPageSize = 100
+
+//time format of the block timestamps in Tx.TxAt
+const blockTimeFormat = "2006-01-02T15:04:05"
+
+//ExportRecord is a row of the accounting export, amounts are decimal strings
+type ExportRecord struct {
+	Time         string `json:"time"`
+	BlockNumber  int64  `json:"block_no"`
+	HistoryID    string `json:"history_id"`
+	Direction    string `json:"direction"`
+	Counterparty string `json:"counterparty"`
+	Amount       string `json:"amount"`
+	Asset        string `json:"asset"`
+	//empty when the counterparty paid the fee
+	Fee      string `json:"fee"`
+	FeeAsset string `json:"fee_asset"`
+	//decrypted memo, empty without a matching memo key
+	Memo   string `json:"memo"`
+	Status string `json:"status"`
+}
+
+var exportHeader = []string{"time", "block_no", "history_id", "direction", "counterparty", "amount", "asset", "fee", "fee_asset", "memo", "status"}
+
+func (record *ExportRecord) row() []string {
+	return []string{record.Time, strconv.FormatInt(record.BlockNumber, 10), record.HistoryID, record.Direction, record.Counterparty,
+		record.Amount, record.Asset, record.Fee, record.FeeAsset, record.Memo, record.Status}
+}
+
+func formatDecimal(value uint64, precision uint8) string {
+	return decimal.New(int64(value), -int32(precision)).StringFixed(int32(precision))
+}
+
+//export record of a history tx of address
+func NewExportRecord(tx *types.Tx, address string) *ExportRecord {
+	in, out := tx.Inputs[0], tx.Outputs[0]
+	record := &ExportRecord{
+		Time:        tx.TxAt,
+		BlockNumber: tx.BlockNumber,
+		HistoryID:   tx.HistoryID,
+		Direction:   string(tx.Direction),
+		Amount:      formatDecimal(in.Value, in.TokenDecimal),
+		Asset:       in.TokenCode,
+		Memo:        tx.Extra["memo"],
+		Status:      string(tx.Status),
+	}
+	if in.Address == address {
+		record.Counterparty = out.Address
+		if tx.Fee != nil {
+			record.Fee = formatDecimal(tx.Fee.Value, tx.Fee.TokenDecimal)
+			record.FeeAsset = tx.Fee.TokenCode
+		}
+	} else {
+		record.Counterparty = in.Address
+	}
+	return record
+}
+
+//recordWriter streams the records in one format
+type recordWriter interface {
+	write(record *ExportRecord) error
+	flush() error
+}
+
+type csvRecordWriter struct {
+	w *csv.Writer
+}
+
+func (cw *csvRecordWriter) write(record *ExportRecord) error {
+	return cw.w.Write(record.row())
+}
+
+func (cw *csvRecordWriter) flush() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+type jsonlRecordWriter struct {
+	enc *json.Encoder
+}
+
+func (jw *jsonlRecordWriter) write(record *ExportRecord) error {
+	return jw.enc.Encode(record)
+}
+
+func (jw *jsonlRecordWriter) flush() error {
+	return nil
+}
+
+func newRecordWriter(w io.Writer, format string) (recordWriter, error) {
+	switch strings.ToLower(format) {
+	case FormatCSV:
+		cw := &csvRecordWriter{csv.NewWriter(w)}
+		if err := cw.w.Write(exportHeader); err != nil {
+			return nil, err
+		}
+		return cw, nil
+	case FormatJSONL:
+		return &jsonlRecordWriter{json.NewEncoder(w)}, nil
+	}
+	return nil, errors.Errorf("unknown export format %s", format)
+}
+
+//write the transfers of address in [from, to) to w as csv or jsonl, newest first,
+//a zero from or to leaves the range open, memos are decrypted with the keys set by
+//SetMemoKeys; the history is walked page by page and each page is written as soon as
+//it is fetched. Returns the number of records written
+func (restClient *RestClient) ExportHistory(w io.Writer, address string, from, to time.Time, format string) (int, error) {
+	rw, err := newRecordWriter(w, format)
+	if err != nil {
+		return 0, err
+	}
+	acc, err := restClient.Database.GetAccount(address)
+	if err != nil {
+		return 0, err
+	}
+	props, err := restClient.getProperties()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	cache := newHistoryCache()
+	start := "1.11.0"
+	for {
+		ophs, err := restClient.History.GetAccountHistory(acc.ID.String(), "1.11.0", historyPageSize, start)
+		if err != nil {
+			return count, err
+		}
+		if len(ophs) == 0 {
+			break
+		}
+		txs, err := restClient.historyToTxs(acc, ophs, props, cache)
+		if err != nil {
+			return count, err
+		}
+		done := false
+		for _, tx := range txs {
+			at, err := time.Parse(blockTimeFormat, tx.TxAt)
+			if err != nil {
+				return count, errors.Wrapf(err, "invalid time of %s", tx.HistoryID)
+			}
+			if !to.IsZero() && !at.Before(to) {
+				continue
+			}
+			if !from.IsZero() && at.Before(from) {
+				done = true
+				break
+			}
+			if err := rw.write(NewExportRecord(tx, acc.Name)); err != nil {
+				return count, err
+			}
+			count++
+		}
+		if err := rw.flush(); err != nil {
+			return count, err
+		}
+
+		oldest := ophs[len(ophs)-1]
+		instance := objectInstance(oldest.ID)
+		//1.11.0 is never returned, as a start it means the most recent
+		if done || len(ophs) < historyPageSize || instance <= 1 {
+			break
+		}
+		//pages older than from are not fetched
+		if !from.IsZero() {
+			header, err := restClient.Database.GetBlockHeader(oldest.BlockNumber)
+			if err != nil {
+				return count, err
+			}
+			if header.Timestamp.Time != nil && header.Timestamp.Before(from) {
+				break
+			}
+		}
+		start = "1.11." + strconv.FormatUint(instance-1, 10)
+	}
+	restClient.log().Log(LevelInfo, "history exported", F("method", "ExportHistory"), F("account", acc.Name), F("records", count))
+	return count, rw.flush()
+}
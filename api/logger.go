@@ -0,0 +1,144 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	default:
+		return "error"
+	}
+}
+
+//Field is a structured log field, e.g. method, account or tx_hash
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+func F(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+//Logger is a levelled structured logger set by RestClient.SetLogger
+type Logger interface {
+	Log(level Level, msg string, fields ...Field)
+}
+
+const redacted = "[REDACTED]"
+
+//field keys holding private key material, whole snake_case segments so that
+//price, priority or the public memo_key are kept
+var secretKey = regexp.MustCompile(`(?i)(^|_)(pri|priv|private|wif|secret|seed|password|brain)(_|$)`)
+
+//field keys of private key material with no such segment
+var secretKeys = map[string]bool{
+	"key":        true,
+	"prikey":     true,
+	"privkey":    true,
+	"privatekey": true,
+	"wifkey":     true,
+	"secretkey":  true,
+}
+
+//values that look like a WIF private key
+var wifValue = regexp.MustCompile(`^5[HJK][1-9A-HJ-NP-Za-km-z]{49}$`)
+
+//redact key-related field values
+func redact(fields []Field) []Field {
+	result := make([]Field, len(fields))
+	for i, field := range fields {
+		result[i] = field
+		if secretKey.MatchString(field.Key) || secretKeys[strings.ToLower(field.Key)] {
+			result[i].Value = redacted
+		} else if s, ok := field.Value.(string); ok && wifValue.MatchString(s) {
+			result[i].Value = redacted
+		}
+	}
+	return result
+}
+
+//redactLogger redacts the fields before passing them to the wrapped logger
+type redactLogger struct {
+	logger Logger
+}
+
+//RedactLogger wraps logger to redact key-related field values,
+//loggers set by SetLogger are always wrapped
+func RedactLogger(logger Logger) Logger {
+	if _, ok := logger.(redactLogger); ok {
+		return logger
+	}
+	return redactLogger{logger}
+}
+
+func (l redactLogger) Log(level Level, msg string, fields ...Field) {
+	l.logger.Log(level, msg, redact(fields)...)
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Log(level Level, msg string, fields ...Field) {}
+
+//TextLogger writes logfmt lines of the given level and above
+type TextLogger struct {
+	mu    sync.Mutex
+	w     io.Writer
+	level Level
+}
+
+func NewTextLogger(w io.Writer, level Level) *TextLogger {
+	return &TextLogger{w: w, level: level}
+}
+
+func (l *TextLogger) Log(level Level, msg string, fields ...Field) {
+	if level < l.level {
+		return
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "time=%s level=%s msg=%q", time.Now().UTC().Format(time.RFC3339), level, msg)
+	for _, field := range fields {
+		fmt.Fprintf(&b, " %s=%q", field.Key, fmt.Sprint(field.Value))
+	}
+	b.WriteByte('\n')
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	io.WriteString(l.w, b.String())
+}
+
+//set the logger of the client, key-related fields are redacted, nil disables logging
+func (restClient *RestClient) SetLogger(logger Logger) {
+	if logger == nil {
+		restClient.logger = nil
+		return
+	}
+	restClient.logger = RedactLogger(logger)
+}
+
+func (restClient *RestClient) log() Logger {
+	if restClient.logger == nil {
+		return nopLogger{}
+	}
+	return restClient.logger
+}
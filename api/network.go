@@ -0,0 +1,192 @@
+package api
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/juju/errors"
+	gxcTypes "gxclient-go/types"
+	"gxclient-go/util"
+)
+
+//NetworkConfig holds the parameters of a graphene network
+type NetworkConfig struct {
+	Name string `json:"name"`
+	//empty to accept the chain id of any node
+	ChainId string `json:"chain_id"`
+	//address prefix of the public keys
+	Prefix string `json:"prefix"`
+	//symbol and id of the core asset, the default transfer and fee asset
+	CoreAsset   string `json:"core_asset"`
+	CoreAssetId string `json:"core_asset_id"`
+	//accounts of the transfer used to estimate the fee
+	FeeFromAccountId string `json:"fee_from_account_id"`
+	FeeToAccountId   string `json:"fee_to_account_id"`
+}
+
+//MainNet is the GXChain main network
+var MainNet = &NetworkConfig{
+	Name:             "mainnet",
+	ChainId:          "4f7d07969c446f8342033acb3ab2ae5044cbe0fde93db02de75bd17fa8fd84b8",
+	Prefix:           "GXC",
+	CoreAsset:        "GXC",
+	CoreAssetId:      "1.3.1",
+	FeeFromAccountId: "1.2.6",
+	FeeToAccountId:   "1.2.7",
+}
+
+//TestNet is the GXChain test network
+var TestNet = &NetworkConfig{
+	Name:             "testnet",
+	ChainId:          "c2af30ef9340ff81fd61654295e98a1ff04b23189748f86727d0b26b40bb0ff4",
+	Prefix:           "GXC",
+	CoreAsset:        "GXC",
+	CoreAssetId:      "1.3.1",
+	FeeFromAccountId: "1.2.6",
+	FeeToAccountId:   "1.2.7",
+}
+
+//Networks are the known networks by name
+var Networks = map[string]*NetworkConfig{
+	MainNet.Name: MainNet,
+	TestNet.Name: TestNet,
+}
+
+//known network by name
+func NetworkByName(name string) (*NetworkConfig, error) {
+	network, ok := Networks[name]
+	if !ok {
+		return nil, errors.Errorf("unknown network %s", name)
+	}
+	return network, nil
+}
+
+//known network by chain id, the parameters of another chain are not guessed,
+//see CustomNetwork
+func NetworkByChainId(chainId string) (*NetworkConfig, error) {
+	for _, network := range Networks {
+		if network.ChainId == chainId {
+			return network, nil
+		}
+	}
+	return nil, errors.Errorf("unknown chain id %s, set a custom network", chainId)
+}
+
+//CustomNetwork returns a copy of the mainnet parameters with the given chain id and prefix,
+//change the other fields of the copy for chains with another core asset
+func CustomNetwork(chainId, prefix string) *NetworkConfig {
+	custom := *MainNet
+	custom.Name = "custom"
+	custom.ChainId = chainId
+	custom.Prefix = prefix
+	return &custom
+}
+
+//network by name, or a custom network when the chain id is set, nil when both are empty to detect it,
+//the empty custom parameters keep the mainnet values
+func SelectNetwork(name, chainId, prefix, coreAsset, coreAssetId string) (*NetworkConfig, error) {
+	if len(chainId) == 0 {
+		if len(prefix) > 0 || len(coreAsset) > 0 || len(coreAssetId) > 0 {
+			return nil, errors.New("a custom network needs its chain id")
+		}
+		if len(name) == 0 {
+			return nil, nil
+		}
+		return NetworkByName(name)
+	}
+	if len(name) > 0 {
+		return nil, errors.Errorf("network %s and a custom chain id are exclusive", name)
+	}
+	if len(prefix) == 0 {
+		prefix = MainNet.Prefix
+	}
+	network := CustomNetwork(chainId, prefix)
+	if len(coreAsset) > 0 {
+		network.CoreAsset = coreAsset
+	}
+	if len(coreAssetId) > 0 {
+		if _, err := gxcTypes.ParseObjectID(coreAssetId); err != nil {
+			return nil, errors.Annotatef(err, "core asset id %s", coreAssetId)
+		}
+		network.CoreAssetId = coreAssetId
+	}
+	return network, nil
+}
+
+//error unless the chain id of the node is the one of the network
+func (network *NetworkConfig) CheckChainId(chainId string) error {
+	if len(network.ChainId) > 0 && network.ChainId != chainId {
+		return errors.Errorf("chain id mismatch: node %s, network %s expects %s", chainId, network.Name, network.ChainId)
+	}
+	return nil
+}
+
+//sign the transaction with the chain id of the network
+func (network *NetworkConfig) Sign(activePriHex, raw_tx_hex string) (string, error) {
+	if len(network.ChainId) == 0 {
+		return "", errors.Errorf("network %s has no chain id", network.Name)
+	}
+	return Sign(activePriHex, network.ChainId, raw_tx_hex)
+}
+
+//length of the ripemd160 checksum after the key bytes
+const pubKeyChecksumLen = 4
+
+//compressed (33 bytes) or uncompressed (65 bytes) public key in hex to base58 with the network prefix
+func (network *NetworkConfig) PubKeyHexToBase58(pubHex string) (string, error) {
+	h, err := hex.DecodeString(pubHex)
+	if err != nil {
+		return "", errors.Annotate(err, "DecodeHEX")
+	}
+	if len(h) != btcec.PubKeyBytesLenCompressed && len(h) != btcec.PubKeyBytesLenUncompressed {
+		return "", errors.Errorf("invalid public key length %d, expect %d or %d bytes",
+			len(h), btcec.PubKeyBytesLenCompressed, btcec.PubKeyBytesLenUncompressed)
+	}
+	pubKey, err := btcec.ParsePubKey(h, btcec.S256())
+	if err != nil {
+		return "", errors.Annotate(err, "ParsePubKey")
+	}
+
+	buf := pubKey.SerializeCompressed()
+	chk, err := util.Ripemd160Checksum(buf)
+	if err != nil {
+		return "", errors.Annotate(err, "Ripemd160Checksum")
+	}
+
+	b := append(buf, chk...)
+	return fmt.Sprintf("%s%s", network.Prefix, base58.Encode(b)), nil
+}
+
+//base58 public key with the network prefix to compressed hex
+func (network *NetworkConfig) PubKeyBase58ToHex(pubBase58 string) (string, error) {
+	if len(pubBase58) <= len(network.Prefix) {
+		return "", gxcTypes.ErrInvalidPublicKey
+	}
+	if pubBase58[:len(network.Prefix)] != network.Prefix {
+		return "", gxcTypes.ErrPublicKeyChainPrefixMismatch
+	}
+
+	b58 := base58.Decode(pubBase58[len(network.Prefix):])
+	if len(b58) != btcec.PubKeyBytesLenCompressed+pubKeyChecksumLen {
+		return "", gxcTypes.ErrInvalidPublicKey
+	}
+	chk1 := b58[len(b58)-pubKeyChecksumLen:]
+
+	keyBytes := b58[:len(b58)-pubKeyChecksumLen]
+	chk2, err := util.Ripemd160Checksum(keyBytes)
+	if err != nil {
+		return "", errors.Annotate(err, "Ripemd160Checksum")
+	}
+	if !bytes.Equal(chk1, chk2) {
+		return "", gxcTypes.ErrInvalidPublicKey
+	}
+
+	pub, err := btcec.ParsePubKey(keyBytes, btcec.S256())
+	if err != nil {
+		return "", errors.Annotate(err, "ParsePubKey")
+	}
+
+	return hex.EncodeToString(pub.SerializeCompressed()), nil
+}
@@ -0,0 +1,340 @@
+package api
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"gxclient-adapter/metrics"
+	"gxclient-adapter/types"
+	gxcTypes "gxclient-go/types"
+	"strconv"
+)
+
+//operation types gxclient-go gets wrong, its types/optype.go declares
+//AccountCreateOpType = 5 and the constants after it repeat the 5 instead of
+//counting on, the gxcTypes constants are used for the other operations
+const (
+	AccountUpdateOpType    gxcTypes.OpType = 6
+	AssetCreateOpType      gxcTypes.OpType = 10
+	AssetUpdateOpType      gxcTypes.OpType = 11
+	AssetIssueOpType       gxcTypes.OpType = 14
+	AssetReserveOpType     gxcTypes.OpType = 15
+	AssetFundFeePoolOpType gxcTypes.OpType = 16
+	ProposalCreateOpType   gxcTypes.OpType = 22
+	ProposalUpdateOpType   gxcTypes.OpType = 23
+	BalanceLockOpType      gxcTypes.OpType = 71
+	BalanceUnlockOpType    gxcTypes.OpType = 72
+)
+
+//operations gxclient-go leaves unknown, decoded by the adapter
+var operations = map[gxcTypes.OpType]func() gxcTypes.Operation{
+	gxcTypes.CallContractOpType: func() gxcTypes.Operation { return &CallContractOperation{} },
+	AccountUpdateOpType:         func() gxcTypes.Operation { return &AccountUpdateOperation{} },
+	AssetCreateOpType:           func() gxcTypes.Operation { return &AssetCreateOperation{} },
+	AssetUpdateOpType:           func() gxcTypes.Operation { return &AssetUpdateOperation{} },
+	AssetIssueOpType:            func() gxcTypes.Operation { return &AssetIssueOperation{} },
+	AssetReserveOpType:          func() gxcTypes.Operation { return &AssetReserveOperation{} },
+	AssetFundFeePoolOpType:      func() gxcTypes.Operation { return &AssetFundFeePoolOperation{} },
+	ProposalCreateOpType:        func() gxcTypes.Operation { return &ProposalCreateOperation{} },
+	ProposalUpdateOpType:        func() gxcTypes.Operation { return &ProposalUpdateOperation{} },
+	BalanceLockOpType:           func() gxcTypes.Operation { return &BalanceLockOperation{} },
+	BalanceUnlockOpType:         func() gxcTypes.Operation { return &BalanceUnlockOperation{} },
+}
+
+//decode op with the adapter types when gxclient-go does not know it,
+//the unknown operations can not be serialized for signing
+func resolveOperation(op gxcTypes.Operation) (gxcTypes.Operation, error) {
+	unknown, ok := op.(*gxcTypes.UnknownOperation)
+	if !ok {
+		return op, nil
+	}
+	newOp := operations[op.Type()]
+	if newOp == nil {
+		return op, nil
+	}
+	data, _ := unknown.Data().(*json.RawMessage)
+	if data == nil {
+		return nil, errors.Errorf("empty operation %d", op.Type())
+	}
+	resolved := newOp()
+	if err := json.Unmarshal(*data, resolved); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal operation %d", op.Type())
+	}
+	return resolved, nil
+}
+
+func resolveOperations(trx *gxcTypes.Transaction) error {
+	for i, op := range trx.Operations {
+		resolved, err := resolveOperation(op)
+		if err != nil {
+			return err
+		}
+		trx.Operations[i] = resolved
+	}
+	return nil
+}
+
+//ParseTransaction parses the json of a signed or unsigned transaction,
+//including the operations only the adapter knows
+func ParseTransaction(raw_tx string) (*gxcTypes.SignedTransaction, error) {
+	var stx *gxcTypes.SignedTransaction
+	if err := json.Unmarshal([]byte(raw_tx), &stx); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the transaction")
+	}
+	if stx == nil || stx.Transaction == nil {
+		return nil, errors.New("empty transaction")
+	}
+	if err := resolveOperations(stx.Transaction); err != nil {
+		return nil, err
+	}
+	return stx, nil
+}
+
+//build an unsigned transaction of op paying fee, pointing into op, in the core asset
+func (restClient *RestClient) buildOperation(method string, op gxcTypes.Operation, fee *gxcTypes.AssetAmount, fields ...Field) (string, error) {
+	feeAsset, err := restClient.Database.GetAsset(restClient.Network().CoreAsset)
+	if err != nil {
+		return "", err
+	}
+	fee.AssetID = feeAsset.ID
+	fees, err := restClient.Database.GetRequiredFee([]gxcTypes.Operation{op}, feeAsset.ID.String())
+	if err != nil {
+		return "", err
+	}
+	fee.Amount = fees[0].Amount
+
+	stx, err := restClient.newTransaction(op)
+	if err != nil {
+		return "", err
+	}
+	if err := restClient.logEncoded(stx, method); err != nil {
+		return "", err
+	}
+
+	str, _ := json.Marshal(stx)
+	metrics.Transactions.Inc(metrics.StageBuilt)
+	fields = append([]Field{F("method", method)}, fields...)
+	restClient.log().Log(LevelInfo, "transaction built", append(fields, F("fee", fee.Amount))...)
+	return string(str), nil
+}
+
+//opSummary is what a tx shows of an operation other than a transfer
+type opSummary struct {
+	opType string
+	//the account paying, and the account receiving the amount
+	from, to gxcTypes.ObjectID
+	//nil when no amount moves
+	amount *gxcTypes.AssetAmount
+	//the amount is minted to to, from is not debited
+	minted bool
+	//the amount leaves from without reaching to, burned or paid into a fee pool
+	burned      bool
+	fee         gxcTypes.AssetAmount
+	contract    *types.ContractCall
+	staking     *types.Staking
+	assetChange *types.AssetChange
+	account     *types.AccountUpdate
+	//the proposed operations are added by the callers
+	proposal *types.Proposal
+}
+
+//summary of a resolved operation, nil for the operations txs do not show,
+//coreAssetId is the asset of the amounts the chain keeps in the core asset
+func summarize(op gxcTypes.Operation, coreAssetId gxcTypes.ObjectID) *opSummary {
+	switch op := op.(type) {
+	case *CallContractOperation:
+		return &opSummary{opType: types.OpCallContract, from: op.Account, to: op.ContractId, amount: op.Amount, fee: op.Fee,
+			contract: &types.ContractCall{ContractId: op.ContractId.String(), Method: op.MethodName, Data: op.Data}}
+	case *gxcTypes.StakingCreateOperation:
+		amount := op.Amount
+		return &opSummary{opType: types.OpStakingCreate, from: op.Owner, to: op.Owner, amount: &amount, fee: op.Fee,
+			staking: &types.Staking{ProgramId: op.ProgramId, TrustNode: op.TrustNode.String(), Weight: op.Weight, StakingDays: op.StakingDays}}
+	case *gxcTypes.StakingUpdateOperation:
+		return &opSummary{opType: types.OpStakingUpdate, from: op.Owner, to: op.Owner, fee: op.Fee,
+			staking: &types.Staking{Id: op.StakingId.String(), TrustNode: op.TrustNode.String()}}
+	case *gxcTypes.StakingClaimOperation:
+		return &opSummary{opType: types.OpStakingClaim, from: op.Owner, to: op.Owner, fee: op.Fee,
+			staking: &types.Staking{Id: op.StakingId.String()}}
+	case *BalanceLockOperation:
+		amount := op.Amount
+		return &opSummary{opType: types.OpBalanceLock, from: op.Account, to: op.Account, amount: &amount, fee: op.Fee,
+			staking: &types.Staking{ProgramId: op.ProgramId, LockDays: op.LockDays, InterestRate: op.InterestRate, Memo: op.Memo}}
+	case *BalanceUnlockOperation:
+		return &opSummary{opType: types.OpBalanceUnlock, from: op.Account, to: op.Account, fee: op.Fee,
+			staking: &types.Staking{Id: op.LockId.String()}}
+	case *AccountUpdateOperation:
+		update := &types.AccountUpdate{Owner: op.Owner.toTx(), Active: op.Active.toTx()}
+		if op.NewOptions != nil {
+			update.MemoKey = op.NewOptions.MemoKey.String()
+		}
+		return &opSummary{opType: types.OpAccountUpdate, from: op.Account, to: op.Account, fee: op.Fee, account: update}
+	case *AssetCreateOperation:
+		return &opSummary{opType: types.OpAssetCreate, from: op.Issuer, to: op.Issuer, fee: op.Fee,
+			assetChange: &types.AssetChange{Symbol: op.Symbol, Precision: op.Precision, MaxSupply: uint64(op.CommonOptions.MaxSupply),
+				Flags: flagNames(op.CommonOptions.Flags), Description: op.CommonOptions.Description}}
+	case *AssetUpdateOperation:
+		change := &types.AssetChange{AssetId: op.AssetToUpdate.String(), MaxSupply: uint64(op.NewOptions.MaxSupply),
+			Flags: flagNames(op.NewOptions.Flags), Description: op.NewOptions.Description}
+		if op.NewIssuer != nil {
+			change.NewIssuer = op.NewIssuer.String()
+		}
+		return &opSummary{opType: types.OpAssetUpdate, from: op.Issuer, to: op.Issuer, fee: op.Fee, assetChange: change}
+	case *AssetIssueOperation:
+		amount := op.AssetToIssue
+		return &opSummary{opType: types.OpAssetIssue, from: op.Issuer, to: op.IssueToAccount, amount: &amount, minted: true, fee: op.Fee}
+	case *AssetReserveOperation:
+		amount := op.AmountToReserve
+		return &opSummary{opType: types.OpAssetReserve, from: op.Payer, to: op.Payer, amount: &amount, burned: true, fee: op.Fee}
+	case *AssetFundFeePoolOperation:
+		amount := gxcTypes.AssetAmount{Amount: uint64(op.Amount), AssetID: coreAssetId}
+		return &opSummary{opType: types.OpAssetFundFeePool, from: op.FromAccount, to: op.FromAccount, amount: &amount, burned: true, fee: op.Fee,
+			assetChange: &types.AssetChange{AssetId: op.AssetId.String(), FeePoolAmount: uint64(op.Amount)}}
+	case *ProposalCreateOperation:
+		proposal := &types.Proposal{Expiration: op.ExpirationTime.Format(blockTimeFormat)}
+		if op.ReviewPeriodSeconds != nil {
+			proposal.ReviewPeriodSeconds = *op.ReviewPeriodSeconds
+		}
+		return &opSummary{opType: types.OpProposalCreate, from: op.FeePayingAccount, to: op.FeePayingAccount, fee: op.Fee, proposal: proposal}
+	case *ProposalUpdateOperation:
+		return &opSummary{opType: types.OpProposalUpdate, from: op.FeePayingAccount, to: op.FeePayingAccount, fee: op.Fee,
+			proposal: &types.Proposal{Id: op.Proposal.String(),
+				ApprovalsToAdd:    proposalApprovals(op.ActiveApprovalsToAdd, op.OwnerApprovalsToAdd, op.KeyApprovalsToAdd),
+				ApprovalsToRemove: proposalApprovals(op.ActiveApprovalsToRemove, op.OwnerApprovalsToRemove, op.KeyApprovalsToRemove)}}
+	}
+	return nil
+}
+
+//clear the side of the amount that does not move, a minted amount is not
+//debited from in and a burned one not credited to out
+func (summary *opSummary) settle(in, out *types.UTXO) {
+	if summary.minted {
+		in.Value = 0
+	}
+	if summary.burned {
+		out.Value = 0
+	}
+}
+
+//tx of an operation other than a transfer with the account names and the assets,
+//nil for the operations txs do not show
+func (restClient *RestClient) operationToTx(op gxcTypes.Operation) (*types.Tx, error) {
+	op, err := resolveOperation(op)
+	if err != nil {
+		return nil, err
+	}
+	summary := summarize(op, gxcTypes.MustParseObjectID(restClient.Network().CoreAssetId))
+	if summary == nil {
+		return nil, nil
+	}
+	accounts, err := restClient.Database.GetAccountsByIds(summary.from.String(), summary.to.String())
+	if err != nil {
+		return nil, err
+	}
+	for i, id := range []gxcTypes.ObjectID{summary.from, summary.to} {
+		if i >= len(accounts) || accounts[i] == nil {
+			return nil, errors.Errorf("account %s not exist", id.String())
+		}
+	}
+
+	in := types.UTXO{Address: accounts[0].Name}
+	out := types.UTXO{Address: accounts[1].Name}
+	if summary.amount != nil {
+		asset, err := restClient.Database.GetAsset(summary.amount.AssetID.String())
+		if err != nil {
+			return nil, err
+		}
+		for _, utxo := range []*types.UTXO{&in, &out} {
+			utxo.Value = summary.amount.Amount
+			utxo.TokenCode = asset.Symbol
+			utxo.TokenIdentifier = asset.ID.String()
+			utxo.TokenDecimal = asset.Precision
+		}
+		summary.settle(&in, &out)
+	}
+	feeAsset, err := restClient.Database.GetAsset(summary.fee.AssetID.String())
+	if err != nil {
+		return nil, err
+	}
+	extra := map[string]string{}
+	if callOp, ok := op.(*CallContractOperation); ok {
+		//the call is kept undecoded when the contract can not be read
+		if err := restClient.decodeContractCall(callOp, summary.contract); err != nil {
+			restClient.log().Log(LevelWarn, "failed to read contract", F("method", "TransactionToTx"), F("contract", callOp.ContractId.String()), F("error", err))
+			extra["contract_error"] = err.Error()
+		} else {
+			out.Address = summary.contract.Contract
+		}
+	}
+	if createOp, ok := op.(*ProposalCreateOperation); ok {
+		if summary.proposal.Operations, err = restClient.TransactionToTx(createOp.proposed(), "", nil, nilNum); err != nil {
+			return nil, err
+		}
+	}
+	return &types.Tx{
+		Inputs:  []types.UTXO{in},
+		Outputs: []types.UTXO{out},
+		Fee: &types.Fee{
+			Value:           summary.fee.Amount,
+			TokenCode:       feeAsset.Symbol,
+			TokenIdentifier: feeAsset.ID.String(),
+			TokenDecimal:    feeAsset.Precision,
+		},
+		OpType:        summary.opType,
+		Contract:      summary.contract,
+		Staking:       summary.staking,
+		AssetChange:   summary.assetChange,
+		AccountUpdate: summary.account,
+		Proposal:      summary.proposal,
+		Extra:         extra,
+	}, nil
+}
+
+//tx of an operation of the account history other than a transfer, nil for the
+//operations txs do not show or gxclient-go can not decode
+func (restClient *RestClient) historyOperationToTx(raw json.RawMessage) (*types.Tx, error) {
+	var ops gxcTypes.Operations
+	if err := json.Unmarshal([]byte("["+string(raw)+"]"), &ops); err != nil || len(ops) == 0 {
+		restClient.log().Log(LevelWarn, "failed to decode history operation", F("method", "TxsForAddress"), F("error", err))
+		return nil, nil
+	}
+	return restClient.operationToTx(ops[0])
+}
+
+//tx of an operation other than a transfer with the ids, offline
+func operationToTxOffline(op gxcTypes.Operation) (*types.Tx, error) {
+	summary := summarize(op, gxcTypes.MustParseObjectID(MainNet.CoreAssetId))
+	if summary == nil {
+		return nil, nil
+	}
+	if createOp, ok := op.(*ProposalCreateOperation); ok {
+		var err error
+		if summary.proposal.Operations, err = transactionToTx(createOp.proposed()); err != nil {
+			return nil, err
+		}
+	}
+	in := types.UTXO{Address: summary.from.String()}
+	out := types.UTXO{Address: summary.to.String()}
+	if summary.amount != nil {
+		in.Value, in.TokenCode = summary.amount.Amount, summary.amount.AssetID.String()
+		out.Value, out.TokenCode = summary.amount.Amount, summary.amount.AssetID.String()
+		summary.settle(&in, &out)
+	}
+	return &types.Tx{
+		Inputs:  []types.UTXO{in},
+		Outputs: []types.UTXO{out},
+		Fee: &types.Fee{
+			Value:           summary.fee.Amount,
+			TokenIdentifier: summary.fee.AssetID.String(),
+		},
+		TrxInBlock:    -1,
+		OpType:        summary.opType,
+		Contract:      summary.contract,
+		Staking:       summary.staking,
+		AssetChange:   summary.assetChange,
+		AccountUpdate: summary.account,
+		Proposal:      summary.proposal,
+		Extra: map[string]string{
+			"feeAmount":          strconv.FormatUint(summary.fee.Amount, 10),
+			"feeTokenIdentifier": summary.fee.AssetID.String(),
+		},
+	}, nil
+}
@@ -0,0 +1,455 @@
+package api
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/tidwall/gjson"
+	"gxclient-adapter/types"
+	"gxclient-go/transaction"
+	gxcTypes "gxclient-go/types"
+	"sort"
+	"strings"
+	"time"
+)
+
+//OpWrapper is a proposed operation, {"op": [type, operation]} in json
+type OpWrapper struct {
+	Op gxcTypes.Operation
+}
+
+func (w OpWrapper) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{"op": []interface{}{w.Op.Type(), w.Op}})
+}
+
+func (w *OpWrapper) UnmarshalJSON(b []byte) error {
+	var wrapper struct {
+		Op json.RawMessage `json:"op"`
+	}
+	if err := json.Unmarshal(b, &wrapper); err != nil {
+		return errors.Wrapf(err, "invalid proposed operation %s", string(b))
+	}
+	var ops gxcTypes.Operations
+	if err := json.Unmarshal([]byte("["+string(wrapper.Op)+"]"), &ops); err != nil || len(ops) == 0 {
+		return errors.Errorf("invalid proposed operation %s", string(b))
+	}
+	op, err := resolveOperation(ops[0])
+	if err != nil {
+		return err
+	}
+	w.Op = op
+	return nil
+}
+
+func (w OpWrapper) MarshalTransaction(encoder *transaction.Encoder) error {
+	marshaller, ok := w.Op.(transaction.TransactionMarshaller)
+	if !ok {
+		return errors.Errorf("operation %d can not be serialized", w.Op.Type())
+	}
+	return marshaller.MarshalTransaction(encoder)
+}
+
+//idSet is a set of object ids, serialized in order
+type idSet []gxcTypes.ObjectID
+
+func (s idSet) MarshalTransaction(encoder *transaction.Encoder) error {
+	ids := append(idSet{}, s...)
+	sort.Slice(ids, func(i, j int) bool { return ids[i].ID < ids[j].ID })
+	enc := transaction.NewRollingEncoder(encoder)
+	enc.EncodeUVarint(uint64(len(ids)))
+	for _, id := range ids {
+		enc.Encode(id)
+	}
+	return enc.Err()
+}
+
+//keySet is a set of public keys, serialized in the order gxclient-go sorts them
+type keySet []gxcTypes.PublicKey
+
+func (s keySet) MarshalTransaction(encoder *transaction.Encoder) error {
+	keys := append(keySet{}, s...)
+	sort.Slice(keys, func(i, j int) bool {
+		c, _ := gxcTypes.PublicKeyComparator(&keys[i], &keys[j])
+		return c < 0
+	})
+	enc := transaction.NewRollingEncoder(encoder)
+	enc.EncodeUVarint(uint64(len(keys)))
+	for _, key := range keys {
+		enc.Encode(key)
+	}
+	return enc.Err()
+}
+
+//ProposalCreateOperation proposes operations executed once the authorities they require approve
+type ProposalCreateOperation struct {
+	Fee              gxcTypes.AssetAmount `json:"fee"`
+	FeePayingAccount gxcTypes.ObjectID    `json:"fee_paying_account"`
+	ExpirationTime   gxcTypes.Time        `json:"expiration_time"`
+	ProposedOps      []OpWrapper          `json:"proposed_ops"`
+	//nil without a review period
+	ReviewPeriodSeconds *uint32           `json:"review_period_seconds,omitempty"`
+	Extensions          []json.RawMessage `json:"extensions"`
+}
+
+func (op *ProposalCreateOperation) Type() gxcTypes.OpType { return ProposalCreateOpType }
+
+func (op *ProposalCreateOperation) MarshalTransaction(encoder *transaction.Encoder) error {
+	enc := transaction.NewRollingEncoder(encoder)
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.FeePayingAccount)
+	enc.Encode(op.ExpirationTime)
+	enc.EncodeUVarint(uint64(len(op.ProposedOps)))
+	for _, w := range op.ProposedOps {
+		enc.Encode(w)
+	}
+	if op.ReviewPeriodSeconds != nil {
+		enc.EncodeUVarint(1)
+		enc.Encode(*op.ReviewPeriodSeconds)
+	} else {
+		//ReviewPeriodSeconds?
+		enc.EncodeUVarint(0)
+	}
+	//Extensions
+	enc.EncodeUVarint(0)
+	return enc.Err()
+}
+
+//the proposed operations as a transaction, to show them as txs
+func (op *ProposalCreateOperation) proposed() *gxcTypes.Transaction {
+	trx := &gxcTypes.Transaction{}
+	for _, w := range op.ProposedOps {
+		trx.Operations = append(trx.Operations, w.Op)
+	}
+	return trx
+}
+
+//ProposalUpdateOperation adds or removes approvals of a proposal
+type ProposalUpdateOperation struct {
+	FeePayingAccount        gxcTypes.ObjectID    `json:"fee_paying_account"`
+	Fee                     gxcTypes.AssetAmount `json:"fee"`
+	Proposal                gxcTypes.ObjectID    `json:"proposal"`
+	ActiveApprovalsToAdd    idSet                `json:"active_approvals_to_add"`
+	ActiveApprovalsToRemove idSet                `json:"active_approvals_to_remove"`
+	OwnerApprovalsToAdd     idSet                `json:"owner_approvals_to_add"`
+	OwnerApprovalsToRemove  idSet                `json:"owner_approvals_to_remove"`
+	//the keys must sign the transaction
+	KeyApprovalsToAdd    keySet            `json:"key_approvals_to_add"`
+	KeyApprovalsToRemove keySet            `json:"key_approvals_to_remove"`
+	Extensions           []json.RawMessage `json:"extensions"`
+}
+
+func (op *ProposalUpdateOperation) Type() gxcTypes.OpType { return ProposalUpdateOpType }
+
+func (op *ProposalUpdateOperation) MarshalTransaction(encoder *transaction.Encoder) error {
+	enc := transaction.NewRollingEncoder(encoder)
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.FeePayingAccount)
+	enc.Encode(op.Fee)
+	enc.Encode(op.Proposal)
+	enc.Encode(op.ActiveApprovalsToAdd)
+	enc.Encode(op.ActiveApprovalsToRemove)
+	enc.Encode(op.OwnerApprovalsToAdd)
+	enc.Encode(op.OwnerApprovalsToRemove)
+	enc.Encode(op.KeyApprovalsToAdd)
+	enc.Encode(op.KeyApprovalsToRemove)
+	//Extensions
+	enc.EncodeUVarint(0)
+	return enc.Err()
+}
+
+//the approvals of the sets, nil when they are all empty
+func proposalApprovals(active, owner idSet, keys keySet) *types.ProposalApprovals {
+	if len(active)+len(owner)+len(keys) == 0 {
+		return nil
+	}
+	approvals := &types.ProposalApprovals{}
+	for _, id := range active {
+		approvals.Active = append(approvals.Active, id.String())
+	}
+	for _, id := range owner {
+		approvals.Owner = append(approvals.Owner, id.String())
+	}
+	for _, key := range keys {
+		approvals.Keys = append(approvals.Keys, key.String())
+	}
+	return approvals
+}
+
+//proposalObject is a pending proposal as the node returns it
+type proposalObject struct {
+	ID             string        `json:"id"`
+	ExpirationTime gxcTypes.Time `json:"expiration_time"`
+	//nil without a review period
+	ReviewPeriodTime         *gxcTypes.Time        `json:"review_period_time"`
+	ProposedTransaction      *gxcTypes.Transaction `json:"proposed_transaction"`
+	RequiredActiveApprovals  []string              `json:"required_active_approvals"`
+	AvailableActiveApprovals []string              `json:"available_active_approvals"`
+	RequiredOwnerApprovals   []string              `json:"required_owner_approvals"`
+	AvailableOwnerApprovals  []string              `json:"available_owner_approvals"`
+	AvailableKeyApprovals    []string              `json:"available_key_approvals"`
+	Proposer                 string                `json:"proposer"`
+}
+
+//authorityAccount is an account with its authorities as lists of weighted keys and accounts
+type authorityAccount struct {
+	ID     string    `json:"id"`
+	Name   string    `json:"name"`
+	Owner  Authority `json:"owner"`
+	Active Authority `json:"active"`
+}
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
+
+//the pending proposal by id
+func (restClient *RestClient) getProposal(proposalId string) (*proposalObject, error) {
+	if !strings.HasPrefix(proposalId, "1.10.") {
+		return nil, errors.Errorf("invalid proposal id %s", proposalId)
+	}
+	var objects []json.RawMessage
+	if err := restClient.callDatabase("get_objects", []interface{}{[]string{proposalId}}, &objects); err != nil {
+		return nil, errors.Wrapf(err, "failed to get proposal %s", proposalId)
+	}
+	if len(objects) == 0 || gjson.ParseBytes(objects[0]).Type == gjson.Null {
+		return nil, errors.Errorf("proposal %s not exist", proposalId)
+	}
+	var proposal proposalObject
+	if err := json.Unmarshal(objects[0], &proposal); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal proposal %s", proposalId)
+	}
+	return &proposal, nil
+}
+
+//progress of the approvals of p toward the thresholds of the authorities it requires,
+//the accounts of an authority count when they approved the proposal themselves
+func (restClient *RestClient) approvalProgress(p *proposalObject) ([]*types.ApprovalProgress, error) {
+	ids := append(append([]string{}, p.RequiredActiveApprovals...), p.RequiredOwnerApprovals...)
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var accounts []*authorityAccount
+	if err := restClient.callDatabase("get_accounts", []interface{}{ids}, &accounts); err != nil {
+		return nil, errors.Wrapf(err, "failed to get the authorities of proposal %s", p.ID)
+	}
+	approvedBy := func(id string, owner bool) bool {
+		return contains(p.AvailableOwnerApprovals, id) || (!owner && contains(p.AvailableActiveApprovals, id))
+	}
+
+	var progress []*types.ApprovalProgress
+	for i, id := range ids {
+		if i >= len(accounts) || accounts[i] == nil {
+			return nil, errors.Errorf("account %s not exist", id)
+		}
+		owner := i >= len(p.RequiredActiveApprovals)
+		auth, name := accounts[i].Active, "active"
+		if owner {
+			auth, name = accounts[i].Owner, "owner"
+		}
+		var weight uint64
+		for _, key := range auth.KeyAuths {
+			if contains(p.AvailableKeyApprovals, key.Key.String()) {
+				weight += uint64(key.Weight)
+			}
+		}
+		for _, account := range auth.AccountAuths {
+			if approvedBy(account.Account.String(), false) {
+				weight += uint64(account.Weight)
+			}
+		}
+		if approvedBy(id, owner) && weight < uint64(auth.WeightThreshold) {
+			weight = uint64(auth.WeightThreshold)
+		}
+		progress = append(progress, &types.ApprovalProgress{
+			Account:   accounts[i].Name,
+			Authority: name,
+			Threshold: auth.WeightThreshold,
+			Weight:    uint32(weight),
+			Approved:  weight >= uint64(auth.WeightThreshold),
+		})
+	}
+	return progress, nil
+}
+
+//the pending proposal with its operations as txs and its approval progress
+func (restClient *RestClient) pendingProposal(p *proposalObject) (*types.Proposal, error) {
+	if p.ProposedTransaction == nil {
+		return nil, errors.Errorf("empty proposal %s", p.ID)
+	}
+	if err := resolveOperations(p.ProposedTransaction); err != nil {
+		return nil, err
+	}
+	txs, err := restClient.TransactionToTx(p.ProposedTransaction, "", nil, nilNum)
+	if err != nil {
+		return nil, err
+	}
+	progress, err := restClient.approvalProgress(p)
+	if err != nil {
+		return nil, err
+	}
+	proposal := &types.Proposal{
+		Id:         p.ID,
+		Expiration: p.ExpirationTime.Format(blockTimeFormat),
+		Operations: txs,
+		Approvals: &types.ProposalApprovals{
+			Active: p.AvailableActiveApprovals,
+			Owner:  p.AvailableOwnerApprovals,
+			Keys:   p.AvailableKeyApprovals,
+		},
+		Progress: progress,
+		Approved: len(progress) > 0,
+	}
+	if p.ReviewPeriodTime != nil && p.ReviewPeriodTime.Time != nil {
+		proposal.ReviewPeriodSeconds = uint32(p.ExpirationTime.Sub(*p.ReviewPeriodTime.Time) / time.Second)
+	}
+	if len(p.Proposer) > 0 {
+		accounts, err := restClient.Database.GetAccountsByIds(p.Proposer)
+		if err != nil {
+			return nil, err
+		}
+		if len(accounts) == 0 || accounts[0] == nil {
+			return nil, errors.Errorf("account %s not exist", p.Proposer)
+		}
+		proposal.Proposer = accounts[0].Name
+	}
+	for _, pr := range progress {
+		proposal.Approved = proposal.Approved && pr.Approved
+	}
+	return proposal, nil
+}
+
+//pending proposals address has to approve, with how close each is to the thresholds it requires
+func (restClient *RestClient) ProposalsForAddress(address string) ([]*types.Proposal, error) {
+	acc, err := restClient.Database.GetAccount(address)
+	if err != nil {
+		return nil, err
+	}
+	var objects []*proposalObject
+	if err := restClient.callDatabase("get_proposed_transactions", []interface{}{acc.ID.String()}, &objects); err != nil {
+		return nil, errors.Wrapf(err, "failed to get the proposals of %s", address)
+	}
+	proposals := []*types.Proposal{}
+	for _, object := range objects {
+		proposal, err := restClient.pendingProposal(object)
+		if err != nil {
+			return nil, err
+		}
+		proposals = append(proposals, proposal)
+	}
+	return proposals, nil
+}
+
+//build an unsigned proposal by proposer of the operations of unsignedTx, an unsigned
+//transaction of any builder, expiring after expiresIn. reviewPeriod keeps the approved
+//proposal open before it executes, 0 for none, proposals of the committee need one
+func (restClient *RestClient) BuildProposal(proposer, unsignedTx string, expiresIn, reviewPeriod time.Duration) (string, error) {
+	acc, err := restClient.Database.GetAccount(proposer)
+	if err != nil {
+		return "", err
+	}
+	stx, err := ParseTransaction(unsignedTx)
+	if err != nil {
+		return "", err
+	}
+	if len(stx.Transaction.Operations) == 0 {
+		return "", errors.New("no operation to propose")
+	}
+	if reviewPeriod < 0 || expiresIn <= reviewPeriod {
+		return "", errors.Errorf("expiration in %s must be after the review period of %s", expiresIn, reviewPeriod)
+	}
+	var ops []OpWrapper
+	for _, op := range stx.Transaction.Operations {
+		if _, ok := op.(transaction.TransactionMarshaller); !ok {
+			return "", errors.Errorf("operation %d can not be proposed", op.Type())
+		}
+		ops = append(ops, OpWrapper{Op: op})
+	}
+	props, err := restClient.getProperties()
+	if err != nil {
+		return "", err
+	}
+
+	op := &ProposalCreateOperation{
+		FeePayingAccount: gxcTypes.MustParseObjectID(acc.ID.String()),
+		ExpirationTime:   gxcTypes.NewTime(props.Time.Add(expiresIn)),
+		ProposedOps:      ops,
+		Extensions:       []json.RawMessage{},
+	}
+	if reviewPeriod > 0 {
+		seconds := uint32(reviewPeriod / time.Second)
+		op.ReviewPeriodSeconds = &seconds
+	}
+	return restClient.buildOperation("BuildProposal", op, &op.Fee, F("account", proposer), F("operations", len(ops)),
+		F("expiration", op.ExpirationTime.Format(blockTimeFormat)))
+}
+
+//build an unsigned approval of a pending proposal paid by account. Without keys it adds
+//the active approval of account, with keys the approvals of the keys, which must sign it
+//too. approve false removes the approvals instead
+func (restClient *RestClient) BuildProposalApproval(account, proposalId string, keys []string, approve bool) (string, error) {
+	acc, err := restClient.Database.GetAccount(account)
+	if err != nil {
+		return "", err
+	}
+	proposal, err := restClient.getProposal(proposalId)
+	if err != nil {
+		return "", err
+	}
+	op := &ProposalUpdateOperation{
+		FeePayingAccount:        gxcTypes.MustParseObjectID(acc.ID.String()),
+		Proposal:                gxcTypes.MustParseObjectID(proposal.ID),
+		ActiveApprovalsToAdd:    idSet{},
+		ActiveApprovalsToRemove: idSet{},
+		OwnerApprovalsToAdd:     idSet{},
+		OwnerApprovalsToRemove:  idSet{},
+		KeyApprovalsToAdd:       keySet{},
+		KeyApprovalsToRemove:    keySet{},
+		Extensions:              []json.RawMessage{},
+	}
+	//the approver, an account or a key, is already in the approvals when approve is false
+	check := func(approver string, given bool) error {
+		if approve && given {
+			return errors.Errorf("proposal %s is already approved by %s", proposalId, approver)
+		}
+		if !approve && !given {
+			return errors.Errorf("proposal %s is not approved by %s", proposalId, approver)
+		}
+		return nil
+	}
+	if len(keys) == 0 {
+		if err := check(account, contains(proposal.AvailableActiveApprovals, acc.ID.String())); err != nil {
+			return "", err
+		}
+		if approve {
+			op.ActiveApprovalsToAdd = idSet{op.FeePayingAccount}
+		} else {
+			op.ActiveApprovalsToRemove = idSet{op.FeePayingAccount}
+		}
+	}
+	seen := map[string]bool{}
+	for _, key := range keys {
+		pub, err := restClient.publicKey(key)
+		if err != nil {
+			return "", err
+		}
+		if seen[pub.String()] {
+			return "", errors.Errorf("key %s is twice in the approvals", key)
+		}
+		seen[pub.String()] = true
+		if err := check(key, contains(proposal.AvailableKeyApprovals, pub.String())); err != nil {
+			return "", err
+		}
+		if approve {
+			op.KeyApprovalsToAdd = append(op.KeyApprovalsToAdd, *pub)
+		} else {
+			op.KeyApprovalsToRemove = append(op.KeyApprovalsToRemove, *pub)
+		}
+	}
+	return restClient.buildOperation("BuildProposalApproval", op, &op.Fee, F("account", account), F("proposal", proposalId),
+		F("keys", len(keys)), F("approve", approve))
+}
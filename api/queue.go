@@ -0,0 +1,358 @@
+package api
+
+import (
+	"github.com/pkg/errors"
+	"gxclient-adapter/types"
+	"gxclient-go/api/database"
+	"gxclient-go/rpc"
+	gxcTypes "gxclient-go/types"
+	"sync"
+	"time"
+)
+
+//TransferState is the step of a queued transfer
+type TransferState string
+
+const (
+	//accepted, no transaction built yet
+	TransferQueued TransferState = "queued"
+	//the unsigned transaction is built
+	TransferBuilt TransferState = "built"
+	//the signature is saved, the transaction may have reached the node
+	TransferSigned TransferState = "signed"
+	//the node accepted the transaction
+	TransferBroadcast TransferState = "broadcast"
+	//in an irreversible block
+	TransferConfirmed TransferState = "confirmed"
+	//not built, or rejected until it expired, no transaction of it is in a block
+	TransferFailed TransferState = "failed"
+	//the transaction expired outside a block, it is built again
+	TransferExpired TransferState = "expired"
+)
+
+//Transfer is a transfer of the queue and its current transaction
+type Transfer struct {
+	Id     string         `json:"id"`
+	From   string         `json:"from"`
+	To     string         `json:"to"`
+	Symbol string         `json:"symbol"`
+	Amount uint64         `json:"amount"`
+	Memo   *gxcTypes.Memo `json:"memo,omitempty"`
+
+	State       TransferState `json:"state"`
+	TxId        string        `json:"tx_id,omitempty"`
+	UnsignedTx  string        `json:"unsigned_tx,omitempty"`
+	Expiration  string        `json:"expiration,omitempty"`
+	Signature   string        `json:"signature,omitempty"`
+	BlockNumber int64         `json:"block_no,omitempty"`
+	//failed attempts of the current step, the last error
+	Attempts int    `json:"attempts"`
+	Error    string `json:"error,omitempty"`
+	//transactions built before that expired outside a block
+	ExpiredTxIds []string `json:"expired_tx_ids,omitempty"`
+	CreatedAt    string   `json:"created_at"`
+	UpdatedAt    string   `json:"updated_at"`
+}
+
+//the transfer does not change any more
+func (t *Transfer) done() bool {
+	return t.State == TransferConfirmed || t.State == TransferFailed
+}
+
+//TransferQueue moves transfers through building, signing with pubKey and broadcasting
+//until they are confirmed or fail, keeping them in a json file replaced on every step.
+//A transaction is built again only once it expired without being in a block, so a
+//transfer is never sent twice
+type TransferQueue struct {
+	client *RestClient
+	signer Signer
+	pubKey string
+	path   string
+
+	//Interval of the passes of Run
+	Interval time.Duration
+	//MaxAttempts of a step before the transfer fails, a signed transaction
+	//rejected this often fails once it expired
+	MaxAttempts int
+
+	//held by Enqueue and a whole pass of Process
+	mu        sync.Mutex
+	transfers []*Transfer
+}
+
+//queue in the file at path, loading the transfers it already has. The transactions
+//are signed by signer with pubKey, the active key of the accounts sending
+func (restClient *RestClient) NewTransferQueue(signer Signer, pubKey, path string) (*TransferQueue, error) {
+	queue := &TransferQueue{
+		client:      restClient,
+		signer:      signer,
+		pubKey:      pubKey,
+		path:        path,
+		Interval:    10 * time.Second,
+		MaxAttempts: 3,
+	}
+	if err := readJSONFile(path, &queue.transfers); err != nil {
+		return nil, err
+	}
+	return queue, nil
+}
+
+//add a transfer of amount in symbol, the core asset when empty. The id is the
+//idempotency key of the caller, an id queued again returns its transfer
+func (queue *TransferQueue) Enqueue(id, from, to, symbol string, amount uint64, memo *gxcTypes.Memo) (*Transfer, error) {
+	if len(id) == 0 {
+		return nil, errors.New("transfer id required")
+	}
+	if err := ValidateAddress(to); err != nil {
+		return nil, err
+	}
+	if amount == 0 {
+		return nil, errors.New("amount required")
+	}
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	if t := queue.find(id); t != nil {
+		if t.From != from || t.To != to || t.Symbol != symbol || t.Amount != amount {
+			return nil, errors.Errorf("transfer id %s is used by another transfer", id)
+		}
+		copied := *t
+		return &copied, nil
+	}
+	now := time.Now().UTC().Format(blockTimeFormat)
+	t := &Transfer{Id: id, From: from, To: to, Symbol: symbol, Amount: amount, Memo: memo, State: TransferQueued, CreatedAt: now, UpdatedAt: now}
+	queue.transfers = append(queue.transfers, t)
+	if err := queue.save(); err != nil {
+		queue.transfers = queue.transfers[:len(queue.transfers)-1]
+		return nil, err
+	}
+	queue.client.log().Log(LevelInfo, "transfer queued", F("method", "Enqueue"), F("transfer", id), F("account", from),
+		F("to", to), F("symbol", symbol), F("amount", amount))
+	copied := *t
+	return &copied, nil
+}
+
+//the transfer of id, nil when unknown
+func (queue *TransferQueue) Transfer(id string) *Transfer {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	t := queue.find(id)
+	if t == nil {
+		return nil
+	}
+	copied := *t
+	return &copied
+}
+
+//all transfers in the order they were queued
+func (queue *TransferQueue) Transfers() []*Transfer {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	result := make([]*Transfer, len(queue.transfers))
+	for i, t := range queue.transfers {
+		copied := *t
+		result[i] = &copied
+	}
+	return result
+}
+
+func (queue *TransferQueue) find(id string) *Transfer {
+	for _, t := range queue.transfers {
+		if t.Id == id {
+			return t
+		}
+	}
+	return nil
+}
+
+func (queue *TransferQueue) save() error {
+	return writeJSONFile(queue.path, queue.transfers)
+}
+
+//move every transfer as far as it goes now, the error is the first step that failed
+func (queue *TransferQueue) Process() error {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	var first error
+	for _, t := range queue.transfers {
+		for !t.done() {
+			previous := *t
+			err := queue.step(t)
+			if err != nil {
+				queue.client.log().Log(LevelWarn, "transfer step failed", F("method", "Process"), F("transfer", t.Id),
+					F("state", t.State), F("error", err))
+				if first == nil {
+					first = err
+				}
+			}
+			if t.State == previous.State && t.Attempts == previous.Attempts && t.BlockNumber == previous.BlockNumber {
+				break
+			}
+			t.UpdatedAt = time.Now().UTC().Format(blockTimeFormat)
+			if err := queue.save(); err != nil {
+				return err
+			}
+			if t.State != previous.State {
+				queue.client.log().Log(LevelInfo, "transfer "+string(t.State), F("method", "Process"), F("transfer", t.Id), F("tx_id", t.TxId))
+			}
+			if err != nil {
+				break
+			}
+		}
+	}
+	return first
+}
+
+//process the transfers every Interval until stop is closed
+func (queue *TransferQueue) Run(stop <-chan struct{}) {
+	for {
+		if err := queue.Process(); err != nil {
+			queue.client.log().Log(LevelWarn, "transfer queue pass failed", F("method", "Run"), F("error", err))
+		}
+		select {
+		case <-stop:
+			return
+		case <-time.After(queue.Interval):
+		}
+	}
+}
+
+//a failed attempt of a step that sent nothing, the transfer fails after MaxAttempts
+func (queue *TransferQueue) retry(t *Transfer, err error) error {
+	t.Attempts++
+	t.Error = err.Error()
+	if t.Attempts >= queue.MaxAttempts {
+		t.State = TransferFailed
+	}
+	return err
+}
+
+//move t to the next state, the state is unchanged while it waits for the chain
+func (queue *TransferQueue) step(t *Transfer) error {
+	switch t.State {
+	case TransferQueued:
+		return queue.build(t)
+	case TransferBuilt:
+		props, err := queue.client.getProperties()
+		if err != nil {
+			return err
+		}
+		expired, err := queue.expired(t, props)
+		if err != nil || expired {
+			return err
+		}
+		chainId, err := queue.client.chainId()
+		if err != nil {
+			return err
+		}
+		signature, err := SignWith(queue.signer, queue.pubKey, chainId, t.UnsignedTx)
+		if err != nil {
+			return queue.retry(t, err)
+		}
+		t.Signature = signature
+		t.State = TransferSigned
+		t.Attempts = 0
+		t.Error = ""
+		return nil
+	case TransferSigned, TransferBroadcast:
+		return queue.broadcast(t)
+	case TransferExpired:
+		//the expired transaction can not be in a block any more
+		t.ExpiredTxIds = append(t.ExpiredTxIds, t.TxId)
+		t.TxId, t.UnsignedTx, t.Expiration, t.Signature, t.BlockNumber = "", "", "", "", 0
+		t.State = TransferQueued
+		t.Attempts = 0
+		return nil
+	}
+	return errors.Errorf("unknown state %s of transfer %s", t.State, t.Id)
+}
+
+func (queue *TransferQueue) build(t *Transfer) error {
+	unsigned, err := queue.client.BuildTransaction(t.From, t.To, t.Symbol, t.Amount, t.Memo)
+	if err != nil {
+		return queue.retry(t, err)
+	}
+	stx, err := ParseTransaction(unsigned)
+	if err != nil {
+		return queue.retry(t, err)
+	}
+	txId, err := transactionId(stx.Transaction)
+	if err != nil {
+		return queue.retry(t, err)
+	}
+	t.UnsignedTx = unsigned
+	t.TxId = txId
+	t.Expiration = stx.Expiration.Format(blockTimeFormat)
+	t.State = TransferBuilt
+	t.Attempts = 0
+	t.Error = ""
+	return nil
+}
+
+//the transaction of t expired by the last irreversible block of props, t is marked expired
+func (queue *TransferQueue) expired(t *Transfer, props *database.DynamicGlobalProperties) (bool, error) {
+	expiration, err := time.Parse(blockTimeFormat, t.Expiration)
+	if err != nil {
+		return false, errors.Wrapf(err, "invalid expiration of transfer %s", t.Id)
+	}
+	irreversible, err := queue.client.irreversibleTime(props)
+	if err != nil {
+		return false, err
+	}
+	if irreversible.Before(expiration) {
+		return false, nil
+	}
+	t.State = TransferExpired
+	return true, nil
+}
+
+//the transaction of t on chain, looked up before sending it so a transaction sent
+//before a restart is not sent again, and until it is irreversible. A transaction
+//not in a block is sent until the node rejected it MaxAttempts times. The properties
+//are read before the lookup so a transaction included meanwhile is not built again
+func (queue *TransferQueue) broadcast(t *Transfer) error {
+	props, err := queue.client.getProperties()
+	if err != nil {
+		return err
+	}
+	txs, err := queue.client.GetTransaction(t.TxId)
+	if err != nil {
+		return errors.Wrapf(err, "failed to look up transfer %s", t.Id)
+	}
+	if len(txs) > 0 {
+		t.State = TransferBroadcast
+		t.BlockNumber = txs[0].BlockNumber
+		if txs[0].Status == types.TxStatusIrreversible {
+			t.State = TransferConfirmed
+		}
+		return nil
+	}
+	t.BlockNumber = 0
+	expired, err := queue.expired(t, props)
+	if err != nil {
+		return err
+	}
+	if expired {
+		if t.Attempts >= queue.MaxAttempts {
+			t.State = TransferFailed
+		}
+		return nil
+	}
+	if t.State == TransferBroadcast || t.Attempts >= queue.MaxAttempts {
+		return nil
+	}
+
+	tx, err := queue.client.SignTransaction(t.UnsignedTx, t.Signature)
+	if err != nil {
+		if rejection, ok := errors.Cause(err).(*rpc.RPCError); ok {
+			t.Attempts++
+			t.Error = rejection.Message
+		}
+		return err
+	}
+	t.Error = ""
+	//an expired reply is relative to the head block, the expiration is decided
+	//by the last irreversible block on the next lookup
+	t.State = TransferBroadcast
+	t.BlockNumber = tx.BlockNumber
+	return nil
+}
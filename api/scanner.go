@@ -0,0 +1,236 @@
+package api
+
+import (
+	"gxclient-adapter/types"
+	"gxclient-go/api/database"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+type EventType string
+
+const (
+	//tx in a newly scanned block
+	EventTx EventType = "tx"
+	//the block of an emitted tx became irreversible
+	EventIrreversible EventType = "irreversible"
+	//the block of an emitted tx was dropped by a fork
+	EventRollback EventType = "rollback"
+)
+
+//ScanEvent is sent by the HeadScanner for every tx it emits or updates
+type ScanEvent struct {
+	Type        EventType `json:"type"`
+	Tx          *types.Tx `json:"tx"`
+	BlockNumber uint32    `json:"block_no"`
+	BlockID     string    `json:"block_id"`
+}
+
+//reversible block already emitted
+type scannedBlock struct {
+	id  string
+	txs []*types.Tx
+}
+
+//HeadScanner follows the head block instead of the last irreversible block,
+//txs of reversible blocks are emitted right away and followed by either an
+//irreversible or a rollback event
+type HeadScanner struct {
+	client *RestClient
+
+	//Events receives the tx, irreversible and rollback events in chain order
+	Events chan *ScanEvent
+	//Interval to poll new blocks
+	Interval time.Duration
+
+	//read by NextBlock and written by SetNextBlock while Run scans
+	nextBlock uint32
+	//set by SetNextBlock, the next Scan forgets the emitted blocks
+	reset  uint32
+	lib    uint32
+	blocks map[uint32]*scannedBlock
+	//closed to stop a send on a full channel, set while Run runs
+	stop <-chan struct{}
+}
+
+func (restClient *RestClient) NewHeadScanner() *HeadScanner {
+	return &HeadScanner{
+		client:   restClient,
+		Events:   make(chan *ScanEvent, 100),
+		Interval: time.Second,
+		blocks:   map[uint32]*scannedBlock{},
+	}
+}
+
+//next block to scan
+func (scanner *HeadScanner) NextBlock() uint32 {
+	return atomic.LoadUint32(&scanner.nextBlock)
+}
+
+//set the next block to scan, the reversible blocks already emitted are forgotten
+func (scanner *HeadScanner) SetNextBlock(block_no uint32) {
+	atomic.StoreUint32(&scanner.nextBlock, block_no)
+	atomic.StoreUint32(&scanner.reset, 1)
+}
+
+//move the next block from *next to block_no, false when SetNextBlock changed it meanwhile
+func (scanner *HeadScanner) move(next *uint32, block_no uint32) bool {
+	if !atomic.CompareAndSwapUint32(&scanner.nextBlock, *next, block_no) {
+		return false
+	}
+	*next = block_no
+	return true
+}
+
+//send event, false when stop is closed before Events has room
+func (scanner *HeadScanner) send(event *ScanEvent) bool {
+	select {
+	case scanner.Events <- event:
+		return true
+	case <-scanner.stop:
+		return false
+	}
+}
+
+//scan the blocks up to the head block once. The sends wait for room in Events,
+//when Run is stopped meanwhile the block is not done and is scanned again by the next Run
+func (scanner *HeadScanner) Scan() error {
+	if atomic.SwapUint32(&scanner.reset, 0) == 1 {
+		scanner.blocks = map[uint32]*scannedBlock{}
+	}
+	props, err := scanner.client.getProperties()
+	if err != nil {
+		return err
+	}
+	scanner.lib = props.LastIrreversibleBlockNum
+	next := scanner.NextBlock()
+
+	//the last emitted block may have been replaced without a new block on top
+	if next > 0 {
+		if ok, err := scanner.rollback(next-1, &next); err != nil || !ok {
+			return err
+		}
+	}
+
+	for next <= props.HeadBlockNumber {
+		block_no := next
+		block, err := scanner.client.Database.GetBlock(block_no)
+		if err != nil {
+			return err
+		}
+		//not available on the node yet
+		if len(block.BlockId) == 0 {
+			break
+		}
+		if previous := scanner.blocks[block_no-1]; previous != nil && previous.id != block.Previous {
+			scanner.client.log().Log(LevelWarn, "fork detected", F("method", "Scan"), F("block_num", block_no),
+				F("block_id", block.BlockId), F("previous", block.Previous))
+			if ok, err := scanner.rollback(block_no-1, &next); err != nil || !ok {
+				return err
+			}
+			continue
+		}
+
+		txs, err := scanner.client.blockToTxs(block, block_no, props)
+		if err != nil {
+			return err
+		}
+		for _, tx := range txs {
+			if !scanner.send(&ScanEvent{Type: EventTx, Tx: tx, BlockNumber: block_no, BlockID: block.BlockId}) {
+				return nil
+			}
+		}
+		if !scanner.move(&next, block_no+1) {
+			return nil
+		}
+		if block_no > scanner.lib {
+			scanner.blocks[block_no] = &scannedBlock{id: block.BlockId, txs: txs}
+		}
+	}
+
+	scanner.irreversible(props)
+	return nil
+}
+
+//walk down from block_no and roll back the emitted blocks whose id changed, moving
+//*next down to the lowest one. false when stopped or SetNextBlock was called meanwhile
+func (scanner *HeadScanner) rollback(block_no uint32, next *uint32) (bool, error) {
+	for ; block_no > 0; block_no-- {
+		known := scanner.blocks[block_no]
+		if known == nil {
+			return true, nil
+		}
+		block, err := scanner.client.Database.GetBlock(block_no)
+		if err != nil {
+			return false, err
+		}
+		if block.BlockId == known.id {
+			return true, nil
+		}
+		scanner.client.log().Log(LevelWarn, "block rolled back", F("method", "Scan"), F("block_num", block_no),
+			F("block_id", known.id), F("txs", len(known.txs)))
+		for i := len(known.txs) - 1; i >= 0; i-- {
+			//copied, the emitted tx may still be used by the consumer
+			tx := *known.txs[i]
+			tx.Status = types.TxStatusRolledBack
+			tx.Confirmations = 0
+			if !scanner.send(&ScanEvent{Type: EventRollback, Tx: &tx, BlockNumber: block_no, BlockID: known.id}) {
+				return false, nil
+			}
+		}
+		if !scanner.move(next, block_no) {
+			return false, nil
+		}
+		delete(scanner.blocks, block_no)
+	}
+	return true, nil
+}
+
+//send the irreversible events of the emitted blocks at or below the last irreversible block
+func (scanner *HeadScanner) irreversible(props *database.DynamicGlobalProperties) {
+	var heights []uint32
+	for block_no := range scanner.blocks {
+		if block_no <= scanner.lib {
+			heights = append(heights, block_no)
+		}
+	}
+	sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+	for _, block_no := range heights {
+		known := scanner.blocks[block_no]
+		txs := make([]*types.Tx, len(known.txs))
+		for i, tx := range known.txs {
+			copied := *tx
+			txs[i] = &copied
+		}
+		setStatus(txs, int64(block_no), props)
+		for _, tx := range txs {
+			if !scanner.send(&ScanEvent{Type: EventIrreversible, Tx: tx, BlockNumber: block_no, BlockID: known.id}) {
+				return
+			}
+		}
+		delete(scanner.blocks, block_no)
+	}
+}
+
+//follow the head block from block_no until stop is closed
+func (scanner *HeadScanner) Run(block_no uint32, stop <-chan struct{}) error {
+	scanner.stop = stop
+	defer func() { scanner.stop = nil }()
+	scanner.SetNextBlock(block_no)
+	for {
+		select {
+		case <-stop:
+			return nil
+		default:
+		}
+		if err := scanner.Scan(); err != nil {
+			return err
+		}
+		select {
+		case <-stop:
+			return nil
+		case <-time.After(scanner.Interval):
+		}
+	}
+}
@@ -4,9 +4,9 @@ import (
 	"bytes"
 	"encoding/hex"
 	"encoding/json"
-	"fmt"
 	"github.com/pkg/errors"
 	"github.com/tidwall/gjson"
+	"gxclient-adapter/metrics"
 	"gxclient-adapter/types"
 	"gxclient-go/api/broadcast"
 	"gxclient-go/api/database"
@@ -30,6 +30,8 @@ var once *sync.Once = &sync.Once{}
 
 type RestClient struct {
 	cc rpc.CallCloser
+	//for the database calls gxclient-go has no method for
+	databaseAPIID rpc.APIID
 
 	// Database represents database_api
 	Database *database.API
@@ -43,10 +45,28 @@ type RestClient struct {
 	// Login represents login_api
 	Login *login.API
 
-	chainID string
+	//nil means MainNet, see SetNetwork
+	network *NetworkConfig
+
+	//memo private keys by base58 public key, see SetMemoKeys
+	memoKeys  map[string]string
+	memoMutex sync.RWMutex
+
+	//no output until SetLogger
+	logger Logger
+
+	//idempotent withdrawals, see SetWithdrawalStore
+	withdrawals     WithdrawalStore
+	withdrawalMutex sync.Mutex
 }
 
+//the network is detected by the chain id of the node
 func NewRestClient(url string) (*RestClient, error) {
+	return NewRestClientWithNetwork(url, nil)
+}
+
+//fail unless the chain id of the node matches the network, nil detects the network
+func NewRestClientWithNetwork(url string, network *NetworkConfig) (*RestClient, error) {
 	// transport
 	var cc rpc.CallCloser
 	var err error
@@ -58,16 +78,16 @@ func NewRestClient(url string) (*RestClient, error) {
 	if err != nil {
 		return nil, err
 	}
+	cc = metrics.InstrumentCaller(cc)
 
 	client := &RestClient{cc: cc}
 
 	if strings.HasPrefix(url, "http") || strings.HasPrefix(url, "https") {
-		client.Database = database.NewAPI("database", cc)
-		chainID, err := client.Database.GetChainId()
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to get database ID")
+		client.databaseAPIID = "database"
+		client.Database = database.NewAPI(client.databaseAPIID, cc)
+		if err := client.SetNetwork(network); err != nil {
+			return nil, err
 		}
-		client.chainID = chainID
 		client.History = history.NewAPI("history", cc)
 		client.Broadcast = broadcast.NewAPI("network_broadcast", cc)
 		return client, nil
@@ -82,14 +102,13 @@ func NewRestClient(url string) (*RestClient, error) {
 	if err != nil {
 		return nil, err
 	}
+	client.databaseAPIID = databaseAPIID
 	client.Database = database.NewAPI(databaseAPIID, client.cc)
 
 	// database ID
-	chainID, err := client.Database.GetChainId()
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to get database ID")
+	if err := client.SetNetwork(network); err != nil {
+		return nil, err
 	}
-	client.chainID = chainID
 
 	// history
 	historyAPIID, err := loginAPI.History()
@@ -108,6 +127,54 @@ func NewRestClient(url string) (*RestClient, error) {
 	return client, nil
 }
 
+//check the chain id of the node against the network and use its parameters,
+//nil detects the network by the chain id of the node, an unknown chain is an error
+func (restClient *RestClient) SetNetwork(network *NetworkConfig) error {
+	chainID, err := restClient.Database.GetChainId()
+	if err != nil {
+		return errors.Wrap(err, "failed to get database ID")
+	}
+	if network == nil {
+		if network, err = NetworkByChainId(chainID); err != nil {
+			return err
+		}
+	} else if err := network.CheckChainId(chainID); err != nil {
+		return err
+	}
+	restClient.network = network
+	return nil
+}
+
+//network of the client, MainNet until SetNetwork
+func (restClient *RestClient) Network() *NetworkConfig {
+	if restClient.network == nil {
+		return MainNet
+	}
+	return restClient.network
+}
+
+//client over an open connection with the ids of its apis, the network is MainNet until SetNetwork
+func NewRestClientWithCaller(cc rpc.CallCloser, databaseAPIID, historyAPIID, broadcastAPIID rpc.APIID) *RestClient {
+	return &RestClient{
+		cc:            cc,
+		databaseAPIID: databaseAPIID,
+		Database:      database.NewAPI(databaseAPIID, cc),
+		History:       history.NewAPI(historyAPIID, cc),
+		Broadcast:     broadcast.NewAPI(broadcastAPIID, cc),
+	}
+}
+
+//call a database api method gxclient-go has no method for
+func (restClient *RestClient) callDatabase(method string, args []interface{}, reply interface{}) error {
+	if restClient.cc == nil {
+		return errors.Errorf("no connection for %s", method)
+	}
+	if err := restClient.cc.Connect(); err != nil {
+		return err
+	}
+	return restClient.cc.Call(restClient.databaseAPIID, method, args, reply)
+}
+
 func GetInstance(url string) (*RestClient, error) {
 	var err error
 	once.Do(func() {
@@ -119,9 +186,56 @@ func GetInstance(url string) (*RestClient, error) {
 	return restClient, err
 }
 
+//set memo private keys in hex, history and block queries decrypt the memo
+//into extra["memo"] when one of the keys matches the memo's from or to key
+func (restClient *RestClient) SetMemoKeys(memoPriHexes ...string) error {
+	memoKeys := map[string]string{}
+	for _, memoPriHex := range memoPriHexes {
+		wif, err := PriKeyHexToWif(memoPriHex)
+		if err != nil {
+			return err
+		}
+		priKey, err := gxcTypes.NewPrivateKeyFromWif(wif)
+		if err != nil {
+			return err
+		}
+		memoKeys[priKey.PublicKey().String()] = memoPriHex
+	}
+	restClient.memoMutex.Lock()
+	restClient.memoKeys = memoKeys
+	restClient.memoMutex.Unlock()
+	return nil
+}
+
+//decrypt the memo in extra with the matching memo key, if any
+func (restClient *RestClient) decryptMemo(extra map[string]string) {
+	if len(extra["message"]) == 0 {
+		return
+	}
+	restClient.memoMutex.RLock()
+	memoPriHex, ok := restClient.memoKeys[extra["to"]]
+	if !ok {
+		memoPriHex, ok = restClient.memoKeys[extra["from"]]
+	}
+	restClient.memoMutex.RUnlock()
+	if !ok {
+		return
+	}
+	nonce, err := strconv.ParseUint(extra["nonce"], 10, 64)
+	if err != nil {
+		return
+	}
+	memo, err := DeserializeMemo(memoPriHex, extra["from"], extra["to"], extra["message"], gxcTypes.UInt64(nonce))
+	if err != nil {
+		restClient.log().Log(LevelWarn, "failed to decrypt memo", F("method", "decryptMemo"), F("from", extra["from"]), F("to", extra["to"]), F("error", err))
+		return
+	}
+	extra["memo"] = memo
+}
+
 //pubkey to accountId
 func (restClient *RestClient) Pubkey2accountId(pubKeyHex string) ([]string, error) {
-	pubKey, err := PubKeyHexToBase58(pubKeyHex)
+	pubKey, err := restClient.Network().PubKeyHexToBase58(pubKeyHex)
 	if err != nil {
 		return nil, err
 	}
@@ -180,9 +294,17 @@ func (restClient *RestClient) GetBlockCount() (uint32, error) {
 	if err != nil {
 		return 0, err
 	}
+	observeBlocks(properties)
 	return properties.LastIrreversibleBlockNum, nil
 }
 
+//update the block lag metrics
+func observeBlocks(props *database.DynamicGlobalProperties) {
+	if props.Time.Time != nil {
+		metrics.ObserveBlocks(*props.Time.Time, props.HeadBlockNumber, props.LastIrreversibleBlockNum)
+	}
+}
+
 //blocks containing txs
 func (restClient *RestClient) GetBlockTxs(block_no uint32) ([]*types.Tx, error) {
 	block, err := restClient.Database.GetBlock(block_no)
@@ -190,6 +312,16 @@ func (restClient *RestClient) GetBlockTxs(block_no uint32) ([]*types.Tx, error)
 		return nil, err
 	}
 
+	props, err := restClient.getProperties()
+	if err != nil {
+		return nil, err
+	}
+
+	return restClient.blockToTxs(block, block_no, props)
+}
+
+//txs of the block with the status set
+func (restClient *RestClient) blockToTxs(block *database.Block, block_no uint32, props *database.DynamicGlobalProperties) ([]*types.Tx, error) {
 	var result []*types.Tx
 	transactions := block.Transactions
 
@@ -200,14 +332,62 @@ func (restClient *RestClient) GetBlockTxs(block_no uint32) ([]*types.Tx, error)
 		}
 		result = append(result, txs...)
 	}
+	setStatus(result, int64(block_no), props)
 	return result, nil
 }
 
+//dynamic global properties, with the block lag metrics updated
+func (restClient *RestClient) getProperties() (*database.DynamicGlobalProperties, error) {
+	props, err := restClient.Database.GetDynamicGlobalProperties()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get dynamic global properties")
+	}
+	observeBlocks(props)
+	return props, nil
+}
+
+//time of the last irreversible block of props, a transaction looked up after
+//props that expired by then can not be in a block any more
+func (restClient *RestClient) irreversibleTime(props *database.DynamicGlobalProperties) (time.Time, error) {
+	header, err := restClient.Database.GetBlockHeader(props.LastIrreversibleBlockNum)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "failed to get block %d", props.LastIrreversibleBlockNum)
+	}
+	if header.Timestamp.Time == nil {
+		return time.Time{}, errors.Errorf("block %d has no time", props.LastIrreversibleBlockNum)
+	}
+	return *header.Timestamp.Time, nil
+}
+
+//set block number, status, confirmations and confirmation time of the txs,
+//block_num 0 means not in a block yet
+func setStatus(txs []*types.Tx, block_num int64, props *database.DynamicGlobalProperties) {
+	for _, tx := range txs {
+		tx.BlockNumber = block_num
+		if block_num <= 0 {
+			tx.Status = types.TxStatusPending
+			tx.Confirmations = 0
+			continue
+		}
+		if block_num <= int64(props.LastIrreversibleBlockNum) {
+			tx.Status = types.TxStatusIrreversible
+		} else {
+			tx.Status = types.TxStatusIncluded
+		}
+		if int64(props.HeadBlockNumber) >= block_num {
+			tx.Confirmations = uint32(int64(props.HeadBlockNumber) - block_num + 1)
+		}
+		if len(tx.ConfirmedAt) == 0 {
+			tx.ConfirmedAt = tx.TxAt
+		}
+	}
+}
+
 //address balance
 func (restClient *RestClient) BalanceForAddress(address string, symbol string) ([]*types.Asset, error) {
 	//未指定则返回主资产GXC
 	if len(symbol) == 0 {
-		symbol = "GXC"
+		symbol = restClient.Network().CoreAsset
 	}
 
 	asset, err := restClient.Database.GetAsset(symbol)
@@ -276,6 +456,10 @@ func (restClient *RestClient) TxsForAddressFull(address, since_tx_id string, lim
 	if err != nil {
 		return nil, err
 	}
+	props, err := restClient.getProperties()
+	if err != nil {
+		return nil, err
+	}
 
 	assets := map[string]*types.Asset{}
 	accounts := map[string]*gxcTypes.Account{}
@@ -284,15 +468,32 @@ func (restClient *RestClient) TxsForAddressFull(address, since_tx_id string, lim
 			tx := gjson.ParseBytes(byte_s)
 			operation := tx.Get("op")
 			tx_op_code := operation.Get("0").Uint()
-			if gxcTypes.OpType(tx_op_code) != gxcTypes.TransferOpType {
-				continue
-			}
 			block, err := restClient.Database.GetBlock(oph.BlockNumber)
 			if err != nil {
 				return nil, err
 			}
+			if gxcTypes.OpType(tx_op_code) != gxcTypes.TransferOpType {
+				txOb, err := restClient.operationToTx(block.Transactions[oph.TransactionsInBlock].Operations[oph.OperationsInTransactions])
+				if err != nil {
+					return nil, err
+				}
+				if txOb == nil {
+					continue
+				}
+				txOb.TxHash = block.TransactionIds[oph.TransactionsInBlock]
+				txOb.TxAt = block.Timestamp.Format("2006-01-02T15:04:05")
+				txOb.BlockNumber = int64(oph.BlockNumber)
+				txOb.TrxInBlock = int(oph.TransactionsInBlock)
+				txOb.OpIndex = int(oph.OperationsInTransactions)
+				txOb.HistoryID = oph.ID
+				txOb.Extra["id"] = oph.ID
+				setStatus([]*types.Tx{txOb}, txOb.BlockNumber, props)
+				SetDirection(txOb, acc.Name)
+				txs = append(txs, txOb)
+				continue
+			}
 			var transferOp gxcTypes.TransferOperation
-			byte, err := json.Marshal(block.Transactions[oph.TransactionsInBlock].Operations[0])
+			byte, err := json.Marshal(block.Transactions[oph.TransactionsInBlock].Operations[oph.OperationsInTransactions])
 			if err != nil {
 				return nil, err
 			}
@@ -300,12 +501,12 @@ func (restClient *RestClient) TxsForAddressFull(address, since_tx_id string, lim
 
 			tokenIdentifier := transferOp.Amount.AssetID.String()
 			if assets[tokenIdentifier] == nil {
-				asset, _ := restClient.TokenDetail(tokenIdentifier)
+				asset, _ := restClient.tokenOf(tokenIdentifier)
 				assets[tokenIdentifier] = asset
 			}
-			feeIdentifier := transferOp.Amount.AssetID.String()
+			feeIdentifier := transferOp.Fee.AssetID.String()
 			if assets[feeIdentifier] == nil {
-				asset, _ := restClient.TokenDetail(feeIdentifier)
+				asset, _ := restClient.tokenOf(feeIdentifier)
 				assets[feeIdentifier] = asset
 			}
 
@@ -344,6 +545,7 @@ func (restClient *RestClient) TxsForAddressFull(address, since_tx_id string, lim
 				extra["nonce"] = strconv.FormatUint(uint64(transferOp.Memo.Nonce), 10)
 			}
 			extra["id"] = oph.ID
+			restClient.decryptMemo(extra)
 
 			txOb := &types.Tx{
 				TxHash:      block.TransactionIds[oph.TransactionsInBlock],
@@ -352,8 +554,19 @@ func (restClient *RestClient) TxsForAddressFull(address, since_tx_id string, lim
 				TxAt:        block.Timestamp.Format("2006-01-02T15:04:05"),
 				BlockNumber: int64(oph.BlockNumber),
 				ConfirmedAt: "",
-				Extra:       extra,
+				Fee: &types.Fee{
+					Value:           transferOp.Fee.Amount,
+					TokenCode:       assets[feeIdentifier].TokenCode,
+					TokenIdentifier: feeIdentifier,
+					TokenDecimal:    assets[feeIdentifier].TokenDecimal,
+				},
+				TrxInBlock: int(oph.TransactionsInBlock),
+				OpIndex:    int(oph.OperationsInTransactions),
+				HistoryID:  oph.ID,
+				Extra:      extra,
 			}
+			setStatus([]*types.Tx{txOb}, txOb.BlockNumber, props)
+			SetDirection(txOb, acc.Name)
 			txs = append(txs, txOb)
 		}
 	}
@@ -362,7 +575,6 @@ func (restClient *RestClient) TxsForAddressFull(address, since_tx_id string, lim
 
 //address tx list
 func (restClient *RestClient) TxsForAddress(address, since_tx_id string, limit int) ([]*types.Tx, error) {
-	var txs []*types.Tx
 	acc, err := restClient.Database.GetAccount(address)
 	if err != nil {
 		return nil, err
@@ -375,37 +587,116 @@ func (restClient *RestClient) TxsForAddress(address, since_tx_id string, limit i
 	if err != nil {
 		return nil, err
 	}
+	props, err := restClient.getProperties()
+	if err != nil {
+		return nil, err
+	}
 
-	assets := map[string]*types.Asset{}
-	accounts := map[string]*gxcTypes.Account{}
+	return restClient.historyToTxs(acc, ophs, props, newHistoryCache())
+}
+
+//caches of the history conversion, the exporter keeps them across pages
+type historyCache struct {
+	assets     map[string]*types.Asset
+	accounts   map[string]*gxcTypes.Account
+	blockTimes map[int64]string
+}
+
+func newHistoryCache() *historyCache {
+	return &historyCache{
+		assets:     map[string]*types.Asset{},
+		accounts:   map[string]*gxcTypes.Account{},
+		blockTimes: map[int64]string{},
+	}
+}
+
+//time of the block, cached in blockTimes
+func (restClient *RestClient) blockTime(blockTimes map[int64]string, blockNum int64) (string, error) {
+	if blockTime, ok := blockTimes[blockNum]; ok {
+		return blockTime, nil
+	}
+	header, err := restClient.Database.GetBlockHeader(uint32(blockNum))
+	if err != nil {
+		return "", err
+	}
+	blockTimes[blockNum] = header.Timestamp.Format("2006-01-02T15:04:05")
+	return blockTimes[blockNum], nil
+}
+
+//transfers and the other decoded operations of the operation histories of acc
+func (restClient *RestClient) historyToTxs(acc *gxcTypes.Account, ophs []*history.OperationHistory, props *database.DynamicGlobalProperties, cache *historyCache) ([]*types.Tx, error) {
+	var txs []*types.Tx
+	assets, accounts, blockTimes := cache.assets, cache.accounts, cache.blockTimes
 	for _, oph := range ophs {
 		if byte_s, err := json.Marshal(oph); err == nil {
 			tx := gjson.ParseBytes(byte_s)
 			operation := tx.Get("op")
 			tx_op_code := operation.Get("0").Uint()
+			blockNum := tx.Get("block_num").Int()
 			if gxcTypes.OpType(tx_op_code) != gxcTypes.TransferOpType {
+				txOb, err := restClient.historyOperationToTx(json.RawMessage(operation.Raw))
+				if err != nil {
+					return nil, err
+				}
+				if txOb == nil {
+					continue
+				}
+				if txOb.TxAt, err = restClient.blockTime(blockTimes, blockNum); err != nil {
+					return nil, err
+				}
+				txOb.TrxInBlock = int(tx.Get("trx_in_block").Int())
+				txOb.OpIndex = int(tx.Get("op_in_trx").Int())
+				txOb.HistoryID = tx.Get("id").String()
+				txOb.Extra["block_num"] = strconv.FormatInt(blockNum, 10)
+				txOb.Extra["trx_in_block"] = strconv.FormatInt(tx.Get("trx_in_block").Int(), 10)
+				txOb.Extra["id"] = txOb.HistoryID
+				setStatus([]*types.Tx{txOb}, blockNum, props)
+				SetDirection(txOb, acc.Name)
+				txs = append(txs, txOb)
 				continue
 			}
 
+			if _, err := restClient.blockTime(blockTimes, blockNum); err != nil {
+				return nil, err
+			}
+
 			tokenIdentifier := operation.Get("1.amount.asset_id").String()
 			if assets[tokenIdentifier] == nil {
-				asset, _ := restClient.TokenDetail(tokenIdentifier)
+				asset, err := restClient.tokenOf(tokenIdentifier)
+				if err != nil {
+					return nil, err
+				}
 				assets[tokenIdentifier] = asset
 			}
 			feeIdentifier := operation.Get("1.fee.asset_id").String()
 			if assets[feeIdentifier] == nil {
-				asset, _ := restClient.TokenDetail(feeIdentifier)
+				asset, err := restClient.tokenOf(feeIdentifier)
+				if err != nil {
+					return nil, err
+				}
 				assets[feeIdentifier] = asset
 			}
 
 			from := operation.Get("1.from").String()
 			if accounts[from] == nil {
-				acc, _ := restClient.Database.GetAccountsByIds(from)
+				acc, err := restClient.Database.GetAccountsByIds(from)
+				if err != nil {
+					return nil, err
+				}
+				if len(acc) == 0 || acc[0] == nil {
+					return nil, errors.Errorf("account %s not exist", from)
+				}
 				accounts[from] = acc[0]
 			}
 			to := operation.Get("1.to").String()
 			if accounts[to] == nil {
-				acc, _ := restClient.Database.GetAccountsByIds(to)
+				acc, err := restClient.Database.GetAccountsByIds(to)
+				if err != nil {
+					return nil, err
+				}
+				if len(acc) == 0 || acc[0] == nil {
+					return nil, errors.Errorf("account %s not exist", to)
+				}
 				accounts[to] = acc[0]
 			}
 
@@ -438,16 +729,28 @@ func (restClient *RestClient) TxsForAddress(address, since_tx_id string, limit i
 			extra["feeTokenCode"] = assets[feeIdentifier].TokenCode
 			extra["feeTokenIdentifier"] = feeIdentifier
 			extra["feeTokenDecimal"] = strconv.FormatUint(uint64(assets[feeIdentifier].TokenDecimal), 10)
+			restClient.decryptMemo(extra)
 
 			txOb := &types.Tx{
 				TxHash:      "",
 				Inputs:      []types.UTXO{*in},
 				Outputs:     []types.UTXO{*out},
-				TxAt:        "",
+				TxAt:        blockTimes[blockNum],
 				BlockNumber: 0,
 				ConfirmedAt: "",
-				Extra:       extra,
+				Fee: &types.Fee{
+					Value:           operation.Get("1.fee.amount").Uint(),
+					TokenCode:       assets[feeIdentifier].TokenCode,
+					TokenIdentifier: feeIdentifier,
+					TokenDecimal:    assets[feeIdentifier].TokenDecimal,
+				},
+				TrxInBlock: int(tx.Get("trx_in_block").Int()),
+				OpIndex:    int(tx.Get("op_in_trx").Int()),
+				HistoryID:  tx.Get("id").String(),
+				Extra:      extra,
 			}
+			setStatus([]*types.Tx{txOb}, blockNum, props)
+			SetDirection(txOb, acc.Name)
 			txs = append(txs, txOb)
 		}
 	}
@@ -460,10 +763,18 @@ func (restClient *RestClient) GetTransactionByBlockNumAndId(block_num uint32, tr
 	if err != nil {
 		return nil, err
 	}
+	if trx_in_block < 0 || trx_in_block >= len(block.Transactions) {
+		return nil, errors.Errorf("no transaction %d in block %d", trx_in_block, block_num)
+	}
+	props, err := restClient.getProperties()
+	if err != nil {
+		return nil, err
+	}
 	txs, err := restClient.TransactionToTx(&block.Transactions[trx_in_block], block.TransactionIds[trx_in_block], &block.Timestamp, trx_in_block)
 	if err != nil {
 		return nil, err
 	}
+	setStatus(txs, int64(block_num), props)
 	return txs, nil
 }
 
@@ -473,24 +784,45 @@ func (restClient *RestClient) GetTransaction(tx_hash string) ([]*types.Tx, error
 	if err != nil || transaction == nil {
 		return nil, err
 	}
-	txs, err := restClient.TransactionToTx(transaction.Transaction, tx_hash, nil, nilNum)
-	txs[0].BlockNumber = int64(transaction.BlockNumber)
+	props, err := restClient.getProperties()
 	if err != nil {
 		return nil, err
 	}
+	//the node returns no position, it is the index of the id in the block
+	var blockTime *gxcTypes.Time
+	index := nilNum
+	if transaction.BlockNumber > 0 {
+		block, err := restClient.Database.GetBlock(transaction.BlockNumber)
+		if err != nil {
+			return nil, err
+		}
+		blockTime = &block.Timestamp
+		for i, id := range block.TransactionIds {
+			if id == tx_hash {
+				index = i
+				break
+			}
+		}
+	}
+	txs, err := restClient.TransactionToTx(transaction.Transaction, tx_hash, blockTime, index)
+	if err != nil {
+		return nil, err
+	}
+	setStatus(txs, int64(transaction.BlockNumber), props)
 	return txs, nil
 }
 
 func (restClient *RestClient) GetRequiredFee(memoOb *gxcTypes.Memo) (uint64, error) {
+	network := restClient.Network()
 	amountAssets := gxcTypes.AssetAmount{
-		AssetID: gxcTypes.MustParseObjectID("1.3.1"),
+		AssetID: gxcTypes.MustParseObjectID(network.CoreAssetId),
 		Amount:  1,
 	}
 	feeAssets := gxcTypes.AssetAmount{
-		AssetID: gxcTypes.MustParseObjectID("1.3.1"),
+		AssetID: gxcTypes.MustParseObjectID(network.CoreAssetId),
 		Amount:  0,
 	}
-	op := gxcTypes.NewTransferOperation(gxcTypes.MustParseObjectID("1.2.6"), gxcTypes.MustParseObjectID("1.2.7"), amountAssets, feeAssets, memoOb)
+	op := gxcTypes.NewTransferOperation(gxcTypes.MustParseObjectID(network.FeeFromAccountId), gxcTypes.MustParseObjectID(network.FeeToAccountId), amountAssets, feeAssets, memoOb)
 	fees, err := restClient.Database.GetRequiredFee([]gxcTypes.Operation{op}, feeAssets.AssetID.String())
 	if err != nil {
 		return 0, err
@@ -499,6 +831,9 @@ func (restClient *RestClient) GetRequiredFee(memoOb *gxcTypes.Memo) (uint64, err
 }
 
 func (restClient *RestClient) BuildTransaction(from_address, to_address, symbol string, amount uint64, memoOb *gxcTypes.Memo) (string, error) {
+	if err := ValidateAddress(to_address); err != nil {
+		return "", err
+	}
 	fromAccount, err := restClient.Database.GetAccount(from_address)
 	if err != nil {
 		return "", err
@@ -511,7 +846,7 @@ func (restClient *RestClient) BuildTransaction(from_address, to_address, symbol
 
 	//token_identifier(empty for the main coin)
 	if symbol == "" {
-		symbol = "GXC"
+		symbol = restClient.Network().CoreAsset
 	}
 	amountSymbol, err := restClient.Database.GetAsset(symbol)
 	if err != nil {
@@ -522,7 +857,7 @@ func (restClient *RestClient) BuildTransaction(from_address, to_address, symbol
 		Amount:  amount,
 	}
 
-	fee, err := restClient.Database.GetAsset("GXC")
+	fee, err := restClient.Database.GetAsset(restClient.Network().CoreAsset)
 	if err != nil {
 		return "", err
 	}
@@ -539,19 +874,37 @@ func (restClient *RestClient) BuildTransaction(from_address, to_address, symbol
 	}
 	op.Fee.Amount = fees[0].Amount
 
+	stx, err := restClient.newTransaction(op)
+	if err != nil {
+		return "", err
+	}
+	if err := restClient.logEncoded(stx, "BuildTransaction"); err != nil {
+		return "", err
+	}
+
+	str, _ := json.Marshal(stx)
+	metrics.Transactions.Inc(metrics.StageBuilt)
+	restClient.log().Log(LevelInfo, "transaction built", F("method", "BuildTransaction"), F("account", from_address),
+		F("to", to_address), F("symbol", symbol), F("amount", amount), F("fee", op.Fee.Amount))
+	return string(str), nil
+}
+
+//unsigned transaction of ops referencing the last irreversible block, expiring in 10 minutes
+func (restClient *RestClient) newTransaction(ops ...gxcTypes.Operation) (*gxcTypes.SignedTransaction, error) {
 	props, err := restClient.Database.GetDynamicGlobalProperties()
 	if err != nil {
-		return "", errors.Wrap(err, "failed to get dynamic global properties")
+		return nil, errors.Wrap(err, "failed to get dynamic global properties")
 	}
+	observeBlocks(props)
 
 	block, err := restClient.Database.GetBlock(props.LastIrreversibleBlockNum)
 	if err != nil {
-		return "", errors.Wrap(err, "failed to get block")
+		return nil, errors.Wrap(err, "failed to get block")
 	}
 
 	refBlockPrefix, err := sign.RefBlockPrefix(block.Previous)
 	if err != nil {
-		return "", errors.Wrap(err, "failed to sign block prefix")
+		return nil, errors.Wrap(err, "failed to sign block prefix")
 	}
 
 	expiration := props.Time.Add(10 * time.Minute)
@@ -561,65 +914,127 @@ func (restClient *RestClient) BuildTransaction(from_address, to_address, symbol
 		Expiration:     gxcTypes.Time{Time: &expiration},
 	})
 
-	stx.PushOperation(op)
+	for _, op := range ops {
+		stx.PushOperation(op)
+	}
+
+	return stx, nil
+}
 
+//encode the transaction to check it and log its hex
+func (restClient *RestClient) logEncoded(stx *gxcTypes.SignedTransaction, method string) error {
 	var b bytes.Buffer
 	x := transaction.NewEncoder(&b)
 
 	if err := x.Encode(stx.Transaction); err != nil {
-		return "", nil
+		return errors.Wrap(err, "failed to encode transaction")
 	}
-	s := hex.EncodeToString(b.Bytes())
-	fmt.Println(s)
-
-	str, _ := json.Marshal(stx)
-	return string(str), nil
+	restClient.log().Log(LevelDebug, "encoded transaction", F("method", method), F("tx_hex", hex.EncodeToString(b.Bytes())))
+	return nil
 }
 
+//the unsigned transfer with the fee of its transfer set to the required fee
 func (restClient *RestClient) TransactionFee(raw_unsigned_tx_hex string) (string, error) {
-	var stx *gxcTypes.SignedTransaction
-	json.Unmarshal([]byte(raw_unsigned_tx_hex), &stx)
-	var transferOp *gxcTypes.TransferOperation
-	byte, err := json.Marshal(stx.Transaction.Operations[0])
+	stx, err := ParseTransaction(raw_unsigned_tx_hex)
 	if err != nil {
 		return "", err
 	}
-	json.Unmarshal(byte, &transferOp)
+	if len(stx.Operations) == 0 {
+		return "", errors.New("transaction has no operations")
+	}
+	transferOp, ok := stx.Operations[0].(*gxcTypes.TransferOperation)
+	if !ok {
+		return "", errors.Errorf("operation %d is not a transfer", stx.Operations[0].Type())
+	}
 
-	fees, err := restClient.Database.GetRequiredFee([]gxcTypes.Operation{stx.Operations[0]}, transferOp.Fee.AssetID.String())
+	fees, err := restClient.Database.GetRequiredFee([]gxcTypes.Operation{transferOp}, transferOp.Fee.AssetID.String())
 	if err != nil {
 		return "", err
 	}
-
+	if len(fees) == 0 {
+		return "", errors.New("no required fee of the transfer")
+	}
 	transferOp.Fee.Amount = fees[0].Amount
-	stx.Operations = nil
-	stx.PushOperation(transferOp)
 
-	str, _ := json.Marshal(stx)
+	str, err := json.Marshal(stx)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal the transaction")
+	}
 	return string(str), nil
 }
 
 //sign unsigned tx with given signature and broadcast
 func (restClient *RestClient) SignTransaction(unsignex_tx_hex, signature string) (*types.Tx, error) {
-	var stx *gxcTypes.SignedTransaction
-	json.Unmarshal([]byte(unsignex_tx_hex), &stx)
+	stx, err := ParseTransaction(unsignex_tx_hex)
+	if err != nil {
+		return nil, err
+	}
 	stx.Signatures = []string{signature}
+	return restClient.broadcast(stx, "SignTransaction")
+}
+
+//broadcast signed tx with all its signatures, e.g. a multi-signature transaction
+func (restClient *RestClient) BroadcastTransaction(signedTx string) (*types.Tx, error) {
+	stx, err := ParseTransaction(signedTx)
+	if err != nil {
+		return nil, err
+	}
+	if len(stx.Signatures) == 0 {
+		return nil, errors.New("transaction is not signed")
+	}
+	return restClient.broadcast(stx, "BroadcastTransaction")
+}
+
+func (restClient *RestClient) broadcast(stx *gxcTypes.SignedTransaction, method string) (*types.Tx, error) {
 	resp, err := restClient.Broadcast.BroadcastTransactionSynchronous(stx.Transaction)
 	if err != nil {
+		restClient.log().Log(LevelError, "broadcast failed", F("method", method), F("error", err))
 		return nil, err
 	}
+	metrics.Transactions.Inc(metrics.StageBroadcast)
+	restClient.log().Log(LevelInfo, "transaction broadcast", F("method", method), F("tx_hash", resp.ID), F("block_num", resp.BlockNum))
 
-	txs, err := restClient.TransactionToTx(stx.Transaction, resp.ID, nil, nilNum)
+	var blockTime *gxcTypes.Time
+	if resp.BlockNum > 0 {
+		header, err := restClient.Database.GetBlockHeader(uint32(resp.BlockNum))
+		if err != nil {
+			return nil, err
+		}
+		blockTime = &header.Timestamp
+	}
+	txs, err := restClient.TransactionToTx(stx.Transaction, resp.ID, blockTime, int(resp.TrxNum))
 	if err != nil {
 		return nil, err
 	}
+	//the transaction is sent even when none of its operations is summarized
+	if len(txs) == 0 {
+		tx := &types.Tx{
+			TxHash:     resp.ID,
+			Inputs:     []types.UTXO{},
+			Outputs:    []types.UTXO{},
+			TrxInBlock: int(resp.TrxNum),
+			Extra:      map[string]string{},
+		}
+		if blockTime != nil {
+			tx.TxAt = blockTime.Format("2006-01-02T15:04:05")
+		}
+		txs = append(txs, tx)
+	}
+	props, err := restClient.getProperties()
+	if err != nil {
+		return nil, err
+	}
+	setStatus(txs, int64(resp.BlockNum), props)
 	tx := txs[0]
-	tx.BlockNumber = int64(resp.BlockNum)
+	if resp.Expired {
+		tx.Status = types.TxStatusFailed
+	}
 	return tx, nil
 }
 
 //token_code or token_identifier to token detail
-func (restClient *RestClient) TokenDetail(token string) (*types.Asset, error) {
+//the asset without the details of TokenDetail
+func (restClient *RestClient) tokenOf(token string) (*types.Asset, error) {
 	gxcAsset, err := restClient.Database.GetAsset(token)
 	if err != nil {
 		return nil, err
@@ -635,8 +1050,25 @@ func (restClient *RestClient) TokenDetail(token string) (*types.Asset, error) {
 
 func (restClient *RestClient) TransactionToTx(transaction *gxcTypes.Transaction, transactionId string, blockTime *gxcTypes.Time, index int) ([]*types.Tx, error) {
 	var txs []*types.Tx
-	for _, op := range transaction.Operations {
+	for opIndex, op := range transaction.Operations {
 		if op.Type() != gxcTypes.TransferOpType {
+			tx, err := restClient.operationToTx(op)
+			if err != nil {
+				return nil, err
+			}
+			if tx == nil {
+				continue
+			}
+			tx.TxHash = transactionId
+			if blockTime != nil {
+				tx.TxAt = blockTime.Format("2006-01-02T15:04:05")
+			}
+			if index != nilNum {
+				tx.Extra["trx_in_block"] = strconv.FormatInt(int64(index), 10)
+			}
+			tx.TrxInBlock = index
+			tx.OpIndex = opIndex
+			txs = append(txs, tx)
 			continue
 		}
 		var transferOp gxcTypes.TransferOperation
@@ -677,6 +1109,7 @@ func (restClient *RestClient) TransactionToTx(transaction *gxcTypes.Transaction,
 			extra["to"] = transferOp.Memo.To.String()
 			extra["message"] = transferOp.Memo.Message.String()
 			extra["nonce"] = strconv.FormatUint(uint64(transferOp.Memo.Nonce), 10)
+			restClient.decryptMemo(extra)
 		}
 		feeAsset, err := restClient.Database.GetAsset(transferOp.Fee.AssetID.String())
 		if err != nil {
@@ -708,7 +1141,15 @@ func (restClient *RestClient) TransactionToTx(transaction *gxcTypes.Transaction,
 			TxAt:        txAt,
 			BlockNumber: 0,
 			ConfirmedAt: "",
-			Extra:       extra,
+			Fee: &types.Fee{
+				Value:           transferOp.Fee.Amount,
+				TokenCode:       feeAsset.Symbol,
+				TokenIdentifier: feeAsset.ID.String(),
+				TokenDecimal:    feeAsset.Precision,
+			},
+			TrxInBlock: index,
+			OpIndex:    opIndex,
+			Extra:      extra,
 		}
 		txs = append(txs, tx)
 	}
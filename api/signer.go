@@ -0,0 +1,214 @@
+package api
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/juju/errors"
+	"gxclient-adapter/metrics"
+	"gxclient-go/sign"
+	"net"
+	"sync"
+	"time"
+)
+
+//Signer signs a transaction digest with the private key behind pubKey.
+//pubKey is a base58 public key with the network prefix (GXC...) or a public key in hex,
+//the returned signature is the hex encoded compact signature
+type Signer interface {
+	SignDigest(digest []byte, pubKey string) (string, error)
+}
+
+//KeySigner keeps private keys in memory, the base58 public keys have the prefix of its network
+type KeySigner struct {
+	network *NetworkConfig
+	mu      sync.RWMutex
+	//by compressed public key in hex
+	keys map[string]*btcec.PrivateKey
+	pubs []string
+}
+
+//key signer of the mainnet
+func NewKeySigner(priHexes ...string) (*KeySigner, error) {
+	return NewKeySignerWithNetwork(MainNet, priHexes...)
+}
+
+//key signer taking the base58 public keys with the prefix of network
+func NewKeySignerWithNetwork(network *NetworkConfig, priHexes ...string) (*KeySigner, error) {
+	if network == nil {
+		return nil, errors.New("network required")
+	}
+	signer := &KeySigner{network: network, keys: map[string]*btcec.PrivateKey{}}
+	for _, priHex := range priHexes {
+		if err := signer.AddKey(priHex); err != nil {
+			return nil, err
+		}
+	}
+	return signer, nil
+}
+
+//AddKey adds a private key in hex
+func (signer *KeySigner) AddKey(priHex string) error {
+	pri, err := parsePriKeyHex(priHex)
+	if err != nil {
+		return err
+	}
+	pubHex := hex.EncodeToString(pri.PubKey().SerializeCompressed())
+
+	signer.mu.Lock()
+	defer signer.mu.Unlock()
+	if _, ok := signer.keys[pubHex]; !ok {
+		signer.pubs = append(signer.pubs, pubHex)
+	}
+	signer.keys[pubHex] = pri
+	return nil
+}
+
+//PubKeys returns base58 public keys of the held private keys
+func (signer *KeySigner) PubKeys() []string {
+	signer.mu.RLock()
+	defer signer.mu.RUnlock()
+	pubKeys := make([]string, 0, len(signer.pubs))
+	for _, pubHex := range signer.pubs {
+		pubKey, err := signer.network.PubKeyHexToBase58(pubHex)
+		if err != nil {
+			continue
+		}
+		pubKeys = append(pubKeys, pubKey)
+	}
+	return pubKeys
+}
+
+func (signer *KeySigner) SignDigest(digest []byte, pubKey string) (string, error) {
+	pubHex, err := normalizePubKey(signer.network, pubKey)
+	if err != nil {
+		return "", err
+	}
+	signer.mu.RLock()
+	pri, ok := signer.keys[pubHex]
+	signer.mu.RUnlock()
+	if !ok {
+		return "", errors.Errorf("no private key for %s", pubKey)
+	}
+	sig := sign.SignBufferSha256(digest, pri.ToECDSA())
+	if sig == nil {
+		return "", errors.New("failed to sign the digest")
+	}
+	return hex.EncodeToString(sig), nil
+}
+
+//signRequest and signResponse are sent as one json line each over the signer socket
+type signRequest struct {
+	Digest string `json:"digest"`
+	PubKey string `json:"pub_key"`
+}
+
+type signResponse struct {
+	Signature string `json:"signature,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+//SocketSigner asks a local signing daemon listening on a unix socket to sign,
+//so key material can live in an isolated process
+type SocketSigner struct {
+	Path    string
+	Timeout time.Duration
+}
+
+func NewSocketSigner(path string) *SocketSigner {
+	return &SocketSigner{Path: path, Timeout: 10 * time.Second}
+}
+
+func (signer *SocketSigner) SignDigest(digest []byte, pubKey string) (string, error) {
+	conn, err := net.DialTimeout("unix", signer.Path, signer.Timeout)
+	if err != nil {
+		return "", errors.Annotate(err, "dial signer")
+	}
+	defer conn.Close()
+	if signer.Timeout > 0 {
+		conn.SetDeadline(time.Now().Add(signer.Timeout))
+	}
+
+	req := signRequest{Digest: hex.EncodeToString(digest), PubKey: pubKey}
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return "", errors.Annotate(err, "send sign request")
+	}
+
+	var resp signResponse
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&resp); err != nil {
+		return "", errors.Annotate(err, "read sign response")
+	}
+	if len(resp.Error) > 0 {
+		return "", errors.Errorf("signer: %s", resp.Error)
+	}
+	return resp.Signature, nil
+}
+
+//ServeSigner answers SocketSigner requests on l with signer until l is closed,
+//it is the building block of a signing daemon
+func ServeSigner(l net.Listener, signer Signer) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go serveSignerConn(conn, signer)
+	}
+}
+
+func serveSignerConn(conn net.Conn, signer Signer) {
+	defer conn.Close()
+	decoder := json.NewDecoder(bufio.NewReader(conn))
+	encoder := json.NewEncoder(conn)
+	for {
+		var req signRequest
+		if err := decoder.Decode(&req); err != nil {
+			return
+		}
+		var resp signResponse
+		digest, err := hex.DecodeString(req.Digest)
+		if err == nil {
+			resp.Signature, err = signer.SignDigest(digest, req.PubKey)
+		}
+		if err != nil {
+			resp.Error = err.Error()
+		}
+		if err := encoder.Encode(resp); err != nil {
+			return
+		}
+	}
+}
+
+//SignWith signs the unsigned tx built by BuildTransaction through signer with the key of pubKey
+func SignWith(signer Signer, pubKey, chainId, raw_tx_hex string) (string, error) {
+	stx, err := ParseTransaction(raw_tx_hex)
+	if err != nil {
+		return "", err
+	}
+
+	digest, err := stx.Digest(chainId)
+	if err != nil {
+		return "", errors.Annotate(err, "failed to digest the transaction")
+	}
+
+	signature, err := signer.SignDigest(digest, pubKey)
+	if err != nil {
+		return "", errors.Annotate(err, "failed to sign the transaction")
+	}
+	metrics.Transactions.Inc(metrics.StageSigned)
+	return signature, nil
+}
+
+//normalizePubKey returns the compressed hex form of a hex public key or of a
+//base58 public key with the prefix of network
+func normalizePubKey(network *NetworkConfig, pubKey string) (string, error) {
+	if _, err := hex.DecodeString(pubKey); err == nil {
+		pubBase58, err := network.PubKeyHexToBase58(pubKey)
+		if err != nil {
+			return "", err
+		}
+		pubKey = pubBase58
+	}
+	return network.PubKeyBase58ToHex(pubKey)
+}
@@ -0,0 +1,310 @@
+package api
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/tidwall/gjson"
+	"gxclient-go/transaction"
+	gxcTypes "gxclient-go/types"
+	"strings"
+	"time"
+)
+
+//BalanceLockOperation locks an amount of the core asset for the interest of a lock program
+type BalanceLockOperation struct {
+	Fee     gxcTypes.AssetAmount `json:"fee"`
+	Account gxcTypes.ObjectID    `json:"account"`
+	//must be close to the head block time
+	CreateDateTime gxcTypes.Time        `json:"create_date_time"`
+	ProgramId      string               `json:"program_id"`
+	Amount         gxcTypes.AssetAmount `json:"amount"`
+	LockDays       uint32               `json:"lock_days"`
+	InterestRate   uint32               `json:"interest_rate"`
+	Memo           string               `json:"memo"`
+	Extensions     []json.RawMessage    `json:"extensions"`
+}
+
+func (op *BalanceLockOperation) Type() gxcTypes.OpType { return BalanceLockOpType }
+
+func (op *BalanceLockOperation) MarshalTransaction(encoder *transaction.Encoder) error {
+	enc := transaction.NewRollingEncoder(encoder)
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Account)
+	enc.Encode(op.CreateDateTime)
+	enc.Encode(op.ProgramId)
+	enc.Encode(op.Amount)
+	enc.Encode(op.LockDays)
+	enc.Encode(op.InterestRate)
+	enc.Encode(op.Memo)
+	//Extensions
+	enc.EncodeUVarint(0)
+	return enc.Err()
+}
+
+//BalanceUnlockOperation returns a locked balance with its interest
+type BalanceUnlockOperation struct {
+	Fee        gxcTypes.AssetAmount `json:"fee"`
+	Account    gxcTypes.ObjectID    `json:"account"`
+	LockId     gxcTypes.ObjectID    `json:"lock_id"`
+	Extensions []json.RawMessage    `json:"extensions"`
+}
+
+func (op *BalanceUnlockOperation) Type() gxcTypes.OpType { return BalanceUnlockOpType }
+
+func (op *BalanceUnlockOperation) MarshalTransaction(encoder *transaction.Encoder) error {
+	enc := transaction.NewRollingEncoder(encoder)
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Account)
+	enc.Encode(op.LockId)
+	//Extensions
+	enc.EncodeUVarint(0)
+	return enc.Err()
+}
+
+//LockProgram is a balance lock program of the chain parameters
+type LockProgram struct {
+	ProgramId    string `json:"program_id"`
+	LockDays     uint32 `json:"lock_days"`
+	InterestRate uint32 `json:"interest_rate"`
+	IsValid      bool   `json:"is_valid"`
+}
+
+//the balance lock programs, read from the extensions of the chain parameters
+//like gxclient-go reads the staking programs
+func (restClient *RestClient) LockPrograms() ([]*LockProgram, error) {
+	var properties json.RawMessage
+	if err := restClient.callDatabase("get_global_properties", []interface{}{}, &properties); err != nil {
+		return nil, errors.Wrap(err, "failed to get global properties")
+	}
+	var programs []*LockProgram
+	for _, ex := range gjson.GetBytes(properties, "parameters.extensions").Array() {
+		for _, param := range ex.Get("1.params").Array() {
+			if !param.Get("1.lock_days").Exists() {
+				continue
+			}
+			programs = append(programs, &LockProgram{
+				ProgramId:    param.Get("0").String(),
+				LockDays:     uint32(param.Get("1.lock_days").Uint()),
+				InterestRate: uint32(param.Get("1.interest_rate").Uint()),
+				IsValid:      param.Get("1.is_valid").Bool(),
+			})
+		}
+	}
+	return programs, nil
+}
+
+//stakings of the account
+func (restClient *RestClient) StakingsForAddress(address string) ([]*gxcTypes.StakingObject, error) {
+	acc, err := restClient.Database.GetAccount(address)
+	if err != nil {
+		return nil, err
+	}
+	return restClient.Database.GetStakingObjects(acc.ID.String())
+}
+
+//witness id of a witness id or of the account of a witness
+func (restClient *RestClient) trustNode(trustNode string) (gxcTypes.ObjectID, error) {
+	if strings.HasPrefix(trustNode, "1.6.") {
+		return gxcTypes.ParseObjectID(trustNode)
+	}
+	acc, err := restClient.Database.GetAccount(trustNode)
+	if err != nil {
+		return gxcTypes.ObjectID{}, err
+	}
+	witness, err := restClient.Database.GetWitnessByAccount(acc.ID.String())
+	if err != nil {
+		return gxcTypes.ObjectID{}, err
+	}
+	if !witness.IsValid {
+		return gxcTypes.ObjectID{}, errors.Errorf("witness %s is not valid", trustNode)
+	}
+	return gxcTypes.ParseObjectID(witness.Id)
+}
+
+//a decimal amount of the core asset, like "100 GXC"
+func (restClient *RestClient) parseCoreAmount(amount string) (*gxcTypes.AssetAmount, error) {
+	assetAmount, err := restClient.parseAmount(amount)
+	if err != nil {
+		return nil, err
+	}
+	if assetAmount.AssetID.String() != restClient.Network().CoreAssetId {
+		return nil, errors.Errorf("amount %s is not in %s", amount, restClient.Network().CoreAsset)
+	}
+	if assetAmount.Amount == 0 {
+		return nil, errors.Errorf("amount %s must be positive", amount)
+	}
+	return assetAmount, nil
+}
+
+//staking of the owner by id
+func (restClient *RestClient) ownStaking(owner *gxcTypes.Account, stakingId string) (*gxcTypes.StakingObject, error) {
+	stakings, err := restClient.Database.GetStakingObjects(owner.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	for _, staking := range stakings {
+		if staking.ID.String() == stakingId {
+			return staking, nil
+		}
+	}
+	return nil, errors.Errorf("staking %s of %s not exist", stakingId, owner.Name)
+}
+
+//build an unsigned staking of amount, a decimal amount of GXC like "100 GXC", in the
+//staking program voting for trustNode, a witness id or the account of a witness
+func (restClient *RestClient) BuildStakingCreate(owner, trustNode, programId, amount string) (string, error) {
+	acc, err := restClient.Database.GetAccount(owner)
+	if err != nil {
+		return "", err
+	}
+	node, err := restClient.trustNode(trustNode)
+	if err != nil {
+		return "", err
+	}
+	programs, err := restClient.Database.GetStakingPrograms()
+	if err != nil {
+		return "", err
+	}
+	var program *gxcTypes.StakingProgram
+	for _, p := range programs {
+		if p.ProgramId == programId {
+			program = p
+		}
+	}
+	if program == nil {
+		return "", errors.Errorf("staking program %s not exist", programId)
+	}
+	stakingAmount, err := restClient.parseCoreAmount(amount)
+	if err != nil {
+		return "", err
+	}
+
+	op := gxcTypes.NewStakingCreateOperation(gxcTypes.MustParseObjectID(acc.ID.String()), node, *stakingAmount,
+		gxcTypes.AssetAmount{}, program.ProgramId, program.Weight, program.StakingDays)
+	return restClient.buildOperation("BuildStakingCreate", op, &op.Fee, F("account", owner), F("trust_node", node.String()),
+		F("program_id", programId), F("amount", stakingAmount.Amount))
+}
+
+//build an unsigned vote of a staking of owner for another trust node
+func (restClient *RestClient) BuildStakingUpdate(owner, stakingId, trustNode string) (string, error) {
+	acc, err := restClient.Database.GetAccount(owner)
+	if err != nil {
+		return "", err
+	}
+	staking, err := restClient.ownStaking(acc, stakingId)
+	if err != nil {
+		return "", err
+	}
+	node, err := restClient.trustNode(trustNode)
+	if err != nil {
+		return "", err
+	}
+	op := &gxcTypes.StakingUpdateOperation{
+		Owner:      gxcTypes.MustParseObjectID(acc.ID.String()),
+		TrustNode:  node,
+		StakingId:  staking.ID,
+		Extensions: []json.RawMessage{},
+	}
+	return restClient.buildOperation("BuildStakingUpdate", op, &op.Fee, F("account", owner), F("staking_id", stakingId),
+		F("trust_node", node.String()))
+}
+
+//build an unsigned claim of a staking of owner, fails before the staking days are over
+func (restClient *RestClient) BuildStakingClaim(owner, stakingId string) (string, error) {
+	acc, err := restClient.Database.GetAccount(owner)
+	if err != nil {
+		return "", err
+	}
+	staking, err := restClient.ownStaking(acc, stakingId)
+	if err != nil {
+		return "", err
+	}
+	props, err := restClient.getProperties()
+	if err != nil {
+		return "", err
+	}
+	if staking.CreateDateTime.Time != nil {
+		due := staking.CreateDateTime.Add(time.Duration(staking.StakingDays) * 24 * time.Hour)
+		if props.Time.Before(due) {
+			return "", errors.Errorf("staking %s can not be claimed before %s", stakingId, due.Format(blockTimeFormat))
+		}
+	}
+	op := &gxcTypes.StakingClaimOperation{
+		Owner:      gxcTypes.MustParseObjectID(acc.ID.String()),
+		StakingId:  staking.ID,
+		Extensions: []json.RawMessage{},
+	}
+	return restClient.buildOperation("BuildStakingClaim", op, &op.Fee, F("account", owner), F("staking_id", stakingId))
+}
+
+//build an unsigned lock of amount, a decimal amount of GXC like "100 GXC", in the lock program
+func (restClient *RestClient) BuildBalanceLock(account, programId, amount, memo string) (string, error) {
+	acc, err := restClient.Database.GetAccount(account)
+	if err != nil {
+		return "", err
+	}
+	programs, err := restClient.LockPrograms()
+	if err != nil {
+		return "", err
+	}
+	var program *LockProgram
+	for _, p := range programs {
+		if p.ProgramId == programId {
+			program = p
+		}
+	}
+	if program == nil || !program.IsValid {
+		return "", errors.Errorf("lock program %s not exist", programId)
+	}
+	lockAmount, err := restClient.parseCoreAmount(amount)
+	if err != nil {
+		return "", err
+	}
+	props, err := restClient.getProperties()
+	if err != nil {
+		return "", err
+	}
+
+	op := &BalanceLockOperation{
+		Account:        gxcTypes.MustParseObjectID(acc.ID.String()),
+		CreateDateTime: gxcTypes.NewTime(*props.Time.Time),
+		ProgramId:      program.ProgramId,
+		Amount:         *lockAmount,
+		LockDays:       program.LockDays,
+		InterestRate:   program.InterestRate,
+		Memo:           memo,
+		Extensions:     []json.RawMessage{},
+	}
+	return restClient.buildOperation("BuildBalanceLock", op, &op.Fee, F("account", account), F("program_id", programId),
+		F("amount", lockAmount.Amount))
+}
+
+//build an unsigned unlock of a locked balance of account
+func (restClient *RestClient) BuildBalanceUnlock(account, lockId string) (string, error) {
+	acc, err := restClient.Database.GetAccount(account)
+	if err != nil {
+		return "", err
+	}
+	lockObjectId, err := gxcTypes.ParseObjectID(lockId)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid lock id %s", lockId)
+	}
+	var objects []json.RawMessage
+	if err := restClient.callDatabase("get_objects", []interface{}{[]string{lockId}}, &objects); err != nil {
+		return "", errors.Wrapf(err, "failed to get lock %s", lockId)
+	}
+	if len(objects) == 0 || gjson.ParseBytes(objects[0]).Type == gjson.Null {
+		return "", errors.Errorf("lock %s not exist", lockId)
+	}
+	if owner := gjson.GetBytes(objects[0], "account").String(); owner != acc.ID.String() {
+		return "", errors.Errorf("lock %s of %s not exist", lockId, account)
+	}
+	op := &BalanceUnlockOperation{
+		Account:    gxcTypes.MustParseObjectID(acc.ID.String()),
+		LockId:     lockObjectId,
+		Extensions: []json.RawMessage{},
+	}
+	return restClient.buildOperation("BuildBalanceUnlock", op, &op.Fee, F("account", account), F("lock_id", lockId))
+}
@@ -0,0 +1,449 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/tidwall/gjson"
+	"gxclient-adapter/metrics"
+	"gxclient-adapter/types"
+	"gxclient-go/api/database"
+	"gxclient-go/api/history"
+	"gxclient-go/api/login"
+	"gxclient-go/rpc"
+	"gxclient-go/rpc/websocket"
+	"gxclient-go/transaction"
+	gxcTypes "gxclient-go/types"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//kinds of notices
+const (
+	noticeBlock   = "block"
+	noticePending = "pending"
+	noticeAccount = "account"
+)
+
+type notice struct {
+	kind string
+	raw  json.RawMessage
+}
+
+//subscribed account
+type accountState struct {
+	name string
+	id   string
+	//instance of the last operation history sent
+	last uint64
+}
+
+//Subscriber receives the push notices of a websocket node on its own connection,
+//decodes them to txs and subscribes again after a reconnect
+type Subscriber struct {
+	client *RestClient
+
+	//Dial opens the subscription connection, a websocket transport to the url by default
+	Dial func() (rpc.CallCloser, error)
+
+	//Blocks receives the txs of the applied blocks. The channels must be drained, the
+	//notices wait for room until Run is stopped and the notices arriving meanwhile
+	//are dropped, the missed blocks and account txs are sent with the next notice
+	Blocks chan *types.Tx
+	//Accounts receives the new txs of the subscribed accounts
+	Accounts chan *types.Tx
+	//Pending receives the txs accepted by the node but not in a block yet
+	Pending chan *types.Tx
+
+	//Interval to check the connection and to wait before reconnecting
+	Interval time.Duration
+	//Timeout of the connection check
+	Timeout time.Duration
+
+	blocks    bool
+	pending   bool
+	accounts  map[string]*accountState
+	lastBlock uint32
+	notices   chan notice
+	//closed to stop a send on a full channel, set by Run
+	stop <-chan struct{}
+}
+
+//url must be a websocket endpoint, the txs are decoded with restClient
+func (restClient *RestClient) NewSubscriber(url string) (*Subscriber, error) {
+	if !strings.HasPrefix(url, "ws") {
+		return nil, errors.Errorf("subscriptions need a websocket endpoint, got %s", url)
+	}
+	return &Subscriber{
+		client: restClient,
+		Dial: func() (rpc.CallCloser, error) {
+			cc, err := websocket.NewTransport(url)
+			if err != nil {
+				return nil, err
+			}
+			return metrics.InstrumentCaller(cc), nil
+		},
+		Blocks:   make(chan *types.Tx, 100),
+		Accounts: make(chan *types.Tx, 100),
+		Pending:  make(chan *types.Tx, 100),
+		Interval: 10 * time.Second,
+		Timeout:  10 * time.Second,
+		accounts: map[string]*accountState{},
+		notices:  make(chan notice, 1000),
+	}, nil
+}
+
+//subscribe to the applied blocks, must be called before Run
+func (sub *Subscriber) SubscribeBlocks() {
+	sub.blocks = true
+}
+
+//subscribe to the pending txs, must be called before Run
+func (sub *Subscriber) SubscribePending() {
+	sub.pending = true
+}
+
+//subscribe to the changes of the accounts, must be called before Run
+func (sub *Subscriber) SubscribeAccounts(accounts ...string) {
+	for _, account := range accounts {
+		if sub.accounts[account] == nil {
+			sub.accounts[account] = &accountState{name: account}
+		}
+	}
+}
+
+//receive the notices until stop is closed, reconnecting when the connection is lost
+func (sub *Subscriber) Run(stop <-chan struct{}) error {
+	if !sub.blocks && !sub.pending && len(sub.accounts) == 0 {
+		return errors.New("no subscription")
+	}
+	sub.stop = stop
+	for {
+		cc, databaseAPIID, err := sub.connect()
+		if err == nil {
+			err = sub.serve(cc, databaseAPIID, stop)
+			cc.Close()
+			if err == nil {
+				return nil
+			}
+		}
+		sub.client.log().Log(LevelWarn, "subscription connection lost", F("method", "Run"), F("error", err))
+		select {
+		case <-stop:
+			return nil
+		case <-time.After(sub.Interval):
+		}
+	}
+}
+
+//open a connection and register the callbacks
+func (sub *Subscriber) connect() (rpc.CallCloser, rpc.APIID, error) {
+	cc, err := sub.Dial()
+	if err != nil {
+		return nil, "", err
+	}
+	databaseAPIID, err := login.NewAPI(cc).Database()
+	if err != nil {
+		cc.Close()
+		return nil, "", err
+	}
+	if err := sub.subscribe(cc, databaseAPIID); err != nil {
+		cc.Close()
+		return nil, "", err
+	}
+	sub.client.log().Log(LevelInfo, "subscribed", F("method", "Run"), F("blocks", sub.blocks),
+		F("pending", sub.pending), F("accounts", len(sub.accounts)))
+	return cc, databaseAPIID, nil
+}
+
+func (sub *Subscriber) subscribe(cc rpc.CallCloser, databaseAPIID rpc.APIID) error {
+	//callbacks registered on the connection, the transport numbers them from 1
+	callbacks := uint64(0)
+	if sub.blocks {
+		callbacks++
+		if err := cc.SetCallback(databaseAPIID, "set_block_applied_callback", sub.callback(noticeBlock)); err != nil {
+			return errors.Wrap(err, "failed to subscribe to blocks")
+		}
+	}
+	if sub.pending {
+		callbacks++
+		if err := cc.SetCallback(databaseAPIID, "set_pending_transaction_callback", sub.callback(noticePending)); err != nil {
+			return errors.Wrap(err, "failed to subscribe to pending transactions")
+		}
+	}
+	if len(sub.accounts) == 0 {
+		return nil
+	}
+	var names []string
+	for name, state := range sub.accounts {
+		names = append(names, name)
+		if len(state.id) > 0 {
+			continue
+		}
+		acc, err := sub.client.Database.GetAccount(name)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get account %s", name)
+		}
+		state.id = acc.ID.String()
+		ophs, err := sub.client.History.GetAccountHistory(state.id, "1.11.0", 1, "1.11.0")
+		if err != nil {
+			return errors.Wrapf(err, "failed to get history of %s", name)
+		}
+		if len(ophs) > 0 {
+			state.last = objectInstance(ophs[0].ID)
+		}
+	}
+	//SetCallback registers the callback but calls set_subscribe_callback with its id
+	//only, the node rejects it without notify_remove_create, so it is called again
+	//with both parameters
+	callbacks++
+	if err := cc.SetCallback(databaseAPIID, "set_subscribe_callback", sub.callback(noticeAccount)); err != nil {
+		if _, rejected := errors.Cause(err).(*rpc.RPCError); !rejected {
+			return errors.Wrap(err, "failed to subscribe to objects")
+		}
+	}
+	if err := cc.Call(databaseAPIID, "set_subscribe_callback", []interface{}{callbacks, false}, nil); err != nil {
+		return errors.Wrap(err, "failed to subscribe to objects")
+	}
+	if err := cc.Call(databaseAPIID, "get_full_accounts", []interface{}{names, true}, nil); err != nil {
+		return errors.Wrap(err, "failed to subscribe to accounts")
+	}
+	//catch up with the operations missed while disconnected
+	for _, state := range sub.accounts {
+		sub.syncAccount(state)
+	}
+	return nil
+}
+
+//callbacks run on the transport goroutine, the notices are handled by serve.
+//A notice is dropped when serve is behind so the transport is never blocked
+func (sub *Subscriber) callback(kind string) func(raw json.RawMessage) {
+	return func(raw json.RawMessage) {
+		select {
+		case sub.notices <- notice{kind: kind, raw: raw}:
+		default:
+			sub.client.log().Log(LevelWarn, "notice dropped", F("method", "Run"), F("notice", kind))
+		}
+	}
+}
+
+//send tx to ch, false when Run is stopped before ch has room
+func (sub *Subscriber) send(ch chan *types.Tx, tx *types.Tx) bool {
+	select {
+	case ch <- tx:
+		return true
+	case <-sub.stop:
+		return false
+	}
+}
+
+//handle the notices and check the connection until stop is closed or the check fails
+func (sub *Subscriber) serve(cc rpc.CallCloser, databaseAPIID rpc.APIID, stop <-chan struct{}) error {
+	ticker := time.NewTicker(sub.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case n := <-sub.notices:
+			sub.handle(n)
+		case <-ticker.C:
+			if err := sub.ping(cc, databaseAPIID); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+//a lost websocket connection may never answer, so the check has a timeout
+func (sub *Subscriber) ping(cc rpc.CallCloser, databaseAPIID rpc.APIID) error {
+	done := make(chan error, 1)
+	go func() {
+		_, err := database.NewAPI(databaseAPIID, cc).GetDynamicGlobalProperties()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(sub.Timeout):
+		return errors.New("connection check timed out")
+	}
+}
+
+func (sub *Subscriber) handle(n notice) {
+	var err error
+	switch n.kind {
+	case noticeBlock:
+		err = sub.handleBlock(n.raw)
+	case noticePending:
+		err = sub.handlePending(n.raw)
+	case noticeAccount:
+		sub.handleAccount(n.raw)
+	}
+	if err != nil {
+		sub.client.log().Log(LevelWarn, "failed to handle notice", F("method", "Run"), F("notice", n.kind), F("error", err))
+	}
+}
+
+//the notice holds the id of the applied block, its first 4 bytes are the block number
+func (sub *Subscriber) handleBlock(raw json.RawMessage) error {
+	result := gjson.ParseBytes(raw)
+	if result.IsArray() {
+		result = result.Get("0")
+	}
+	blockId := result.String()
+	if len(blockId) < 8 {
+		return errors.Errorf("invalid block id %s", blockId)
+	}
+	num, err := strconv.ParseUint(blockId[:8], 16, 32)
+	if err != nil {
+		return errors.Wrapf(err, "invalid block id %s", blockId)
+	}
+	block_no := uint32(num)
+	//also scan the blocks missed while disconnected
+	from := block_no
+	if sub.lastBlock > 0 && sub.lastBlock < block_no {
+		from = sub.lastBlock + 1
+	}
+	for ; from <= block_no; from++ {
+		txs, err := sub.client.GetBlockTxs(from)
+		if err != nil {
+			return err
+		}
+		for _, tx := range txs {
+			if !sub.send(sub.Blocks, tx) {
+				return nil
+			}
+		}
+		sub.lastBlock = from
+	}
+	return nil
+}
+
+func (sub *Subscriber) handlePending(raw json.RawMessage) error {
+	result := gjson.ParseBytes(raw)
+	if result.IsArray() {
+		result = result.Get("0")
+	}
+	var trx gxcTypes.Transaction
+	if err := json.Unmarshal([]byte(result.Raw), &trx); err != nil {
+		return errors.Wrap(err, "invalid pending transaction")
+	}
+	if err := resolveOperations(&trx); err != nil {
+		return err
+	}
+	txId, err := transactionId(&trx)
+	if err != nil {
+		return err
+	}
+	txs, err := sub.client.TransactionToTx(&trx, txId, nil, nilNum)
+	if err != nil {
+		return err
+	}
+	setStatus(txs, 0, nil)
+	for _, tx := range txs {
+		if !sub.send(sub.Pending, tx) {
+			return nil
+		}
+	}
+	return nil
+}
+
+//the notice holds the changed objects, a new operation of an account
+//changes its statistics object (2.6.x)
+func (sub *Subscriber) handleAccount(raw json.RawMessage) {
+	changed := map[string]bool{}
+	var walk func(result gjson.Result)
+	walk = func(result gjson.Result) {
+		if result.IsArray() {
+			for _, item := range result.Array() {
+				walk(item)
+			}
+		} else if strings.HasPrefix(result.Get("id").String(), "2.6.") {
+			changed[result.Get("owner").String()] = true
+		}
+	}
+	walk(gjson.ParseBytes(raw))
+	for _, state := range sub.accounts {
+		if changed[state.id] {
+			sub.syncAccount(state)
+		}
+	}
+}
+
+//send the txs of the account newer than the last one sent, paging back through
+//the history until the last one
+func (sub *Subscriber) syncAccount(state *accountState) {
+	if err := sub.sendAccountTxs(state); err != nil {
+		sub.client.log().Log(LevelWarn, "failed to get account txs", F("method", "Run"), F("account", state.name), F("error", err))
+	}
+}
+
+func (sub *Subscriber) sendAccountTxs(state *accountState) error {
+	acc, err := sub.client.Database.GetAccount(state.name)
+	if err != nil {
+		return err
+	}
+	//the history is newest first, the operations after stop are returned
+	stop := "1.11." + strconv.FormatUint(state.last, 10)
+	start := "1.11.0"
+	var ophs []*history.OperationHistory
+	for {
+		page, err := sub.client.History.GetAccountHistory(acc.ID.String(), stop, historyPageSize, start)
+		if err != nil {
+			return err
+		}
+		for _, oph := range page {
+			if objectInstance(oph.ID) > state.last {
+				ophs = append(ophs, oph)
+			}
+		}
+		if len(page) < historyPageSize {
+			break
+		}
+		instance := objectInstance(page[len(page)-1].ID)
+		if instance <= state.last+1 {
+			break
+		}
+		start = "1.11." + strconv.FormatUint(instance-1, 10)
+	}
+	if len(ophs) == 0 {
+		return nil
+	}
+	props, err := sub.client.getProperties()
+	if err != nil {
+		return err
+	}
+	txs, err := sub.client.historyToTxs(acc, ophs, props, newHistoryCache())
+	if err != nil {
+		return err
+	}
+	for i := len(txs) - 1; i >= 0; i-- {
+		if !sub.send(sub.Accounts, txs[i]) {
+			return nil
+		}
+		state.last = objectInstance(txs[i].HistoryID)
+	}
+	//operations the adapter does not decode are not sent again
+	state.last = objectInstance(ophs[0].ID)
+	return nil
+}
+
+//instance of an object id, e.g. 500 for 1.11.500
+func objectInstance(id string) uint64 {
+	instance, _ := strconv.ParseUint(id[strings.LastIndex(id, ".")+1:], 10, 64)
+	return instance
+}
+
+//transaction id, the first 20 bytes of the sha256 of the serialized transaction
+func transactionId(trx *gxcTypes.Transaction) (string, error) {
+	var b bytes.Buffer
+	if err := transaction.NewEncoder(&b).Encode(trx); err != nil {
+		return "", errors.Wrap(err, "failed to encode transaction")
+	}
+	hash := sha256.Sum256(b.Bytes())
+	return hex.EncodeToString(hash[:20]), nil
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/tidwall/gjson"
+	gxcTypes "gxclient-go/types"
+	"math"
+	"strconv"
+)
+
+//rows fetched per get_table_rows_ex call
+const tableRowsPageSize = 100
+
+//ContractTable holds rows of a contract table in primary key order
+type ContractTable struct {
+	Rows []json.RawMessage `json:"rows"`
+	//rows are left after the last one, starting from NextLowerBound
+	More           bool   `json:"more"`
+	NextLowerBound uint64 `json:"next_lower_bound,omitempty"`
+}
+
+//decode the rows into out, a pointer to a slice of maps or structs,
+//the numbers of maps are json.Number so that uint64 keys stay exact
+func (table *ContractTable) Decode(out interface{}) error {
+	raw, err := json.Marshal(table.Rows)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	return decoder.Decode(out)
+}
+
+type tableRowsParams struct {
+	LowerBound    uint64 `json:"lower_bound"`
+	UpperBound    uint64 `json:"upper_bound"`
+	Limit         int    `json:"limit"`
+	IndexPosition int    `json:"index_position"`
+	Reverse       bool   `json:"reverse"`
+}
+
+type tableRowsResult struct {
+	Rows []json.RawMessage `json:"rows"`
+	More bool              `json:"more"`
+}
+
+//read the rows of table of contract with primary keys from lowerBound to upperBound,
+//both included, 0 as upperBound leaves it open. Up to limit rows are fetched page by
+//page, all of them when limit is 0. Rows the node returns serialized are decoded with
+//the ABI of the contract
+func (restClient *RestClient) GetContractTable(contract, table string, lowerBound, upperBound uint64, limit int) (*ContractTable, error) {
+	contractAcc, err := restClient.getContract(contract)
+	if err != nil {
+		return nil, err
+	}
+	abiTable, err := contractAcc.codec.table(table)
+	if err != nil {
+		return nil, err
+	}
+	key, err := contractAcc.codec.primaryKey(abiTable)
+	if err != nil {
+		return nil, err
+	}
+	if upperBound == 0 {
+		upperBound = math.MaxUint64
+	}
+
+	result := &ContractTable{}
+	for limit <= 0 || len(result.Rows) < limit {
+		pageSize := tableRowsPageSize
+		if limit > 0 && limit-len(result.Rows) < pageSize {
+			pageSize = limit - len(result.Rows)
+		}
+		var page tableRowsResult
+		params := &tableRowsParams{LowerBound: lowerBound, UpperBound: upperBound, Limit: pageSize, IndexPosition: 1}
+		if err := restClient.callDatabase("get_table_rows_ex", []interface{}{contractAcc.name, table, params}, &page); err != nil {
+			return nil, errors.Wrapf(err, "failed to get rows of %s.%s", contractAcc.name, table)
+		}
+		for _, row := range page.Rows {
+			decoded, err := contractAcc.codec.decodeRow(abiTable.Type, row)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid row of %s.%s", contractAcc.name, table)
+			}
+			result.Rows = append(result.Rows, decoded)
+		}
+		result.More = page.More
+		if !page.More || len(page.Rows) == 0 {
+			result.More = false
+			break
+		}
+
+		last, err := rowKey(result.Rows[len(result.Rows)-1], key)
+		if err != nil {
+			return nil, err
+		}
+		if last == math.MaxUint64 {
+			result.More = false
+			break
+		}
+		lowerBound = last + 1
+		result.NextLowerBound = lowerBound
+	}
+	if !result.More {
+		result.NextLowerBound = 0
+	}
+	restClient.log().Log(LevelDebug, "contract table read", F("method", "GetContractTable"), F("contract", contractAcc.name),
+		F("table", table), F("rows", len(result.Rows)))
+	return result, nil
+}
+
+//a row as json, decoded with the ABI when the node returns it serialized in hex
+func (codec *abiCodec) decodeRow(typ string, row json.RawMessage) (json.RawMessage, error) {
+	result := gjson.ParseBytes(row)
+	if result.Type != gjson.String {
+		return row, nil
+	}
+	data, err := hex.DecodeString(result.Str)
+	if err != nil {
+		return nil, err
+	}
+	return codec.decodeArgs(typ, data)
+}
+
+//primary key field of the table, the first key name or else the first field of the row
+func (codec *abiCodec) primaryKey(table *gxcTypes.Table) (string, error) {
+	if len(table.KeyNames) > 0 {
+		return table.KeyNames[0], nil
+	}
+	typ, err := codec.resolve(table.Type)
+	if err != nil {
+		return "", err
+	}
+	s, ok := codec.structs[typ]
+	if !ok || len(s.Fields) == 0 {
+		return "", errors.Errorf("no primary key in table %s", table.Name)
+	}
+	return s.Fields[0].Name, nil
+}
+
+//primary key of the row, a number or a name
+func rowKey(row json.RawMessage, key string) (uint64, error) {
+	value := gjson.GetBytes(row, key)
+	if !value.Exists() {
+		return 0, errors.Errorf("no primary key %s in row %s", key, string(row))
+	}
+	if u, err := strconv.ParseUint(numberText(value), 10, 64); err == nil {
+		return u, nil
+	}
+	return stringToName(value.String())
+}
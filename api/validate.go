@@ -0,0 +1,208 @@
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/pkg/errors"
+	"github.com/tidwall/gjson"
+	"gxclient-go/rpc"
+	gxcTypes "gxclient-go/types"
+	"time"
+)
+
+//maximum_time_until_expiration of the chain parameters when the node does not return it
+const defaultMaxTimeUntilExpiration = 24 * time.Hour
+
+//FeeMismatch is an operation whose fee is not the fee the node requires
+type FeeMismatch struct {
+	OpIndex  int    `json:"op_index"`
+	AssetId  string `json:"asset_id"`
+	Fee      uint64 `json:"fee"`
+	Required uint64 `json:"required"`
+}
+
+//TxValidation is what the node thinks of a signed transaction, checked without broadcasting it
+type TxValidation struct {
+	//the node accepts the transaction as it is now
+	Valid bool   `json:"valid"`
+	TxId  string `json:"tx_id"`
+	//public keys of the signatures
+	SignedKeys []string `json:"signed_keys"`
+	//signed keys the authorities of the operations use
+	RequiredKeys []string `json:"required_keys"`
+	//signed keys the authorities do not use, the node rejects their signatures
+	UnnecessaryKeys []string `json:"unnecessary_keys,omitempty"`
+	//keys the node requires of all the keys that can sign, that did not sign
+	MissingKeys []string `json:"missing_keys,omitempty"`
+	Expiration  string   `json:"expiration"`
+	//empty when the transaction expires within the limits of the chain
+	ExpirationError string `json:"expiration_error,omitempty"`
+	//fees below the required fee are rejected, fees above it are paid in full
+	FeeMismatches []*FeeMismatch `json:"fee_mismatches,omitempty"`
+	//rejection of the node validation
+	Error string `json:"error,omitempty"`
+}
+
+//chain id of the network, of the node when the network accepts any
+func (restClient *RestClient) chainId() (string, error) {
+	if chainId := restClient.Network().ChainId; len(chainId) > 0 {
+		return chainId, nil
+	}
+	chainId, err := restClient.Database.GetChainId()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get chain id")
+	}
+	return chainId, nil
+}
+
+//public key of the compact signature in hex of digest
+func recoverKey(digest []byte, signature string) (string, error) {
+	sig, err := hex.DecodeString(signature)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid signature %s", signature)
+	}
+	pub, _, err := btcec.RecoverCompact(btcec.S256(), sig, digest)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid signature %s", signature)
+	}
+	key, err := gxcTypes.NewPublicKey(pub)
+	if err != nil {
+		return "", err
+	}
+	return key.String(), nil
+}
+
+//check the expiration against the head block time and the maximum time until expiration
+func (restClient *RestClient) checkExpiration(trx *gxcTypes.Transaction, result *TxValidation) error {
+	if trx.Expiration.Time == nil {
+		result.ExpirationError = "no expiration"
+		return nil
+	}
+	result.Expiration = trx.Expiration.Format(blockTimeFormat)
+	props, err := restClient.getProperties()
+	if err != nil {
+		return err
+	}
+	var properties json.RawMessage
+	if err := restClient.callDatabase("get_global_properties", []interface{}{}, &properties); err != nil {
+		return errors.Wrap(err, "failed to get global properties")
+	}
+	maxExpiration := defaultMaxTimeUntilExpiration
+	if seconds := gjson.GetBytes(properties, "parameters.maximum_time_until_expiration"); seconds.Exists() {
+		maxExpiration = time.Duration(seconds.Int()) * time.Second
+	}
+
+	head := props.Time.Format(blockTimeFormat)
+	if !trx.Expiration.After(*props.Time.Time) {
+		result.ExpirationError = "expired at " + result.Expiration + ", the head block time is " + head
+	} else if trx.Expiration.After(props.Time.Add(maxExpiration)) {
+		result.ExpirationError = "expires at " + result.Expiration + ", more than " + maxExpiration.String() + " after the head block time " + head
+	}
+	return nil
+}
+
+//compare the fee of each operation with the fee the node requires in its asset
+func (restClient *RestClient) checkFees(trx *gxcTypes.Transaction, result *TxValidation) error {
+	for i, op := range trx.Operations {
+		data, err := json.Marshal(op)
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal operation %d", i)
+		}
+		fee := gjson.GetBytes(data, "fee")
+		assetId := fee.Get("asset_id").String()
+		fees, err := restClient.Database.GetRequiredFee([]gxcTypes.Operation{op}, assetId)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get the fee of operation %d", i)
+		}
+		if len(fees) == 0 {
+			return errors.Errorf("no fee of operation %d", i)
+		}
+		if amount := fee.Get("amount").Uint(); amount != fees[0].Amount {
+			result.FeeMismatches = append(result.FeeMismatches, &FeeMismatch{OpIndex: i, AssetId: assetId, Fee: amount, Required: fees[0].Amount})
+		}
+	}
+	return nil
+}
+
+//check what the node thinks of unsignedTx with signatures, the hex signatures of the
+//signers, without broadcasting it. Rejections of the node are in the result, the
+//error is for the transactions that can not be checked
+func (restClient *RestClient) ValidateTransaction(unsignedTx string, signatures []string) (*TxValidation, error) {
+	stx, err := ParseTransaction(unsignedTx)
+	if err != nil {
+		return nil, err
+	}
+	stx.Signatures = nil
+	txId, err := transactionId(stx.Transaction)
+	if err != nil {
+		return nil, err
+	}
+	chainId, err := restClient.chainId()
+	if err != nil {
+		return nil, err
+	}
+	digest, err := stx.Digest(chainId)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to digest the transaction")
+	}
+
+	result := &TxValidation{TxId: txId, SignedKeys: []string{}, RequiredKeys: []string{}}
+	for _, signature := range signatures {
+		key, err := recoverKey(digest, signature)
+		if err != nil {
+			return nil, err
+		}
+		if contains(result.SignedKeys, key) {
+			return nil, errors.Errorf("key %s signed twice", key)
+		}
+		result.SignedKeys = append(result.SignedKeys, key)
+	}
+	stx.Signatures = append([]string{}, signatures...)
+
+	if err := restClient.checkExpiration(stx.Transaction, result); err != nil {
+		return nil, err
+	}
+	if err := restClient.checkFees(stx.Transaction, result); err != nil {
+		return nil, err
+	}
+	var potential []string
+	if err := restClient.callDatabase("get_potential_signatures", []interface{}{stx.Transaction}, &potential); err != nil {
+		return nil, errors.Wrap(err, "failed to get the potential signatures")
+	}
+	if err := restClient.callDatabase("get_required_signatures", []interface{}{stx.Transaction, result.SignedKeys}, &result.RequiredKeys); err != nil {
+		return nil, errors.Wrap(err, "failed to get the required signatures")
+	}
+	for _, key := range result.SignedKeys {
+		if !contains(result.RequiredKeys, key) {
+			result.UnnecessaryKeys = append(result.UnnecessaryKeys, key)
+		}
+	}
+	var required []string
+	if err := restClient.callDatabase("get_required_signatures", []interface{}{stx.Transaction, potential}, &required); err != nil {
+		return nil, errors.Wrap(err, "failed to get the required signatures")
+	}
+	for _, key := range required {
+		if !contains(result.SignedKeys, key) {
+			result.MissingKeys = append(result.MissingKeys, key)
+		}
+	}
+
+	var processed json.RawMessage
+	if err := restClient.callDatabase("validate_transaction", []interface{}{stx.Transaction}, &processed); err != nil {
+		rejection, ok := err.(*rpc.RPCError)
+		if !ok {
+			return nil, errors.Wrap(err, "failed to validate the transaction")
+		}
+		result.Error = rejection.Message
+	}
+
+	underpaid := false
+	for _, mismatch := range result.FeeMismatches {
+		underpaid = underpaid || mismatch.Fee < mismatch.Required
+	}
+	result.Valid = len(result.Error) == 0 && len(result.ExpirationError) == 0 && len(result.UnnecessaryKeys) == 0 && !underpaid
+	restClient.log().Log(LevelInfo, "transaction validated", F("method", "ValidateTransaction"), F("tx_id", txId),
+		F("valid", result.Valid), F("error", result.Error))
+	return result, nil
+}
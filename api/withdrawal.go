@@ -0,0 +1,298 @@
+package api
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"gxclient-adapter/types"
+	gxcTypes "gxclient-go/types"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+//Withdrawal is the record of a transfer by its idempotency key, the key always
+//maps to the same transaction
+type Withdrawal struct {
+	Key    string `json:"key"`
+	TxId   string `json:"tx_id"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Symbol string `json:"symbol"`
+	Amount uint64 `json:"amount"`
+	//unsigned transaction to sign, the same for every submission of the key
+	UnsignedTx string `json:"unsigned_tx"`
+	Expiration string `json:"expiration"`
+	CreatedAt  string `json:"created_at"`
+	//saved before the transaction is broadcast, it may have reached the node
+	//when the process stopped right after
+	Signature string `json:"signature,omitempty"`
+	Broadcast bool   `json:"broadcast"`
+	//status on chain at the last lookup, empty while it can still be broadcast,
+	//failed once it expired outside a block
+	Status      types.TxStatus `json:"status,omitempty"`
+	BlockNumber int64          `json:"block_no,omitempty"`
+}
+
+//WithdrawalStore keeps the withdrawals by idempotency key, it must survive
+//restarts for the keys to protect against sending twice
+type WithdrawalStore interface {
+	//nil without an error for an unknown key
+	Get(key string) (*Withdrawal, error)
+	Put(withdrawal *Withdrawal) error
+}
+
+//FileWithdrawalStore keeps the withdrawals in a json file, replaced atomically on every change
+type FileWithdrawalStore struct {
+	path        string
+	mu          sync.Mutex
+	withdrawals map[string]*Withdrawal
+}
+
+//store in the file at path, loading the withdrawals it already has
+func NewFileWithdrawalStore(path string) (*FileWithdrawalStore, error) {
+	store := &FileWithdrawalStore{path: path, withdrawals: map[string]*Withdrawal{}}
+	if err := readJSONFile(path, &store.withdrawals); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+func (store *FileWithdrawalStore) Get(key string) (*Withdrawal, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	withdrawal, ok := store.withdrawals[key]
+	if !ok {
+		return nil, nil
+	}
+	copied := *withdrawal
+	return &copied, nil
+}
+
+func (store *FileWithdrawalStore) Put(withdrawal *Withdrawal) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	previous, existed := store.withdrawals[withdrawal.Key]
+	copied := *withdrawal
+	store.withdrawals[withdrawal.Key] = &copied
+	if err := writeJSONFile(store.path, store.withdrawals); err != nil {
+		if existed {
+			store.withdrawals[withdrawal.Key] = previous
+		} else {
+			delete(store.withdrawals, withdrawal.Key)
+		}
+		return err
+	}
+	return nil
+}
+
+//read the json file at path into v, a missing file leaves v unchanged
+func readJSONFile(path string, v interface{}) error {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to read %s", path)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return errors.Wrapf(err, "failed to parse %s", path)
+	}
+	return nil
+}
+
+//replace the file at path with the json of v, synced before the rename so a
+//crash leaves either the old or the new file
+func writeJSONFile(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal %s", path)
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return errors.Wrapf(err, "failed to write %s", path)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.Wrapf(err, "failed to write %s", path)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return errors.Wrapf(err, "failed to sync %s", path)
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrapf(err, "failed to write %s", path)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return errors.Wrapf(err, "failed to replace %s", path)
+	}
+	return nil
+}
+
+//keep the withdrawals of BuildWithdrawal and BroadcastWithdrawal in store
+func (restClient *RestClient) SetWithdrawalStore(store WithdrawalStore) {
+	restClient.withdrawalMutex.Lock()
+	defer restClient.withdrawalMutex.Unlock()
+	restClient.withdrawals = store
+}
+
+//the withdrawal of key, nil for an unknown key, the caller holds withdrawalMutex
+func (restClient *RestClient) getWithdrawal(key string) (*Withdrawal, error) {
+	if len(key) == 0 {
+		return nil, errors.New("idempotency key required")
+	}
+	if restClient.withdrawals == nil {
+		return nil, errors.New("no withdrawal store, see SetWithdrawalStore")
+	}
+	return restClient.withdrawals.Get(key)
+}
+
+//look up the status of the transaction of withdrawal on chain and save it, a
+//transaction not in a block is failed once the last irreversible block passed
+//its expiration. The properties are read before the lookup so a transaction
+//included meanwhile is found
+func (restClient *RestClient) refreshWithdrawal(withdrawal *Withdrawal) error {
+	if withdrawal.Status == types.TxStatusIrreversible {
+		return nil
+	}
+	props, err := restClient.getProperties()
+	if err != nil {
+		return err
+	}
+	txs, err := restClient.GetTransaction(withdrawal.TxId)
+	if err != nil {
+		return errors.Wrapf(err, "failed to look up withdrawal %s", withdrawal.Key)
+	}
+	if len(txs) > 0 {
+		withdrawal.Status = txs[0].Status
+		withdrawal.BlockNumber = txs[0].BlockNumber
+	} else {
+		expiration, err := time.Parse(blockTimeFormat, withdrawal.Expiration)
+		if err != nil {
+			return errors.Wrapf(err, "invalid expiration of withdrawal %s", withdrawal.Key)
+		}
+		irreversible, err := restClient.irreversibleTime(props)
+		if err != nil {
+			return err
+		}
+		withdrawal.BlockNumber = 0
+		switch {
+		case !irreversible.Before(expiration):
+			withdrawal.Status = types.TxStatusFailed
+		case withdrawal.Broadcast:
+			withdrawal.Status = types.TxStatusPending
+		default:
+			withdrawal.Status = ""
+		}
+	}
+	return restClient.withdrawals.Put(withdrawal)
+}
+
+//build the unsigned transfer of the idempotency key once. A key submitted again
+//returns its withdrawal with the status of its transaction on chain instead of
+//building another transfer, a key of another transfer is an error
+func (restClient *RestClient) BuildWithdrawal(key, from, to, symbol string, amount uint64, memo *gxcTypes.Memo) (*Withdrawal, error) {
+	restClient.withdrawalMutex.Lock()
+	defer restClient.withdrawalMutex.Unlock()
+	withdrawal, err := restClient.getWithdrawal(key)
+	if err != nil {
+		return nil, err
+	}
+	if withdrawal != nil {
+		if withdrawal.From != from || withdrawal.To != to || withdrawal.Symbol != symbol || withdrawal.Amount != amount {
+			return nil, errors.Errorf("idempotency key %s is used by another transfer", key)
+		}
+		restClient.log().Log(LevelInfo, "withdrawal resubmitted", F("method", "BuildWithdrawal"), F("withdrawal", key), F("tx_id", withdrawal.TxId))
+		if err := restClient.refreshWithdrawal(withdrawal); err != nil {
+			return nil, err
+		}
+		return withdrawal, nil
+	}
+
+	unsigned, err := restClient.BuildTransaction(from, to, symbol, amount, memo)
+	if err != nil {
+		return nil, err
+	}
+	stx, err := ParseTransaction(unsigned)
+	if err != nil {
+		return nil, err
+	}
+	txId, err := transactionId(stx.Transaction)
+	if err != nil {
+		return nil, err
+	}
+	withdrawal = &Withdrawal{
+		Key:        key,
+		TxId:       txId,
+		From:       from,
+		To:         to,
+		Symbol:     symbol,
+		Amount:     amount,
+		UnsignedTx: unsigned,
+		Expiration: stx.Expiration.Format(blockTimeFormat),
+		CreatedAt:  time.Now().UTC().Format(blockTimeFormat),
+	}
+	if err := restClient.withdrawals.Put(withdrawal); err != nil {
+		return nil, err
+	}
+	restClient.log().Log(LevelInfo, "withdrawal built", F("method", "BuildWithdrawal"), F("withdrawal", key), F("tx_id", txId))
+	return withdrawal, nil
+}
+
+//broadcast the transaction of the idempotency key with signature. The status on
+//chain is looked up first, a transaction in a block or expired is not sent again.
+//The withdrawal is saved as broadcast before it is sent, a failed broadcast is
+//looked up again on the next submission
+func (restClient *RestClient) BroadcastWithdrawal(key, signature string) (*Withdrawal, error) {
+	restClient.withdrawalMutex.Lock()
+	defer restClient.withdrawalMutex.Unlock()
+	withdrawal, err := restClient.getWithdrawal(key)
+	if err != nil {
+		return nil, err
+	}
+	if withdrawal == nil {
+		return nil, errors.Errorf("unknown idempotency key %s", key)
+	}
+	if err := restClient.refreshWithdrawal(withdrawal); err != nil {
+		return nil, err
+	}
+	if withdrawal.BlockNumber > 0 || withdrawal.Status == types.TxStatusFailed {
+		restClient.log().Log(LevelInfo, "withdrawal not sent again", F("method", "BroadcastWithdrawal"), F("withdrawal", key),
+			F("tx_id", withdrawal.TxId), F("status", withdrawal.Status))
+		return withdrawal, nil
+	}
+
+	withdrawal.Signature = signature
+	withdrawal.Broadcast = true
+	withdrawal.Status = types.TxStatusPending
+	if err := restClient.withdrawals.Put(withdrawal); err != nil {
+		return nil, err
+	}
+	if _, err := restClient.SignTransaction(withdrawal.UnsignedTx, signature); err != nil {
+		return nil, err
+	}
+	//the status of the reply expires by the head block, the lookup by the last irreversible block
+	if err := restClient.refreshWithdrawal(withdrawal); err != nil {
+		return nil, err
+	}
+	return withdrawal, nil
+}
+
+//the withdrawal of the idempotency key with the status of its transaction on chain
+func (restClient *RestClient) WithdrawalStatus(key string) (*Withdrawal, error) {
+	restClient.withdrawalMutex.Lock()
+	defer restClient.withdrawalMutex.Unlock()
+	withdrawal, err := restClient.getWithdrawal(key)
+	if err != nil {
+		return nil, err
+	}
+	if withdrawal == nil {
+		return nil, errors.Errorf("unknown idempotency key %s", key)
+	}
+	if err := restClient.refreshWithdrawal(withdrawal); err != nil {
+		return nil, err
+	}
+	return withdrawal, nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"gxclient-adapter/api"
+	"gxclient-adapter/jsonrpc"
+	"gxclient-adapter/metrics"
+	"gxclient-adapter/rest"
+	"log"
+	"net/http"
+	"os"
+)
+
+func main() {
+	node := flag.String("node", env("GXADAPTER_NODE", "wss://node1.gxb.io"), "gxchain node url, http(s) or ws(s)")
+	listen := flag.String("listen", env("GXADAPTER_LISTEN", "127.0.0.1:8080"), "http listen address")
+	logLevel := flag.String("log-level", env("GXADAPTER_LOG_LEVEL", "info"), "debug, info, warn, error or off")
+	networkName := flag.String("network", env("GXADAPTER_NETWORK", ""), "mainnet or testnet, the chain id of the node must match, detected when empty")
+	chainId := flag.String("chain-id", env("GXADAPTER_CHAIN_ID", ""), "chain id of a custom network, instead of -network")
+	prefix := flag.String("prefix", env("GXADAPTER_PREFIX", ""), "public key prefix of the custom network, GXC when empty")
+	coreAsset := flag.String("core-asset", env("GXADAPTER_CORE_ASSET", ""), "core asset symbol of the custom network, GXC when empty")
+	coreAssetId := flag.String("core-asset-id", env("GXADAPTER_CORE_ASSET_ID", ""), "core asset id of the custom network, 1.3.1 when empty")
+	withdrawals := flag.String("withdrawals", env("GXADAPTER_WITHDRAWALS", ""), "json file of the idempotent withdrawals, disabled when empty")
+	flag.Parse()
+
+	network, err := api.SelectNetwork(*networkName, *chainId, *prefix, *coreAsset, *coreAssetId)
+	if err != nil {
+		log.Fatal(err)
+	}
+	restClient, err := api.NewRestClientWithNetwork(*node, network)
+	if err != nil {
+		log.Fatalf("failed to connect %s: %v", *node, err)
+	}
+	if *logLevel != "off" {
+		level, ok := levels[*logLevel]
+		if !ok {
+			log.Fatalf("unknown log level %s", *logLevel)
+		}
+		restClient.SetLogger(api.NewTextLogger(os.Stderr, level))
+	}
+	if len(*withdrawals) > 0 {
+		store, err := api.NewFileWithdrawalStore(*withdrawals)
+		if err != nil {
+			log.Fatal(err)
+		}
+		restClient.SetWithdrawalStore(store)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/", rest.NewHandler(restClient))
+	mux.Handle("/rpc", jsonrpc.NewHandler(restClient))
+	mux.Handle("/metrics", metrics.Handler())
+
+	log.Printf("gxadapter-server listening on %s, node %s, network %s", *listen, *node, restClient.Network().Name)
+	log.Fatal(http.ListenAndServe(*listen, mux))
+}
+
+var levels = map[string]api.Level{
+	"debug": api.LevelDebug,
+	"info":  api.LevelInfo,
+	"warn":  api.LevelWarn,
+	"error": api.LevelError,
+}
+
+//value of the environment variable key, or def if not set
+func env(key, def string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return def
+}
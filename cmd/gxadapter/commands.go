@@ -0,0 +1,448 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"gxclient-adapter/api"
+	"gxclient-adapter/types"
+	gxcTypes "gxclient-go/types"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
+
+var jsonOutput bool
+
+//print v as json, or text when not in json mode
+func output(v interface{}, text func()) error {
+	if jsonOutput {
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+		return nil
+	}
+	text()
+	return nil
+}
+
+func formatAmount(value uint64, precision uint8) string {
+	return decimal.New(int64(value), -int32(precision)).StringFixed(int32(precision))
+}
+
+//signed amount, e.g. +1.50000 or -0.01000
+func formatNetAmount(value int64, precision uint8) string {
+	amount := decimal.New(value, -int32(precision)).StringFixed(int32(precision))
+	if value > 0 {
+		return "+" + amount
+	}
+	return amount
+}
+
+func nodeFlag(fs *flag.FlagSet) *string {
+	return fs.String("node", env("GXADAPTER_NODE", "wss://node1.gxb.io"), "gxchain node url")
+}
+
+//-network, or the parameters of a custom network with -chain-id
+type networkFlags struct {
+	name, chainId, prefix, coreAsset, coreAssetId *string
+}
+
+func networkFlag(fs *flag.FlagSet) *networkFlags {
+	return &networkFlags{
+		name:        fs.String("network", env("GXADAPTER_NETWORK", ""), "mainnet or testnet, checked against the chain id of the node"),
+		chainId:     fs.String("chain-id", env("GXADAPTER_CHAIN_ID", ""), "chain id of a custom network, instead of -network"),
+		prefix:      fs.String("prefix", env("GXADAPTER_PREFIX", ""), "public key prefix of the custom network, GXC when empty"),
+		coreAsset:   fs.String("core-asset", env("GXADAPTER_CORE_ASSET", ""), "core asset symbol of the custom network, GXC when empty"),
+		coreAssetId: fs.String("core-asset-id", env("GXADAPTER_CORE_ASSET_ID", ""), "core asset id of the custom network, 1.3.1 when empty"),
+	}
+}
+
+//nil when neither -network nor -chain-id is set
+func (flags *networkFlags) config() (*api.NetworkConfig, error) {
+	return api.SelectNetwork(*flags.name, *flags.chainId, *flags.prefix, *flags.coreAsset, *flags.coreAssetId)
+}
+
+//connect the node, checking its chain id when the network is set
+func connect(node string, network *networkFlags) (*api.RestClient, error) {
+	config, err := network.config()
+	if err != nil {
+		return nil, err
+	}
+	return api.NewRestClientWithNetwork(node, config)
+}
+
+//parse the flags and check the number of positional args
+func parse(fs *flag.FlagSet, args []string, min, max int) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < min || fs.NArg() > max {
+		return errors.Errorf("expect %d to %d arguments, got %d", min, max, fs.NArg())
+	}
+	return nil
+}
+
+func runKey(args []string) error {
+	fs := flag.NewFlagSet("key", flag.ExitOnError)
+	prefix := fs.String("prefix", api.MainNet.Prefix, "public key prefix")
+	if err := parse(fs, args, 2, 2); err != nil {
+		return err
+	}
+	network := &api.NetworkConfig{Prefix: *prefix}
+	var convert func(string) (string, error)
+	switch fs.Arg(0) {
+	case "pri-hex-to-wif":
+		convert = api.PriKeyHexToWif
+	case "pri-wif-to-hex":
+		convert = api.PriKeyWifToHex
+	case "pub-hex-to-base58":
+		convert = network.PubKeyHexToBase58
+	case "pub-base58-to-hex":
+		convert = network.PubKeyBase58ToHex
+	default:
+		return errors.Errorf("unknown conversion %s", fs.Arg(0))
+	}
+	key, err := convert(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	return output(map[string]string{"key": key}, func() { fmt.Println(key) })
+}
+
+func runBalance(args []string) error {
+	fs := flag.NewFlagSet("balance", flag.ExitOnError)
+	node, network := nodeFlag(fs), networkFlag(fs)
+	if err := parse(fs, args, 1, 2); err != nil {
+		return err
+	}
+	restClient, err := connect(*node, network)
+	if err != nil {
+		return err
+	}
+	var assets []*types.Asset
+	if fs.NArg() == 2 {
+		assets, err = restClient.BalanceForAddress(fs.Arg(0), fs.Arg(1))
+	} else {
+		assets, err = restClient.BalancesForAddress(fs.Arg(0))
+	}
+	if err != nil {
+		return err
+	}
+	return output(assets, func() {
+		for _, asset := range assets {
+			fmt.Printf("%s %s (%s)\n", formatAmount(asset.Balance, asset.TokenDecimal), asset.TokenCode, asset.TokenIdentifier)
+		}
+	})
+}
+
+func runHistory(args []string) error {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	node, network := nodeFlag(fs), networkFlag(fs)
+	since := fs.String("since", "", "id of the most recent operation history to list")
+	limit := fs.Int("limit", 20, "max number of operations, at most 100")
+	if err := parse(fs, args, 1, 1); err != nil {
+		return err
+	}
+	restClient, err := connect(*node, network)
+	if err != nil {
+		return err
+	}
+	if key := os.Getenv("GXADAPTER_MEMO_KEY"); len(key) > 0 {
+		if err := restClient.SetMemoKeys(key); err != nil {
+			return err
+		}
+	}
+	txs, err := restClient.TxsForAddress(fs.Arg(0), *since, *limit)
+	if err != nil {
+		return err
+	}
+	return output(txs, func() { printTxs(txs) })
+}
+
+//the operation of tx in one line, the op type and its typed payload for operations other than transfers
+func describeTx(tx *types.Tx) string {
+	var parts []string
+	if len(tx.OpType) > 0 {
+		parts = append(parts, tx.OpType)
+	}
+	if len(tx.Inputs) > 0 && len(tx.Outputs) > 0 && (len(tx.OpType) == 0 || tx.Inputs[0].Value > 0) {
+		in, out := tx.Inputs[0], tx.Outputs[0]
+		parts = append(parts, fmt.Sprintf("%s -> %s %s %s", in.Address, out.Address, formatAmount(in.Value, in.TokenDecimal), in.TokenCode))
+	}
+	var payload interface{}
+	switch {
+	case tx.Contract != nil:
+		payload = tx.Contract
+	case tx.Staking != nil:
+		payload = tx.Staking
+	case tx.AssetChange != nil:
+		payload = tx.AssetChange
+	case tx.AccountUpdate != nil:
+		payload = tx.AccountUpdate
+	case tx.Proposal != nil:
+		payload = tx.Proposal
+	}
+	if payload != nil {
+		data, _ := json.Marshal(payload)
+		parts = append(parts, string(data))
+	}
+	return strings.Join(parts, " ")
+}
+
+func printTxs(txs []*types.Tx) {
+	for _, tx := range txs {
+		fmt.Printf("%-10s %s", tx.Extra["id"], describeTx(tx))
+		if len(tx.Direction) > 0 {
+			fmt.Printf(" %s", tx.Direction)
+			for _, net := range tx.NetAmounts {
+				fmt.Printf(" %s %s", formatNetAmount(net.Value, net.TokenDecimal), net.TokenCode)
+			}
+		}
+		if memo, ok := tx.Extra["memo"]; ok {
+			fmt.Printf(" memo:%q", memo)
+		}
+		fmt.Println()
+	}
+}
+
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	node, network := nodeFlag(fs), networkFlag(fs)
+	from := fs.String("from", "", "first day, YYYY-MM-DD in UTC")
+	to := fs.String("to", "", "day after the last one, YYYY-MM-DD in UTC")
+	format := fs.String("format", api.FormatCSV, "csv or jsonl")
+	out := fs.String("o", "", "write to file instead of stdout")
+	if err := parse(fs, args, 1, 1); err != nil {
+		return err
+	}
+	var fromTime, toTime time.Time
+	var err error
+	if len(*from) > 0 {
+		if fromTime, err = time.Parse("2006-01-02", *from); err != nil {
+			return errors.Wrap(err, "invalid -from")
+		}
+	}
+	if len(*to) > 0 {
+		if toTime, err = time.Parse("2006-01-02", *to); err != nil {
+			return errors.Wrap(err, "invalid -to")
+		}
+	}
+	restClient, err := connect(*node, network)
+	if err != nil {
+		return err
+	}
+	if key := os.Getenv("GXADAPTER_MEMO_KEY"); len(key) > 0 {
+		if err := restClient.SetMemoKeys(key); err != nil {
+			return err
+		}
+	}
+	w := os.Stdout
+	if len(*out) > 0 {
+		if w, err = os.Create(*out); err != nil {
+			return err
+		}
+		defer w.Close()
+	}
+	count, err := restClient.ExportHistory(w, fs.Arg(0), fromTime, toTime, *format)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%d records exported\n", count)
+	return nil
+}
+
+func runBuild(args []string) error {
+	fs := flag.NewFlagSet("build", flag.ExitOnError)
+	node, network := nodeFlag(fs), networkFlag(fs)
+	from := fs.String("from", "", "sender account name")
+	to := fs.String("to", "", "receiver account name")
+	symbol := fs.String("symbol", "", "asset symbol, the core asset of the network when empty")
+	amount := fs.String("amount", "", "decimal amount, e.g. 1.5")
+	memo := fs.String("memo", "", "memo text, encrypted with GXADAPTER_MEMO_KEY")
+	out := fs.String("o", "", "write the unsigned transaction to file instead of stdout")
+	if err := parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	if len(*from) == 0 || len(*to) == 0 || len(*amount) == 0 {
+		return errors.New("-from, -to and -amount are required")
+	}
+	realAmount, err := decimal.NewFromString(*amount)
+	if err != nil {
+		return errors.Wrap(err, "invalid amount")
+	}
+
+	restClient, err := connect(*node, network)
+	if err != nil {
+		return err
+	}
+	if len(*symbol) == 0 {
+		*symbol = restClient.Network().CoreAsset
+	}
+	token, err := restClient.TokenDetail(*symbol)
+	if err != nil {
+		return err
+	}
+	amountInt := realAmount.Shift(int32(token.TokenDecimal))
+	if !amountInt.Equal(amountInt.Truncate(0)) || amountInt.Sign() <= 0 {
+		return errors.Errorf("invalid amount %s for precision %d", *amount, token.TokenDecimal)
+	}
+
+	var memoOb *gxcTypes.Memo
+	if len(*memo) > 0 {
+		memoKey := os.Getenv("GXADAPTER_MEMO_KEY")
+		if len(memoKey) == 0 {
+			return errors.New("GXADAPTER_MEMO_KEY is required to encrypt the memo")
+		}
+		fromAccount, err := restClient.Database.GetAccount(*from)
+		if err != nil {
+			return err
+		}
+		toAccount, err := restClient.Database.GetAccount(*to)
+		if err != nil {
+			return err
+		}
+		if toAccount.Options.MemoKey.IsNul() {
+			return errors.Errorf("account %s has no memo key", *to)
+		}
+		memoOb, err = api.EncryptMemo(memoKey, *memo, &fromAccount.Options.MemoKey, &toAccount.Options.MemoKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	unsignedTx, err := restClient.BuildTransaction(*from, *to, *symbol, uint64(amountInt.IntPart()), memoOb)
+	if err != nil {
+		return err
+	}
+	return writeOut(*out, unsignedTx)
+}
+
+func runSign(args []string) error {
+	fs := flag.NewFlagSet("sign", flag.ExitOnError)
+	network := networkFlag(fs)
+	key := fs.String("key", "", "active private key in hex, GXADAPTER_KEY when empty")
+	socket := fs.String("signer", "", "unix socket of a signing daemon, used instead of -key")
+	pub := fs.String("pub", "", "public key to sign with through -signer")
+	out := fs.String("o", "", "write the signed transaction to file instead of stdout")
+	if err := parse(fs, args, 1, 1); err != nil {
+		return err
+	}
+	//not the flag default, the usage would print it
+	if len(*key) == 0 {
+		*key = os.Getenv("GXADAPTER_KEY")
+	}
+	config, err := network.config()
+	if err != nil {
+		return err
+	}
+	if config == nil {
+		return errors.New("-chain-id or -network is required")
+	}
+	unsignedTx, err := readIn(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	var signature string
+	if len(*socket) > 0 {
+		if len(*pub) == 0 {
+			return errors.New("-pub is required with -signer")
+		}
+		signature, err = api.SignWith(api.NewSocketSigner(*socket), *pub, config.ChainId, unsignedTx)
+	} else {
+		if len(*key) == 0 {
+			return errors.New("-key or GXADAPTER_KEY is required")
+		}
+		signature, err = api.Sign(*key, config.ChainId, unsignedTx)
+	}
+	if err != nil {
+		return err
+	}
+
+	stx, err := api.ParseTransaction(unsignedTx)
+	if err != nil {
+		return err
+	}
+	stx.Signatures = append(stx.Signatures, signature)
+	signedTx, err := json.Marshal(stx)
+	if err != nil {
+		return err
+	}
+	return writeOut(*out, string(signedTx))
+}
+
+func runBroadcast(args []string) error {
+	fs := flag.NewFlagSet("broadcast", flag.ExitOnError)
+	node, network := nodeFlag(fs), networkFlag(fs)
+	if err := parse(fs, args, 1, 1); err != nil {
+		return err
+	}
+	signedTx, err := readIn(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	restClient, err := connect(*node, network)
+	if err != nil {
+		return err
+	}
+	tx, err := restClient.BroadcastTransaction(signedTx)
+	if err != nil {
+		return err
+	}
+	return output(tx, func() {
+		fmt.Printf("broadcast %s in block %d\n", tx.TxHash, tx.BlockNumber)
+	})
+}
+
+func runDecode(args []string) error {
+	fs := flag.NewFlagSet("decode", flag.ExitOnError)
+	if err := parse(fs, args, 1, 1); err != nil {
+		return err
+	}
+	raw := fs.Arg(0)
+	if !strings.HasPrefix(strings.TrimSpace(raw), "{") {
+		var err error
+		if raw, err = readIn(raw); err != nil {
+			return err
+		}
+	}
+	txs, err := api.Deserialize(raw)
+	if err != nil {
+		return err
+	}
+	return output(txs, func() {
+		for _, tx := range txs {
+			fmt.Printf("%s fee %s %s\n", describeTx(tx), tx.Extra["feeAmount"], tx.Extra["feeTokenIdentifier"])
+		}
+	})
+}
+
+//read a transaction file, "-" for stdin
+func readIn(file string) (string, error) {
+	var b []byte
+	var err error
+	if file == "-" {
+		b, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		b, err = ioutil.ReadFile(file)
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+//write a transaction to file, stdout if file is empty
+func writeOut(file, tx string) error {
+	if len(file) == 0 {
+		fmt.Println(tx)
+		return nil
+	}
+	return ioutil.WriteFile(file, []byte(tx+"\n"), 0600)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+)
+
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var commands = map[string]command{
+	"key":       {"key [-prefix GXC] <pri-hex-to-wif|pri-wif-to-hex|pub-hex-to-base58|pub-base58-to-hex> <key>", runKey},
+	"balance":   {"balance [-node url] [-network name | -chain-id id] <address> [symbol]", runBalance},
+	"history":   {"history [-node url] [-network name | -chain-id id] [-since id] [-limit n] <address>", runHistory},
+	"export":    {"export [-node url] [-network name | -chain-id id] [-from YYYY-MM-DD] [-to YYYY-MM-DD] [-format csv|jsonl] [-o file] <address>", runExport},
+	"build":     {"build [-node url] [-network name | -chain-id id] -from a -to b -amount 1.5 [-symbol GXC] [-memo text] [-o file]", runBuild},
+	"sign":      {"sign <-network name | -chain-id id> [-key hex | -signer socket -pub key] [-o file] <file>", runSign},
+	"broadcast": {"broadcast [-node url] [-network name | -chain-id id] <file>", runBroadcast},
+	"decode":    {"decode <file|raw tx json>", runDecode},
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: gxadapter [-json] <command> [args]")
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %s\n", commands[name].usage)
+	}
+	fmt.Fprintln(os.Stderr, "private keys can also be given by GXADAPTER_KEY and GXADAPTER_MEMO_KEY, the node by GXADAPTER_NODE and the network by GXADAPTER_NETWORK or GXADAPTER_CHAIN_ID")
+}
+
+func main() {
+	flag.BoolVar(&jsonOutput, "json", false, "print json instead of text")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[flag.Arg(0)]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+	if err := cmd.run(flag.Args()[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "gxadapter %s: %v\n", flag.Arg(0), err)
+		os.Exit(1)
+	}
+}
+
+//value of the environment variable key, or def if not set
+func env(key, def string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return def
+}
@@ -0,0 +1,233 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"gxclient-adapter/api"
+	"io/ioutil"
+	"net/http"
+)
+
+//error codes of JSON-RPC 2.0
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+	//the node or the adapter failed to handle the call
+	CodeServerError = -32000
+)
+
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	ID      json.RawMessage `json:"id,omitempty"`
+}
+
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func newError(code int, err error) *Error {
+	return &Error{Code: code, Message: err.Error()}
+}
+
+//a method decodes its params into a struct, positional params are
+//assigned to the json names in order
+type method struct {
+	params []string
+	call   func(params json.RawMessage) (interface{}, error)
+}
+
+//Handler serves the adapter methods by JSON-RPC 2.0 over http POST, batches included
+type Handler struct {
+	client  *api.RestClient
+	methods map[string]method
+}
+
+func NewHandler(restClient *api.RestClient) *Handler {
+	h := &Handler{client: restClient}
+	h.methods = map[string]method{
+		"getBalance":            {[]string{"address", "symbol"}, h.getBalance},
+		"getTransactions":       {[]string{"address", "since_tx_id", "limit"}, h.getTransactions},
+		"getTransaction":        {[]string{"tx_hash"}, h.getTransaction},
+		"getBlockCount":         {nil, h.getBlockCount},
+		"getBlockTransactions":  {[]string{"block_no"}, h.getBlockTransactions},
+		"getTokenDetail":        {[]string{"token"}, h.getTokenDetail},
+		"checkAddress":          {[]string{"address"}, h.checkAddress},
+		"getContractTable":      {[]string{"contract", "table", "lower_bound", "upper_bound", "limit"}, h.getContractTable},
+		"getStakings":           {[]string{"address"}, h.getStakings},
+		"getProposals":          {[]string{"address"}, h.getProposals},
+		"buildTransaction":      {[]string{"from", "to", "symbol", "amount", "memo"}, h.buildTransaction},
+		"buildContractCall":     {[]string{"from", "contract", "method", "args", "amount"}, h.buildContractCall},
+		"buildStaking":          {[]string{"action", "account", "trust_node", "program_id", "id", "amount", "memo"}, h.buildStaking},
+		"buildAccountUpdate":    {[]string{"account", "owner", "active", "memo_key"}, h.buildAccountUpdate},
+		"buildProposal":         {[]string{"account", "tx", "expires_in", "review_period"}, h.buildProposal},
+		"buildProposalApproval": {[]string{"account", "proposal", "keys", "remove"}, h.buildProposalApproval},
+		"buildAsset":            {[]string{"action", "account", "symbol", "precision", "to", "new_issuer", "amount", "memo", "options"}, h.buildAsset},
+		"getTransactionFee":     {[]string{"tx"}, h.getTransactionFee},
+		"validateTransaction":   {[]string{"tx", "signatures"}, h.validateTransaction},
+		"broadcast":             {[]string{"tx", "signature"}, h.broadcast},
+		"buildWithdrawal":       {[]string{"key", "from", "to", "symbol", "amount", "memo"}, h.buildWithdrawal},
+		"broadcastWithdrawal":   {[]string{"key", "signature"}, h.broadcastWithdrawal},
+		"getWithdrawal":         {[]string{"key"}, h.getWithdrawal},
+		"decodeTransaction":     {[]string{"tx"}, h.decodeTransaction},
+	}
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if result := h.Handle(body); result != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(result)
+	} else {
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+//handle a single or batch request body, nil when there is nothing to respond
+func (h *Handler) Handle(body []byte) []byte {
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(body, &batch); err != nil {
+			return marshal(errorResponse(nil, newError(CodeParseError, err)))
+		}
+		if len(batch) == 0 {
+			return marshal(errorResponse(nil, &Error{CodeInvalidRequest, "empty batch"}))
+		}
+		var responses []*Response
+		for _, raw := range batch {
+			if resp := h.handleOne(raw); resp != nil {
+				responses = append(responses, resp)
+			}
+		}
+		if len(responses) == 0 {
+			return nil
+		}
+		return marshal(responses)
+	}
+	if resp := h.handleOne(body); resp != nil {
+		return marshal(resp)
+	}
+	return nil
+}
+
+func marshal(v interface{}) []byte {
+	b, _ := json.Marshal(v)
+	return b
+}
+
+func errorResponse(id json.RawMessage, err *Error) *Response {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return &Response{JSONRPC: "2.0", Error: err, ID: id}
+}
+
+//handle one request, nil for notifications. A panic of the method is an
+//internal error of the request, the other requests of a batch are still handled
+func (h *Handler) handleOne(raw json.RawMessage) (resp *Response) {
+	var req Request
+	defer func() {
+		if r := recover(); r != nil {
+			resp = nil
+			if req.ID != nil {
+				resp = errorResponse(req.ID, &Error{CodeInternalError, fmt.Sprintf("internal error: %v", r)})
+			}
+		}
+	}()
+	if err := json.Unmarshal(raw, &req); err != nil {
+		if _, ok := err.(*json.SyntaxError); ok {
+			return errorResponse(nil, newError(CodeParseError, err))
+		}
+		return errorResponse(nil, newError(CodeInvalidRequest, err))
+	}
+	if req.JSONRPC != "2.0" || len(req.Method) == 0 {
+		return errorResponse(req.ID, &Error{CodeInvalidRequest, "invalid request"})
+	}
+
+	result, err := h.call(req)
+	if req.ID == nil {
+		return nil
+	}
+	if err != nil {
+		rpcErr, ok := err.(*Error)
+		if !ok {
+			rpcErr = newError(CodeServerError, err)
+		}
+		return errorResponse(req.ID, rpcErr)
+	}
+	b, err := json.Marshal(result)
+	if err != nil {
+		return errorResponse(req.ID, newError(CodeInternalError, err))
+	}
+	return &Response{JSONRPC: "2.0", Result: b, ID: req.ID}
+}
+
+func (h *Handler) call(req Request) (interface{}, error) {
+	m, ok := h.methods[req.Method]
+	if !ok {
+		return nil, &Error{CodeMethodNotFound, "method not found: " + req.Method}
+	}
+	params, err := namedParams(req.Params, m.params)
+	if err != nil {
+		return nil, newError(CodeInvalidParams, err)
+	}
+	return m.call(params)
+}
+
+//convert positional params into named ones
+func namedParams(params json.RawMessage, names []string) (json.RawMessage, error) {
+	params = bytes.TrimSpace(params)
+	if len(params) == 0 {
+		return json.RawMessage("{}"), nil
+	}
+	if params[0] != '[' {
+		return params, nil
+	}
+	var values []json.RawMessage
+	if err := json.Unmarshal(params, &values); err != nil {
+		return nil, err
+	}
+	if len(values) > len(names) {
+		return nil, errors.Errorf("expect at most %d params, got %d", len(names), len(values))
+	}
+	named := map[string]json.RawMessage{}
+	for i, value := range values {
+		named[names[i]] = value
+	}
+	return json.Marshal(named)
+}
+
+func decodeParams(params json.RawMessage, v interface{}) error {
+	if err := json.Unmarshal(params, v); err != nil {
+		return newError(CodeInvalidParams, err)
+	}
+	return nil
+}
@@ -0,0 +1,408 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"gxclient-adapter/api"
+	"gxclient-adapter/types"
+	gxcTypes "gxclient-go/types"
+	"time"
+)
+
+type balanceParams struct {
+	Address string `json:"address"`
+	Symbol  string `json:"symbol"`
+}
+
+//all balances when symbol is empty
+func (h *Handler) getBalance(params json.RawMessage) (interface{}, error) {
+	var p balanceParams
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	if len(p.Address) == 0 {
+		return nil, &Error{CodeInvalidParams, "address required"}
+	}
+	if len(p.Symbol) == 0 {
+		return h.client.BalancesForAddress(p.Address)
+	}
+	return h.client.BalanceForAddress(p.Address, p.Symbol)
+}
+
+type transactionsParams struct {
+	Address   string `json:"address"`
+	SinceTxId string `json:"since_tx_id"`
+	Limit     int    `json:"limit"`
+}
+
+func (h *Handler) getTransactions(params json.RawMessage) (interface{}, error) {
+	p := transactionsParams{Limit: 10}
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	if len(p.Address) == 0 {
+		return nil, &Error{CodeInvalidParams, "address required"}
+	}
+	return h.client.TxsForAddress(p.Address, p.SinceTxId, p.Limit)
+}
+
+type transactionParams struct {
+	TxHash string `json:"tx_hash"`
+}
+
+func (h *Handler) getTransaction(params json.RawMessage) (interface{}, error) {
+	var p transactionParams
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	return h.client.GetTransaction(p.TxHash)
+}
+
+func (h *Handler) getBlockCount(params json.RawMessage) (interface{}, error) {
+	return h.client.GetBlockCount()
+}
+
+type blockParams struct {
+	BlockNo uint32 `json:"block_no"`
+}
+
+func (h *Handler) getBlockTransactions(params json.RawMessage) (interface{}, error) {
+	var p blockParams
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	return h.client.GetBlockTxs(p.BlockNo)
+}
+
+type tokenParams struct {
+	Token string `json:"token"`
+}
+
+func (h *Handler) getTokenDetail(params json.RawMessage) (interface{}, error) {
+	var p tokenParams
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	return h.client.TokenDetail(p.Token)
+}
+
+type addressParams struct {
+	Address string `json:"address"`
+}
+
+func (h *Handler) checkAddress(params json.RawMessage) (interface{}, error) {
+	var p addressParams
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	if err := api.ValidateAddress(p.Address); err != nil {
+		return nil, &Error{CodeInvalidParams, err.Error()}
+	}
+	return h.client.CheckAddress(p.Address)
+}
+
+type tableParams struct {
+	Contract   string `json:"contract"`
+	Table      string `json:"table"`
+	LowerBound uint64 `json:"lower_bound"`
+	UpperBound uint64 `json:"upper_bound"`
+	Limit      int    `json:"limit"`
+}
+
+func (h *Handler) getContractTable(params json.RawMessage) (interface{}, error) {
+	var p tableParams
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	if len(p.Contract) == 0 || len(p.Table) == 0 {
+		return nil, &Error{CodeInvalidParams, "contract and table required"}
+	}
+	return h.client.GetContractTable(p.Contract, p.Table, p.LowerBound, p.UpperBound, p.Limit)
+}
+
+type buildParams struct {
+	From   string         `json:"from"`
+	To     string         `json:"to"`
+	Symbol string         `json:"symbol"`
+	Amount uint64         `json:"amount"`
+	Memo   *gxcTypes.Memo `json:"memo"`
+}
+
+func (h *Handler) buildTransaction(params json.RawMessage) (interface{}, error) {
+	var p buildParams
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	if len(p.From) == 0 || len(p.To) == 0 || p.Amount == 0 {
+		return nil, &Error{CodeInvalidParams, "from, to and amount required"}
+	}
+	return h.client.BuildTransaction(p.From, p.To, p.Symbol, p.Amount, p.Memo)
+}
+
+type contractCallParams struct {
+	From     string          `json:"from"`
+	Contract string          `json:"contract"`
+	Method   string          `json:"method"`
+	Args     json.RawMessage `json:"args"`
+	//decimal amount and asset, e.g. "1.5 GXC"
+	Amount string `json:"amount"`
+}
+
+func (h *Handler) buildContractCall(params json.RawMessage) (interface{}, error) {
+	var p contractCallParams
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	if len(p.From) == 0 || len(p.Contract) == 0 || len(p.Method) == 0 {
+		return nil, &Error{CodeInvalidParams, "from, contract and method required"}
+	}
+	return h.client.BuildContractCall(p.From, p.Contract, p.Method, p.Args, p.Amount)
+}
+
+func (h *Handler) getStakings(params json.RawMessage) (interface{}, error) {
+	var p addressParams
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	if len(p.Address) == 0 {
+		return nil, &Error{CodeInvalidParams, "address required"}
+	}
+	return h.client.StakingsForAddress(p.Address)
+}
+
+type stakingParams struct {
+	//create, update, claim, lock or unlock
+	Action    string `json:"action"`
+	Account   string `json:"account"`
+	TrustNode string `json:"trust_node"`
+	ProgramId string `json:"program_id"`
+	//staking id of update and claim, lock id of unlock
+	Id string `json:"id"`
+	//decimal amount of GXC, e.g. "100 GXC"
+	Amount string `json:"amount"`
+	Memo   string `json:"memo"`
+}
+
+func (h *Handler) buildStaking(params json.RawMessage) (interface{}, error) {
+	var p stakingParams
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	if len(p.Account) == 0 {
+		return nil, &Error{CodeInvalidParams, "account required"}
+	}
+	switch p.Action {
+	case "create":
+		return h.client.BuildStakingCreate(p.Account, p.TrustNode, p.ProgramId, p.Amount)
+	case "update":
+		return h.client.BuildStakingUpdate(p.Account, p.Id, p.TrustNode)
+	case "claim":
+		return h.client.BuildStakingClaim(p.Account, p.Id)
+	case "lock":
+		return h.client.BuildBalanceLock(p.Account, p.ProgramId, p.Amount, p.Memo)
+	case "unlock":
+		return h.client.BuildBalanceUnlock(p.Account, p.Id)
+	}
+	return nil, &Error{CodeInvalidParams, "unknown staking action " + p.Action}
+}
+
+type assetParams struct {
+	//create, update, issue, reserve or fund_fee_pool
+	Action    string `json:"action"`
+	Account   string `json:"account"`
+	Symbol    string `json:"symbol"`
+	Precision uint8  `json:"precision"`
+	//receiver of issue
+	To        string `json:"to"`
+	NewIssuer string `json:"new_issuer"`
+	//decimal amount and asset, e.g. "100 LOY"
+	Amount  string           `json:"amount"`
+	Memo    *gxcTypes.Memo   `json:"memo"`
+	Options *api.AssetParams `json:"options"`
+}
+
+func (h *Handler) buildAsset(params json.RawMessage) (interface{}, error) {
+	var p assetParams
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	if len(p.Account) == 0 {
+		return nil, &Error{CodeInvalidParams, "account required"}
+	}
+	switch p.Action {
+	case "create":
+		return h.client.BuildAssetCreate(p.Account, p.Symbol, p.Precision, p.Options)
+	case "update":
+		return h.client.BuildAssetUpdate(p.Account, p.Symbol, p.NewIssuer, p.Options)
+	case "issue":
+		return h.client.BuildAssetIssue(p.Account, p.To, p.Amount, p.Memo)
+	case "reserve":
+		return h.client.BuildAssetReserve(p.Account, p.Amount)
+	case "fund_fee_pool":
+		return h.client.BuildAssetFundFeePool(p.Account, p.Symbol, p.Amount)
+	}
+	return nil, &Error{CodeInvalidParams, "unknown asset action " + p.Action}
+}
+
+type accountUpdateParams struct {
+	Account string `json:"account"`
+	//nil to keep the authority
+	Owner   *types.Authority `json:"owner"`
+	Active  *types.Authority `json:"active"`
+	MemoKey string           `json:"memo_key"`
+}
+
+func (h *Handler) buildAccountUpdate(params json.RawMessage) (interface{}, error) {
+	var p accountUpdateParams
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	if len(p.Account) == 0 {
+		return nil, &Error{CodeInvalidParams, "account required"}
+	}
+	return h.client.BuildAccountUpdate(p.Account, p.Owner, p.Active, p.MemoKey)
+}
+
+func (h *Handler) getProposals(params json.RawMessage) (interface{}, error) {
+	var p addressParams
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	if len(p.Address) == 0 {
+		return nil, &Error{CodeInvalidParams, "address required"}
+	}
+	return h.client.ProposalsForAddress(p.Address)
+}
+
+type proposalParams struct {
+	Account string `json:"account"`
+	//unsigned transaction of the operations to propose
+	Tx string `json:"tx"`
+	//seconds
+	ExpiresIn    uint32 `json:"expires_in"`
+	ReviewPeriod uint32 `json:"review_period"`
+}
+
+func (h *Handler) buildProposal(params json.RawMessage) (interface{}, error) {
+	var p proposalParams
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	if len(p.Account) == 0 || len(p.Tx) == 0 {
+		return nil, &Error{CodeInvalidParams, "account and tx required"}
+	}
+	return h.client.BuildProposal(p.Account, p.Tx, time.Duration(p.ExpiresIn)*time.Second,
+		time.Duration(p.ReviewPeriod)*time.Second)
+}
+
+type proposalApprovalParams struct {
+	Account  string `json:"account"`
+	Proposal string `json:"proposal"`
+	//public keys approving, empty for the active approval of the account
+	Keys []string `json:"keys"`
+	//remove the approvals instead of adding them
+	Remove bool `json:"remove"`
+}
+
+func (h *Handler) buildProposalApproval(params json.RawMessage) (interface{}, error) {
+	var p proposalApprovalParams
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	if len(p.Account) == 0 || len(p.Proposal) == 0 {
+		return nil, &Error{CodeInvalidParams, "account and proposal required"}
+	}
+	return h.client.BuildProposalApproval(p.Account, p.Proposal, p.Keys, !p.Remove)
+}
+
+type txParams struct {
+	Tx        string `json:"tx"`
+	Signature string `json:"signature"`
+}
+
+func (h *Handler) getTransactionFee(params json.RawMessage) (interface{}, error) {
+	var p txParams
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	return h.client.TransactionFee(p.Tx)
+}
+
+type validateParams struct {
+	Tx         string   `json:"tx"`
+	Signatures []string `json:"signatures"`
+}
+
+func (h *Handler) validateTransaction(params json.RawMessage) (interface{}, error) {
+	var p validateParams
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	if len(p.Tx) == 0 {
+		return nil, &Error{CodeInvalidParams, "tx required"}
+	}
+	return h.client.ValidateTransaction(p.Tx, p.Signatures)
+}
+
+func (h *Handler) broadcast(params json.RawMessage) (interface{}, error) {
+	var p txParams
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	if len(p.Tx) == 0 || len(p.Signature) == 0 {
+		return nil, &Error{CodeInvalidParams, "tx and signature required"}
+	}
+	return h.client.SignTransaction(p.Tx, p.Signature)
+}
+
+type withdrawalParams struct {
+	Key string `json:"key"`
+	buildParams
+	Signature string `json:"signature"`
+}
+
+//a key submitted again returns its withdrawal, never another transfer
+func (h *Handler) buildWithdrawal(params json.RawMessage) (interface{}, error) {
+	var p withdrawalParams
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	if len(p.Key) == 0 || len(p.From) == 0 || len(p.To) == 0 || p.Amount == 0 {
+		return nil, &Error{CodeInvalidParams, "key, from, to and amount required"}
+	}
+	return h.client.BuildWithdrawal(p.Key, p.From, p.To, p.Symbol, p.Amount, p.Memo)
+}
+
+func (h *Handler) broadcastWithdrawal(params json.RawMessage) (interface{}, error) {
+	var p withdrawalParams
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	if len(p.Key) == 0 || len(p.Signature) == 0 {
+		return nil, &Error{CodeInvalidParams, "key and signature required"}
+	}
+	return h.client.BroadcastWithdrawal(p.Key, p.Signature)
+}
+
+func (h *Handler) getWithdrawal(params json.RawMessage) (interface{}, error) {
+	var p withdrawalParams
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	if len(p.Key) == 0 {
+		return nil, &Error{CodeInvalidParams, "key required"}
+	}
+	return h.client.WithdrawalStatus(p.Key)
+}
+
+func (h *Handler) decodeTransaction(params json.RawMessage) (interface{}, error) {
+	var p txParams
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	txs, err := api.Deserialize(p.Tx)
+	if err != nil {
+		return nil, newError(CodeInvalidParams, errors.Wrap(err, "invalid tx"))
+	}
+	return txs, nil
+}
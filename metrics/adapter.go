@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"gxclient-go/rpc"
+	"time"
+)
+
+//adapter metrics in the default registry
+var (
+	RPCDuration = NewHistogram(DefaultRegistry, "gxadapter_rpc_duration_seconds", "Latency of node rpc calls.", DefBuckets, "method")
+	RPCErrors   = NewCounter(DefaultRegistry, "gxadapter_rpc_errors_total", "Failed node rpc calls.", "method")
+	RPCInFlight = NewGauge(DefaultRegistry, "gxadapter_rpc_in_flight", "Node rpc calls in flight.")
+
+	//stage is built, signed or broadcast
+	Transactions = NewCounter(DefaultRegistry, "gxadapter_transactions_total", "Transactions handled by the adapter.", "stage")
+
+	HeadBlockLag = NewGauge(DefaultRegistry, "gxadapter_head_block_lag_seconds", "Seconds between now and the head block time.")
+	LIBBlockLag  = NewGauge(DefaultRegistry, "gxadapter_lib_block_lag_blocks", "Blocks between the head block and the last irreversible block.")
+)
+
+//stages of Transactions
+const (
+	StageBuilt     = "built"
+	StageSigned    = "signed"
+	StageBroadcast = "broadcast"
+)
+
+//ObserveBlocks updates the block lag gauges from the dynamic global properties
+func ObserveBlocks(headTime time.Time, headBlockNum, libBlockNum uint32) {
+	HeadBlockLag.Set(time.Since(headTime).Seconds())
+	LIBBlockLag.Set(float64(headBlockNum) - float64(libBlockNum))
+}
+
+//instrumentedCaller records every rpc call going through the transport
+type instrumentedCaller struct {
+	rpc.CallCloser
+}
+
+//InstrumentCaller wraps the transport with rpc metrics
+func InstrumentCaller(cc rpc.CallCloser) rpc.CallCloser {
+	return &instrumentedCaller{cc}
+}
+
+func (caller *instrumentedCaller) Call(api rpc.APIID, method string, args []interface{}, reply interface{}) error {
+	RPCInFlight.Add(1)
+	start := time.Now()
+	err := caller.CallCloser.Call(api, method, args, reply)
+	RPCDuration.Observe(time.Since(start).Seconds(), method)
+	RPCInFlight.Add(-1)
+	if err != nil {
+		RPCErrors.Inc(method)
+	}
+	return err
+}
@@ -0,0 +1,302 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//collector writes its samples in the prometheus text format
+type collector interface {
+	name() string
+	write(w io.Writer)
+}
+
+//Registry holds the metrics served by its handler
+type Registry struct {
+	mu         sync.Mutex
+	collectors []collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+//DefaultRegistry holds the adapter metrics
+var DefaultRegistry = NewRegistry()
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, registered := range r.collectors {
+		if registered.name() == c.name() {
+			panic("metrics: duplicate metric " + c.name())
+		}
+	}
+	r.collectors = append(r.collectors, c)
+}
+
+//Write writes all metrics in the prometheus text exposition format
+func (r *Registry) Write(w io.Writer) {
+	r.mu.Lock()
+	collectors := append([]collector{}, r.collectors...)
+	r.mu.Unlock()
+	sort.Slice(collectors, func(i, j int) bool { return collectors[i].name() < collectors[j].name() })
+	for _, c := range collectors {
+		c.write(w)
+	}
+}
+
+//Handler serves the registry, it can be mounted on any path
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		r.Write(w)
+	})
+}
+
+//Handler serves the default registry
+func Handler() http.Handler {
+	return DefaultRegistry.Handler()
+}
+
+//desc is the name, help and label names shared by every metric type
+type desc struct {
+	metricName string
+	help       string
+	labels     []string
+	kind       string
+}
+
+func (d *desc) name() string {
+	return d.metricName
+}
+
+func (d *desc) header(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.metricName, d.help, d.metricName, d.kind)
+}
+
+//label pairs of the values, extra pairs are appended as is
+func (d *desc) labelString(values []string, extra ...string) string {
+	var pairs []string
+	for i, label := range d.labels {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", label, values[i]))
+	}
+	pairs = append(pairs, extra...)
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func (d *desc) key(values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", d.metricName, len(d.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+//values of a metric by label values
+type series struct {
+	mu     sync.Mutex
+	values map[string]float64
+	labels map[string][]string
+}
+
+func (s *series) add(d *desc, values []string, delta float64, set bool) {
+	key := d.key(values)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.values == nil {
+		s.values = map[string]float64{}
+		s.labels = map[string][]string{}
+	}
+	if set {
+		s.values[key] = delta
+	} else {
+		s.values[key] += delta
+	}
+	s.labels[key] = values
+}
+
+func (s *series) get(d *desc, values []string) float64 {
+	key := d.key(values)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.values[key]
+}
+
+func (s *series) write(w io.Writer, d *desc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d.header(w)
+	keys := make([]string, 0, len(s.values))
+	for key := range s.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	if len(keys) == 0 && len(d.labels) == 0 {
+		fmt.Fprintf(w, "%s 0\n", d.metricName)
+	}
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s%s %s\n", d.metricName, d.labelString(s.labels[key]), formatFloat(s.values[key]))
+	}
+}
+
+//Counter only goes up
+type Counter struct {
+	desc
+	series
+}
+
+func NewCounter(r *Registry, name, help string, labels ...string) *Counter {
+	c := &Counter{desc: desc{name, help, labels, "counter"}}
+	r.register(c)
+	return c
+}
+
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		panic("metrics: counter cannot decrease")
+	}
+	c.add(&c.desc, labelValues, delta, false)
+}
+
+func (c *Counter) Value(labelValues ...string) float64 {
+	return c.get(&c.desc, labelValues)
+}
+
+func (c *Counter) write(w io.Writer) {
+	c.series.write(w, &c.desc)
+}
+
+//Gauge goes up and down
+type Gauge struct {
+	desc
+	series
+}
+
+func NewGauge(r *Registry, name, help string, labels ...string) *Gauge {
+	g := &Gauge{desc: desc{name, help, labels, "gauge"}}
+	r.register(g)
+	return g
+}
+
+func (g *Gauge) Set(v float64, labelValues ...string) {
+	g.add(&g.desc, labelValues, v, true)
+}
+
+func (g *Gauge) Add(delta float64, labelValues ...string) {
+	g.add(&g.desc, labelValues, delta, false)
+}
+
+func (g *Gauge) Value(labelValues ...string) float64 {
+	return g.get(&g.desc, labelValues)
+}
+
+func (g *Gauge) write(w io.Writer) {
+	g.series.write(w, &g.desc)
+}
+
+//DefBuckets are the default histogram buckets in seconds
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type histogramValue struct {
+	labels []string
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+//Histogram counts observations in buckets
+type Histogram struct {
+	desc
+	buckets []float64
+
+	mu     sync.Mutex
+	values map[string]*histogramValue
+}
+
+func NewHistogram(r *Registry, name, help string, buckets []float64, labels ...string) *Histogram {
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+	buckets = append([]float64{}, buckets...)
+	sort.Float64s(buckets)
+	h := &Histogram{
+		desc:    desc{name, help, labels, "histogram"},
+		buckets: buckets,
+		values:  map[string]*histogramValue{},
+	}
+	r.register(h)
+	return h
+}
+
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	key := h.key(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	value := h.values[key]
+	if value == nil {
+		value = &histogramValue{labels: labelValues, counts: make([]uint64, len(h.buckets))}
+		h.values[key] = value
+	}
+	for i, bound := range h.buckets {
+		if v <= bound {
+			value.counts[i]++
+		}
+	}
+	value.sum += v
+	value.count++
+}
+
+//number of observations
+func (h *Histogram) Count(labelValues ...string) uint64 {
+	key := h.key(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if value := h.values[key]; value != nil {
+		return value.count
+	}
+	return 0
+}
+
+func (h *Histogram) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.header(w)
+	keys := make([]string, 0, len(h.values))
+	for key := range h.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := h.values[key]
+		for i, bound := range h.buckets {
+			le := fmt.Sprintf("le=%q", formatFloat(bound))
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, h.labelString(value.labels, le), value.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, h.labelString(value.labels, `le="+Inf"`), value.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.metricName, h.labelString(value.labels), formatFloat(value.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.metricName, h.labelString(value.labels), value.count)
+	}
+}
@@ -0,0 +1,561 @@
+package rest
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"gxclient-adapter/api"
+	"gxclient-adapter/types"
+	gxcTypes "gxclient-go/types"
+	"io"
+	"net/http"
+	"time"
+)
+
+//Handler serves the RestClient methods and the offline helpers over http,
+//every endpoint takes a json body by POST and responds {"result":...} or {"error":"..."}
+type Handler struct {
+	client *api.RestClient
+	mux    *http.ServeMux
+}
+
+type handlerFunc func(r *http.Request) (interface{}, error)
+
+//errBadRequest marks errors caused by the request
+type errBadRequest struct {
+	error
+}
+
+func badRequest(err error) error {
+	return errBadRequest{err}
+}
+
+func NewHandler(restClient *api.RestClient) *Handler {
+	h := &Handler{client: restClient, mux: http.NewServeMux()}
+
+	//node queries
+	h.handle("/balance", h.balance)
+	h.handle("/balances", h.balances)
+	h.handle("/txs", h.txs)
+	h.handle("/tx", h.tx)
+	h.handle("/block_count", h.blockCount)
+	h.handle("/block_txs", h.blockTxs)
+	h.handle("/token", h.token)
+	h.handle("/address", h.address)
+	h.handle("/check_address", h.checkAddress)
+	h.handle("/contract_table", h.contractTable)
+	h.handle("/stakings", h.stakings)
+	h.handle("/proposals", h.proposals)
+
+	//transaction flow
+	h.handle("/build", h.build)
+	h.handle("/build_contract_call", h.buildContractCall)
+	h.handle("/build_staking", h.buildStaking)
+	h.handle("/build_asset", h.buildAsset)
+	h.handle("/build_account_update", h.buildAccountUpdate)
+	h.handle("/build_proposal", h.buildProposal)
+	h.handle("/build_proposal_approval", h.buildProposalApproval)
+	h.handle("/fee", h.fee)
+	h.handle("/transaction_fee", h.transactionFee)
+	h.handle("/validate", h.validate)
+	h.handle("/broadcast", h.broadcast)
+
+	//idempotent withdrawals
+	h.handle("/build_withdrawal", h.buildWithdrawal)
+	h.handle("/broadcast_withdrawal", h.broadcastWithdrawal)
+	h.handle("/withdrawal", h.withdrawal)
+
+	//offline helpers
+	h.handle("/deserialize", h.deserialize)
+	h.handle("/deserialize_memo", h.deserializeMemo)
+	h.handle("/convert_key", h.convertKey)
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) handle(pattern string, fn handlerFunc) {
+	h.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+		result, err := fn(r)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if _, ok := err.(errBadRequest); ok {
+				status = http.StatusBadRequest
+			}
+			writeJSON(w, status, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"result": result})
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+//decode the json body into req, an empty body leaves req untouched
+func decode(r *http.Request, req interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil && err != io.EOF {
+		return badRequest(errors.Wrap(err, "invalid request body"))
+	}
+	return nil
+}
+
+type addressRequest struct {
+	Address string `json:"address"`
+	Symbol  string `json:"symbol"`
+}
+
+func (h *Handler) balance(r *http.Request) (interface{}, error) {
+	var req addressRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	return h.client.BalanceForAddress(req.Address, req.Symbol)
+}
+
+func (h *Handler) balances(r *http.Request) (interface{}, error) {
+	var req addressRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	return h.client.BalancesForAddress(req.Address)
+}
+
+type txsRequest struct {
+	Address   string `json:"address"`
+	SinceTxId string `json:"since_tx_id"`
+	Limit     int    `json:"limit"`
+}
+
+func (h *Handler) txs(r *http.Request) (interface{}, error) {
+	req := txsRequest{Limit: 10}
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	return h.client.TxsForAddress(req.Address, req.SinceTxId, req.Limit)
+}
+
+type txRequest struct {
+	TxHash     string `json:"tx_hash"`
+	BlockNum   uint32 `json:"block_num"`
+	TrxInBlock int    `json:"trx_in_block"`
+}
+
+//by tx_hash, or by block_num and trx_in_block
+func (h *Handler) tx(r *http.Request) (interface{}, error) {
+	var req txRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	if len(req.TxHash) > 0 {
+		return h.client.GetTransaction(req.TxHash)
+	}
+	if req.BlockNum == 0 {
+		return nil, badRequest(errors.New("tx_hash or block_num required"))
+	}
+	return h.client.GetTransactionByBlockNumAndId(req.BlockNum, req.TrxInBlock)
+}
+
+func (h *Handler) blockCount(r *http.Request) (interface{}, error) {
+	return h.client.GetBlockCount()
+}
+
+type blockRequest struct {
+	BlockNo uint32 `json:"block_no"`
+}
+
+func (h *Handler) blockTxs(r *http.Request) (interface{}, error) {
+	var req blockRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	return h.client.GetBlockTxs(req.BlockNo)
+}
+
+type tokenRequest struct {
+	Token string `json:"token"`
+}
+
+func (h *Handler) token(r *http.Request) (interface{}, error) {
+	var req tokenRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	return h.client.TokenDetail(req.Token)
+}
+
+type keyRequest struct {
+	Type string `json:"type"`
+	Key  string `json:"key"`
+	//public key prefix, GXC by default
+	Prefix string `json:"prefix"`
+}
+
+//accounts by public key in hex
+func (h *Handler) address(r *http.Request) (interface{}, error) {
+	var req keyRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	return h.client.Pubkey2address(req.Key)
+}
+
+//syntax errors are bad requests, a missing account is reported by exists
+func (h *Handler) checkAddress(r *http.Request) (interface{}, error) {
+	var req addressRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	if err := api.ValidateAddress(req.Address); err != nil {
+		return nil, badRequest(err)
+	}
+	return h.client.CheckAddress(req.Address)
+}
+
+type tableRequest struct {
+	Contract   string `json:"contract"`
+	Table      string `json:"table"`
+	LowerBound uint64 `json:"lower_bound"`
+	UpperBound uint64 `json:"upper_bound"`
+	Limit      int    `json:"limit"`
+}
+
+func (h *Handler) contractTable(r *http.Request) (interface{}, error) {
+	var req tableRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	return h.client.GetContractTable(req.Contract, req.Table, req.LowerBound, req.UpperBound, req.Limit)
+}
+
+type buildRequest struct {
+	From   string         `json:"from"`
+	To     string         `json:"to"`
+	Symbol string         `json:"symbol"`
+	Amount uint64         `json:"amount"`
+	Memo   *gxcTypes.Memo `json:"memo"`
+}
+
+//the memo is encrypted by the caller with EncryptMemo
+func (h *Handler) build(r *http.Request) (interface{}, error) {
+	var req buildRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	return h.client.BuildTransaction(req.From, req.To, req.Symbol, req.Amount, req.Memo)
+}
+
+type contractCallRequest struct {
+	From     string          `json:"from"`
+	Contract string          `json:"contract"`
+	Method   string          `json:"method"`
+	Args     json.RawMessage `json:"args"`
+	//decimal amount and asset, e.g. "1.5 GXC"
+	Amount string `json:"amount"`
+}
+
+func (h *Handler) buildContractCall(r *http.Request) (interface{}, error) {
+	var req contractCallRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	return h.client.BuildContractCall(req.From, req.Contract, req.Method, req.Args, req.Amount)
+}
+
+func (h *Handler) stakings(r *http.Request) (interface{}, error) {
+	var req addressRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	return h.client.StakingsForAddress(req.Address)
+}
+
+type stakingRequest struct {
+	//create, update, claim, lock or unlock
+	Action    string `json:"action"`
+	Account   string `json:"account"`
+	TrustNode string `json:"trust_node"`
+	ProgramId string `json:"program_id"`
+	//staking id of update and claim, lock id of unlock
+	Id string `json:"id"`
+	//decimal amount of GXC, e.g. "100 GXC"
+	Amount string `json:"amount"`
+	Memo   string `json:"memo"`
+}
+
+func (h *Handler) buildStaking(r *http.Request) (interface{}, error) {
+	var req stakingRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	switch req.Action {
+	case "create":
+		return h.client.BuildStakingCreate(req.Account, req.TrustNode, req.ProgramId, req.Amount)
+	case "update":
+		return h.client.BuildStakingUpdate(req.Account, req.Id, req.TrustNode)
+	case "claim":
+		return h.client.BuildStakingClaim(req.Account, req.Id)
+	case "lock":
+		return h.client.BuildBalanceLock(req.Account, req.ProgramId, req.Amount, req.Memo)
+	case "unlock":
+		return h.client.BuildBalanceUnlock(req.Account, req.Id)
+	}
+	return nil, badRequest(errors.Errorf("unknown staking action %s", req.Action))
+}
+
+type assetRequest struct {
+	//create, update, issue, reserve or fund_fee_pool
+	Action    string `json:"action"`
+	Account   string `json:"account"`
+	Symbol    string `json:"symbol"`
+	Precision uint8  `json:"precision"`
+	//receiver of issue
+	To        string `json:"to"`
+	NewIssuer string `json:"new_issuer"`
+	//decimal amount and asset, e.g. "100 LOY"
+	Amount  string           `json:"amount"`
+	Memo    *gxcTypes.Memo   `json:"memo"`
+	Options *api.AssetParams `json:"options"`
+}
+
+//the memo is encrypted by the caller with EncryptMemo
+func (h *Handler) buildAsset(r *http.Request) (interface{}, error) {
+	var req assetRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	switch req.Action {
+	case "create":
+		return h.client.BuildAssetCreate(req.Account, req.Symbol, req.Precision, req.Options)
+	case "update":
+		return h.client.BuildAssetUpdate(req.Account, req.Symbol, req.NewIssuer, req.Options)
+	case "issue":
+		return h.client.BuildAssetIssue(req.Account, req.To, req.Amount, req.Memo)
+	case "reserve":
+		return h.client.BuildAssetReserve(req.Account, req.Amount)
+	case "fund_fee_pool":
+		return h.client.BuildAssetFundFeePool(req.Account, req.Symbol, req.Amount)
+	}
+	return nil, badRequest(errors.Errorf("unknown asset action %s", req.Action))
+}
+
+type accountUpdateRequest struct {
+	Account string `json:"account"`
+	//nil to keep the authority
+	Owner   *types.Authority `json:"owner"`
+	Active  *types.Authority `json:"active"`
+	MemoKey string           `json:"memo_key"`
+}
+
+func (h *Handler) buildAccountUpdate(r *http.Request) (interface{}, error) {
+	var req accountUpdateRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	return h.client.BuildAccountUpdate(req.Account, req.Owner, req.Active, req.MemoKey)
+}
+
+func (h *Handler) proposals(r *http.Request) (interface{}, error) {
+	var req addressRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	return h.client.ProposalsForAddress(req.Address)
+}
+
+type proposalRequest struct {
+	Account string `json:"account"`
+	//unsigned transaction of the operations to propose
+	Tx string `json:"tx"`
+	//seconds
+	ExpiresIn    uint32 `json:"expires_in"`
+	ReviewPeriod uint32 `json:"review_period"`
+}
+
+func (h *Handler) buildProposal(r *http.Request) (interface{}, error) {
+	var req proposalRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	return h.client.BuildProposal(req.Account, req.Tx, time.Duration(req.ExpiresIn)*time.Second,
+		time.Duration(req.ReviewPeriod)*time.Second)
+}
+
+type proposalApprovalRequest struct {
+	Account  string `json:"account"`
+	Proposal string `json:"proposal"`
+	//public keys approving, empty for the active approval of the account
+	Keys []string `json:"keys"`
+	//remove the approvals instead of adding them
+	Remove bool `json:"remove"`
+}
+
+func (h *Handler) buildProposalApproval(r *http.Request) (interface{}, error) {
+	var req proposalApprovalRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	return h.client.BuildProposalApproval(req.Account, req.Proposal, req.Keys, !req.Remove)
+}
+
+type feeRequest struct {
+	Memo *gxcTypes.Memo `json:"memo"`
+}
+
+func (h *Handler) fee(r *http.Request) (interface{}, error) {
+	var req feeRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	return h.client.GetRequiredFee(req.Memo)
+}
+
+type txJSONRequest struct {
+	Tx        string `json:"tx"`
+	Signature string `json:"signature"`
+}
+
+func (h *Handler) transactionFee(r *http.Request) (interface{}, error) {
+	var req txJSONRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	if _, err := api.ParseTransaction(req.Tx); err != nil {
+		return nil, badRequest(err)
+	}
+	return h.client.TransactionFee(req.Tx)
+}
+
+type validateRequest struct {
+	Tx         string   `json:"tx"`
+	Signatures []string `json:"signatures"`
+}
+
+func (h *Handler) validate(r *http.Request) (interface{}, error) {
+	var req validateRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	return h.client.ValidateTransaction(req.Tx, req.Signatures)
+}
+
+func (h *Handler) broadcast(r *http.Request) (interface{}, error) {
+	var req txJSONRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	if len(req.Signature) == 0 {
+		return nil, badRequest(errors.New("signature required"))
+	}
+	return h.client.SignTransaction(req.Tx, req.Signature)
+}
+
+type withdrawalRequest struct {
+	Key string `json:"key"`
+	buildRequest
+	Signature string `json:"signature"`
+}
+
+//a key submitted again returns its withdrawal, never another transfer
+func (h *Handler) buildWithdrawal(r *http.Request) (interface{}, error) {
+	var req withdrawalRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	if len(req.Key) == 0 {
+		return nil, badRequest(errors.New("key required"))
+	}
+	return h.client.BuildWithdrawal(req.Key, req.From, req.To, req.Symbol, req.Amount, req.Memo)
+}
+
+func (h *Handler) broadcastWithdrawal(r *http.Request) (interface{}, error) {
+	var req withdrawalRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	if len(req.Key) == 0 || len(req.Signature) == 0 {
+		return nil, badRequest(errors.New("key and signature required"))
+	}
+	return h.client.BroadcastWithdrawal(req.Key, req.Signature)
+}
+
+func (h *Handler) withdrawal(r *http.Request) (interface{}, error) {
+	var req withdrawalRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	if len(req.Key) == 0 {
+		return nil, badRequest(errors.New("key required"))
+	}
+	return h.client.WithdrawalStatus(req.Key)
+}
+
+func (h *Handler) deserialize(r *http.Request) (interface{}, error) {
+	var req txJSONRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	txs, err := api.Deserialize(req.Tx)
+	if err != nil {
+		return nil, badRequest(err)
+	}
+	return txs, nil
+}
+
+type memoRequest struct {
+	MemoPriHex string          `json:"memo_pri_hex"`
+	From       string          `json:"from"`
+	To         string          `json:"to"`
+	Message    string          `json:"message"`
+	Nonce      gxcTypes.UInt64 `json:"nonce"`
+}
+
+func (h *Handler) deserializeMemo(r *http.Request) (interface{}, error) {
+	var req memoRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	memo, err := api.DeserializeMemo(req.MemoPriHex, req.From, req.To, req.Message, req.Nonce)
+	if err != nil {
+		return nil, badRequest(err)
+	}
+	return memo, nil
+}
+
+//type is one of pri_hex_to_wif, pri_wif_to_hex, pub_hex_to_base58 and pub_base58_to_hex
+func (h *Handler) convertKey(r *http.Request) (interface{}, error) {
+	var req keyRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	network := api.MainNet
+	if len(req.Prefix) > 0 {
+		network = &api.NetworkConfig{Prefix: req.Prefix}
+	}
+	var convert func(string) (string, error)
+	switch req.Type {
+	case "pri_hex_to_wif":
+		convert = api.PriKeyHexToWif
+	case "pri_wif_to_hex":
+		convert = api.PriKeyWifToHex
+	case "pub_hex_to_base58":
+		convert = network.PubKeyHexToBase58
+	case "pub_base58_to_hex":
+		convert = network.PubKeyBase58ToHex
+	default:
+		return nil, badRequest(errors.Errorf("unknown type %s", req.Type))
+	}
+	key, err := convert(req.Key)
+	if err != nil {
+		return nil, badRequest(err)
+	}
+	return key, nil
+}
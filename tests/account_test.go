@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"gxclient-adapter/api"
+	"gxclient-adapter/types"
+	"testing"
+)
+
+const testOtherPub = "GXC8AoHzhXhMRV9AFTihMAcQPNXKFEZCeYNYomdcc7vh8Gzp7b7xP"
+
+const testAccount = `{"id":"1.2.4015","name":"cli-wallet-test",
+	"owner":{"weight_threshold":1,"account_auths":[],"key_auths":[["` + testPub + `",1]],"address_auths":[]},
+	"active":{"weight_threshold":1,"account_auths":[],"key_auths":[["` + testPub + `",1]],"address_auths":[]},
+	"options":{"memo_key":"` + testPub + `","voting_account":"1.2.5","num_witness":0,"num_committee":0,"votes":[],"extensions":[]}}`
+
+func newAccountNode() *fakeNode {
+	node := newFakeNode()
+	node.SetAccount("cli-wallet-test", json.RawMessage(testAccount))
+	return node
+}
+
+func Test_BuildAccountUpdate(t *testing.T) {
+	restClient := newAccountNode().Client()
+
+	owner := &types.Authority{Threshold: 2, Keys: map[string]uint16{testPub: 1, testOtherPub: 1}, Accounts: map[string]uint16{"init0": 1}}
+	unsigned, err := restClient.BuildAccountUpdate(testAccountName, owner, nil, testOtherPub)
+	require.Nil(t, err)
+	op := gjson.Get(unsigned, "operations.0")
+	require.Equal(t, int64(6), op.Get("0").Int())
+	require.Equal(t, "1.2.4015", op.Get("1.account").String())
+	require.Equal(t, int64(2), op.Get("1.owner.weight_threshold").Int())
+	require.Equal(t, 2, len(op.Get("1.owner.key_auths").Array()))
+	require.Equal(t, "1.2.17", op.Get("1.owner.account_auths.0.0").String())
+	require.False(t, op.Get("1.active").Exists())
+	require.Equal(t, testOtherPub, op.Get("1.new_options.memo_key").String())
+	require.Equal(t, "1.2.5", op.Get("1.new_options.voting_account").String())
+	_, err = api.Sign(testPriHex, testChainId, unsigned)
+	require.Nil(t, err)
+
+	txs, err := api.Deserialize(unsigned)
+	require.Nil(t, err)
+	require.Equal(t, types.OpAccountUpdate, txs[0].OpType)
+	require.Equal(t, uint32(2), txs[0].AccountUpdate.Owner.Threshold)
+	require.Equal(t, uint16(1), txs[0].AccountUpdate.Owner.Accounts["1.2.17"])
+	require.Equal(t, testOtherPub, txs[0].AccountUpdate.MemoKey)
+
+	//the keys alone do not reach the threshold, init0 has to approve, which is warned
+	var b bytes.Buffer
+	restClient.SetLogger(api.NewTextLogger(&b, api.LevelWarn))
+	unsigned, err = restClient.BuildAccountUpdate(testAccountName, nil,
+		&types.Authority{Threshold: 2, Keys: map[string]uint16{testPub: 1}, Accounts: map[string]uint16{"1.2.17": 1}}, "")
+	require.Nil(t, err)
+	require.False(t, gjson.Get(unsigned, "operations.0.1.new_options").Exists())
+	require.Contains(t, b.String(), "authority threshold unreachable with the keys")
+	require.Contains(t, b.String(), `authority="active"`)
+}
+
+func Test_BuildAccountUpdateInvalid(t *testing.T) {
+	restClient := newAccountNode().Client()
+
+	_, err := restClient.BuildAccountUpdate(testAccountName, nil, nil, "")
+	require.NotNil(t, err)
+	_, err = restClient.BuildAccountUpdate(testAccountName, &types.Authority{Threshold: 5, Keys: map[string]uint16{testPub: 1, testOtherPub: 1}}, nil, "")
+	require.NotNil(t, err)
+	_, err = restClient.BuildAccountUpdate(testAccountName, &types.Authority{Threshold: 0, Keys: map[string]uint16{testPub: 1}}, nil, "")
+	require.NotNil(t, err)
+	_, err = restClient.BuildAccountUpdate(testAccountName, &types.Authority{Threshold: 1, Keys: map[string]uint16{testPub: 0}}, nil, "")
+	require.NotNil(t, err)
+	_, err = restClient.BuildAccountUpdate(testAccountName, &types.Authority{Threshold: 1, Keys: map[string]uint16{"GXCinvalid": 1}}, nil, "")
+	require.NotNil(t, err)
+	_, err = restClient.BuildAccountUpdate(testAccountName, nil, nil, "GXCinvalid")
+	require.NotNil(t, err)
+}
@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"gxclient-adapter/api"
+	"gxclient-go/rpc"
+	"strings"
+	"testing"
+)
+
+func Test_ValidateAddress(t *testing.T) {
+	for _, name := range []string{"a", "init0", "cli-wallet-test", "gxb.io", "a1.b-2.c", strings.Repeat("a", 63)} {
+		require.Nil(t, api.ValidateAddress(name), name)
+	}
+	for _, name := range []string{"", "Init0", "0init", "init-", "init_0", "gxb..io", ".gxb", "gxb.", "in it", strings.Repeat("a", 64)} {
+		require.NotNil(t, api.ValidateAddress(name), name)
+	}
+}
+
+func Test_CheckAddress(t *testing.T) {
+	node := newFakeNode()
+	memoKeys := map[string]string{
+		"1.2.4015": testPub,
+		"1.2.17":   "GXC1111111111111111111111111111111114T1Anm",
+	}
+	node.Handle("get_account_by_name", func(args gjson.Result) (interface{}, error) {
+		switch args.Get("0").String() {
+		case "cli-wallet-test":
+			return map[string]string{"id": "1.2.4015", "name": "cli-wallet-test"}, nil
+		case "init0":
+			return map[string]string{"id": "1.2.17", "name": "init0"}, nil
+		case "broken":
+			return nil, &rpc.RPCError{Code: 1, Message: "account broken not exist"}
+		case "failing":
+			return map[string]string{"id": "1.2.98", "name": "failing"}, nil
+		case "vanished":
+			return map[string]string{"id": "1.2.99", "name": "vanished"}, nil
+		}
+		return nil, nil
+	})
+	node.Handle("get_objects", func(args gjson.Result) (interface{}, error) {
+		id := args.Get("0.0").String()
+		switch id {
+		case "1.2.98":
+			return nil, &rpc.RPCError{Code: 1, Message: "database error"}
+		case "1.2.99":
+			return []json.RawMessage{json.RawMessage("null")}, nil
+		}
+		return []json.RawMessage{json.RawMessage(`{"id":"` + id + `","options":{"memo_key":"` + memoKeys[id] + `"}}`)}, nil
+	})
+	restClient := node.Client()
+
+	info, err := restClient.CheckAddress("cli-wallet-test")
+	require.Nil(t, err)
+	require.True(t, info.Exists)
+	require.True(t, info.HasMemoKey)
+	require.Equal(t, "1.2.4015", info.AccountId)
+	require.Equal(t, memoKeys["1.2.4015"], info.MemoKey)
+
+	info, err = restClient.CheckAddress("init0")
+	require.Nil(t, err)
+	require.True(t, info.Exists)
+	require.False(t, info.HasMemoKey)
+
+	info, err = restClient.CheckAddress("nobody")
+	require.Nil(t, err)
+	require.False(t, info.Exists)
+
+	//only a null account is missing, a failed lookup is an error whatever it says
+	_, err = restClient.CheckAddress("broken")
+	require.NotNil(t, err)
+	_, err = restClient.CheckAddress("failing")
+	require.NotNil(t, err)
+	_, err = restClient.CheckAddress("vanished")
+	require.NotNil(t, err)
+
+	_, err = restClient.CheckAddress("No Body")
+	require.NotNil(t, err)
+	require.Equal(t, 6, node.Calls("get_account_by_name"))
+}
@@ -0,0 +1,225 @@
+package tests
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"gxclient-adapter/api"
+	"gxclient-adapter/types"
+	gxcTypes "gxclient-go/types"
+	"testing"
+)
+
+//LOY, issued by cli-wallet-test, 1000000 LOY at most and 500000 LOY issued,
+//its fee pool pays 0.5 GXC per LOY
+const testLoyAsset = `{"id":"1.3.20","symbol":"LOY","precision":4,"issuer":"1.2.4015","dynamic_asset_data_id":"2.3.20",
+	"options":{"max_supply":"10000000000","market_fee_percent":0,"max_market_fee":"0","issuer_permissions":79,"flags":2,
+	"core_exchange_rate":{"base":{"amount":20000,"asset_id":"1.3.20"},"quote":{"amount":100000,"asset_id":"1.3.1"}},
+	"whitelist_authorities":["1.2.17"],"blacklist_authorities":[],"whitelist_markets":[],"blacklist_markets":[],
+	"description":"loyalty points","extensions":[]}}`
+
+const testAssetHistory = `[{"id":"1.11.700","block_num":110,"trx_in_block":0,"op_in_trx":0,"virtual_op":1,"result":[0,{}],
+	"op":[16,{"fee":{"amount":1000,"asset_id":"1.3.1"},"from_account":"1.2.4015","asset_id":"1.3.20","amount":"5000000","extensions":[]}]}]`
+
+//creation of POINT as the chain accepts it, the evaluator replaces the non-zero base
+//of the core exchange rate with the id of the new asset and keeps the GXC quote
+const testAssetCreateTx = `{"ref_block_num":99,"ref_block_prefix":0,"expiration":"2020-03-19T04:30:00","operations":[[10,{
+	"fee":{"amount":1000,"asset_id":"1.3.1"},"issuer":"1.2.4015","symbol":"POINT","precision":2,
+	"common_options":{"max_supply":"100000000","market_fee_percent":0,"max_market_fee":"0","issuer_permissions":79,"flags":0,
+	"core_exchange_rate":{"base":{"amount":100,"asset_id":"1.3.2"},"quote":{"amount":1000,"asset_id":"1.3.1"}},
+	"whitelist_authorities":[],"blacklist_authorities":[],"whitelist_markets":[],"blacklist_markets":[],"description":"","extensions":[]},
+	"is_prediction_market":false,"extensions":[]}]],"extensions":[],"signatures":[]}`
+
+func newAssetNode() *fakeNode {
+	node := newFakeNode()
+	gxc := node.handlers["lookup_asset_symbols"]
+	node.Handle("lookup_asset_symbols", func(args gjson.Result) (interface{}, error) {
+		switch args.Get("0.0").String() {
+		case "LOY", "1.3.20":
+			return []json.RawMessage{json.RawMessage(testLoyAsset)}, nil
+		}
+		return gxc(args)
+	})
+	objects := node.handlers["get_objects"]
+	node.Handle("get_objects", func(args gjson.Result) (interface{}, error) {
+		if args.Get("0.0").String() == "2.3.20" {
+			return []json.RawMessage{json.RawMessage(`{"id":"2.3.20","current_supply":"5000000000","accumulated_fees":1200,"fee_pool":"300000"}`)}, nil
+		}
+		return objects(args)
+	})
+	node.Handle("get_account_history", func(args gjson.Result) (interface{}, error) {
+		return json.RawMessage(testAssetHistory), nil
+	})
+	return node
+}
+
+func Test_AssetTokenDetail(t *testing.T) {
+	token, err := newAssetNode().Client().TokenDetail("LOY")
+	require.Nil(t, err)
+	require.Equal(t, "1.3.20", token.TokenIdentifier)
+	require.Equal(t, "cli-wallet-test", token.Issuer)
+	require.Equal(t, uint64(10000000000), token.MaxSupply)
+	require.Equal(t, uint64(5000000000), token.CurrentSupply)
+	require.Equal(t, []string{"white_list"}, token.Flags)
+	require.Equal(t, []string{"charge_market_fee", "white_list", "override_authority", "transfer_restricted", "disable_confidential"}, token.IssuerPermissions)
+	require.Equal(t, uint64(300000), token.FeePool.Balance)
+	require.Equal(t, uint64(1200), token.FeePool.AccumulatedFees)
+	require.Equal(t, "0.5", token.FeePool.CoreExchangeRate)
+}
+
+func Test_BuildAssetCreate(t *testing.T) {
+	restClient := newAssetNode().Client()
+
+	unsigned, err := restClient.BuildAssetCreate(testAccountName, "POINT", 2, &api.AssetParams{
+		MaxSupply:        "1000000",
+		CoreExchangeRate: "0.01",
+		Flags:            []string{"transfer_restricted"},
+		Description:      "points",
+	})
+	require.Nil(t, err)
+	op := gjson.Get(unsigned, "operations.0")
+	require.Equal(t, int64(10), op.Get("0").Int())
+	require.Equal(t, "POINT", op.Get("1.symbol").String())
+	options := op.Get("1.common_options")
+	require.Equal(t, int64(100000000), options.Get("max_supply").Int())
+	require.Equal(t, int64(79), options.Get("issuer_permissions").Int())
+	require.Equal(t, int64(8), options.Get("flags").Int())
+	require.Equal(t, int64(100), options.Get("core_exchange_rate.base.amount").Int())
+	require.Equal(t, int64(1000), options.Get("core_exchange_rate.quote.amount").Int())
+	_, err = api.Sign(testPriHex, testChainId, unsigned)
+	require.Nil(t, err)
+
+	txs, err := api.Deserialize(unsigned)
+	require.Nil(t, err)
+	require.Equal(t, types.OpAssetCreate, txs[0].OpType)
+	require.Equal(t, "POINT", txs[0].AssetChange.Symbol)
+	require.Equal(t, []string{"transfer_restricted"}, txs[0].AssetChange.Flags)
+
+	//the same bytes are signed as for the known good creation
+	params := &api.AssetParams{MaxSupply: "1000000", CoreExchangeRate: "0.01"}
+	unsigned, err = restClient.BuildAssetCreate(testAccountName, "POINT", 2, params)
+	require.Nil(t, err)
+	rate := gjson.Get(unsigned, "operations.0.1.common_options.core_exchange_rate")
+	require.NotEqual(t, uint64(0), gxcTypes.MustParseObjectID(rate.Get("base.asset_id").String()).ID)
+	require.Equal(t, "1.3.1", rate.Get("quote.asset_id").String())
+	signature, err := api.Sign(testPriHex, testChainId, unsigned)
+	require.Nil(t, err)
+	expected, err := api.Sign(testPriHex, testChainId, testAssetCreateTx)
+	require.Nil(t, err)
+	require.Equal(t, expected, signature)
+
+	_, err = restClient.BuildAssetCreate(testAccountName, "LOY", 2, params)
+	require.NotNil(t, err)
+	_, err = restClient.BuildAssetCreate(testAccountName, "point", 2, params)
+	require.NotNil(t, err)
+	_, err = restClient.BuildAssetCreate(testAccountName, "POINT", 2, &api.AssetParams{MaxSupply: "1000000"})
+	require.NotNil(t, err)
+	_, err = restClient.BuildAssetCreate(testAccountName, "POINT", 2, &api.AssetParams{MaxSupply: "1000000.001", CoreExchangeRate: "0.01"})
+	require.NotNil(t, err)
+	_, err = restClient.BuildAssetCreate(testAccountName, "POINT", 2, &api.AssetParams{MaxSupply: "1000000", CoreExchangeRate: "0.01",
+		Flags: []string{"white_list"}, IssuerPermissions: []string{"override_authority"}})
+	require.NotNil(t, err)
+	_, err = restClient.BuildAssetCreate(testAccountName, "POINT", 2, &api.AssetParams{MaxSupply: "1000000", CoreExchangeRate: "0.01",
+		IssuerPermissions: []string{"witness_fed_asset"}})
+	require.NotNil(t, err)
+}
+
+func Test_BuildAssetUpdate(t *testing.T) {
+	restClient := newAssetNode().Client()
+
+	unsigned, err := restClient.BuildAssetUpdate(testAccountName, "LOY", "init0", &api.AssetParams{Flags: []string{}})
+	require.Nil(t, err)
+	op := gjson.Get(unsigned, "operations.0")
+	require.Equal(t, int64(11), op.Get("0").Int())
+	require.Equal(t, "1.3.20", op.Get("1.asset_to_update").String())
+	require.Equal(t, "1.2.17", op.Get("1.new_issuer").String())
+	require.Equal(t, int64(0), op.Get("1.new_options.flags").Int())
+	require.Equal(t, int64(10000000000), op.Get("1.new_options.max_supply").Int())
+	require.Equal(t, "loyalty points", op.Get("1.new_options.description").String())
+	_, err = api.Sign(testPriHex, testChainId, unsigned)
+	require.Nil(t, err)
+
+	_, err = restClient.BuildAssetUpdate("init0", "LOY", "", nil)
+	require.NotNil(t, err)
+}
+
+func Test_BuildAssetIssue(t *testing.T) {
+	restClient := newAssetNode().Client()
+
+	unsigned, err := restClient.BuildAssetIssue(testAccountName, "init0", "100.5 LOY", nil)
+	require.Nil(t, err)
+	op := gjson.Get(unsigned, "operations.0")
+	require.Equal(t, int64(14), op.Get("0").Int())
+	require.Equal(t, int64(1005000), op.Get("1.asset_to_issue.amount").Int())
+	require.Equal(t, "1.2.17", op.Get("1.issue_to_account").String())
+	_, err = api.Sign(testPriHex, testChainId, unsigned)
+	require.Nil(t, err)
+
+	txs, err := api.Deserialize(unsigned)
+	require.Nil(t, err)
+	require.Equal(t, types.OpAssetIssue, txs[0].OpType)
+	require.Equal(t, "1.2.17", txs[0].Outputs[0].Address)
+	require.Equal(t, uint64(1005000), txs[0].Outputs[0].Value)
+
+	//the issued amount is minted, only the receiver is credited
+	gxcFee := types.NetAmount{Value: -1000, TokenCode: "GXC", TokenIdentifier: "1.3.1", TokenDecimal: 5}
+	tx := assetTx(t, restClient, unsigned)
+	api.SetDirection(tx, "init0")
+	require.Equal(t, []types.NetAmount{{Value: 1005000, TokenCode: "LOY", TokenIdentifier: "1.3.20", TokenDecimal: 4}}, tx.NetAmounts)
+	tx = assetTx(t, restClient, unsigned)
+	api.SetDirection(tx, testAccountName)
+	require.Equal(t, []types.NetAmount{gxcFee}, tx.NetAmounts)
+
+	//500000 LOY are left to issue
+	_, err = restClient.BuildAssetIssue(testAccountName, "init0", "500001 LOY", nil)
+	require.NotNil(t, err)
+	_, err = restClient.BuildAssetIssue("init0", testAccountName, "1 LOY", nil)
+	require.NotNil(t, err)
+
+	unsigned, err = restClient.BuildAssetReserve("init0", "10 LOY")
+	require.Nil(t, err)
+	require.Equal(t, int64(15), gjson.Get(unsigned, "operations.0.0").Int())
+	require.Equal(t, int64(100000), gjson.Get(unsigned, "operations.0.1.amount_to_reserve.amount").Int())
+	_, err = api.Sign(testPriHex, testChainId, unsigned)
+	require.Nil(t, err)
+
+	//the reserved amount is burned, only the payer is debited
+	tx = assetTx(t, restClient, unsigned)
+	api.SetDirection(tx, "init0")
+	require.Equal(t, []types.NetAmount{{Value: -100000, TokenCode: "LOY", TokenIdentifier: "1.3.20", TokenDecimal: 4}, gxcFee}, tx.NetAmounts)
+}
+
+//the tx of the operation of the unsigned transaction
+func assetTx(t *testing.T, restClient *api.RestClient, unsigned string) *types.Tx {
+	stx, err := api.ParseTransaction(unsigned)
+	require.Nil(t, err)
+	txs, err := restClient.TransactionToTx(stx.Transaction, "", nil, -1)
+	require.Nil(t, err)
+	require.Equal(t, 1, len(txs))
+	return txs[0]
+}
+
+func Test_BuildAssetFundFeePool(t *testing.T) {
+	restClient := newAssetNode().Client()
+
+	unsigned, err := restClient.BuildAssetFundFeePool(testAccountName, "LOY", "50 GXC")
+	require.Nil(t, err)
+	op := gjson.Get(unsigned, "operations.0")
+	require.Equal(t, int64(16), op.Get("0").Int())
+	require.Equal(t, "1.3.20", op.Get("1.asset_id").String())
+	require.Equal(t, int64(5000000), op.Get("1.amount").Int())
+	_, err = api.Sign(testPriHex, testChainId, unsigned)
+	require.Nil(t, err)
+
+	_, err = restClient.BuildAssetFundFeePool(testAccountName, "LOY", "50 LOY")
+	require.NotNil(t, err)
+
+	txs, err := restClient.TxsForAddress(testAccountName, "", 10)
+	require.Nil(t, err)
+	require.Equal(t, 1, len(txs))
+	require.Equal(t, types.OpAssetFundFeePool, txs[0].OpType)
+	require.Equal(t, "1.3.20", txs[0].AssetChange.AssetId)
+	require.Equal(t, uint64(5000000), txs[0].AssetChange.FeePoolAmount)
+	//the core asset paid into the fee pool is debited
+	require.Equal(t, []types.NetAmount{{Value: -5001000, TokenCode: "GXC", TokenIdentifier: "1.3.1", TokenDecimal: 5}}, txs[0].NetAmounts)
+}
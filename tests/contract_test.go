@@ -0,0 +1,215 @@
+package tests
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"gxclient-adapter/api"
+	"gxclient-adapter/types"
+	"gxclient-go/rpc"
+	"strings"
+	"testing"
+)
+
+const testTokenAbi = `{"version":"gxc::abi/1.0","types":[{"new_type_name":"account_name","type":"string"}],
+	"structs":[{"name":"transfer","base":"","fields":[{"name":"to","type":"account_name"},{"name":"amount","type":"contract_asset"},{"name":"memo","type":"string?"}]},
+		{"name":"deposit","base":"","fields":[]},
+		{"name":"vote","base":"","fields":[{"name":"delta","type":"varint32"}]},
+		{"name":"account","base":"","fields":[{"name":"id","type":"uint64"},{"name":"owner","type":"name"},{"name":"balances","type":"uint64[]"},{"name":"frozen","type":"bool"}]}],
+	"actions":[{"name":"transfer","type":"transfer","payable":false},{"name":"deposit","type":"deposit","payable":true},{"name":"vote","type":"vote","payable":false}],
+	"tables":[{"name":"account","index_type":"i64","key_names":["id"],"key_types":["uint64"],"type":"account"}],
+	"error_messages":[],"abi_extensions":[]}`
+
+//contract call of cli-wallet-test to the token contract 1.2.50
+const testContractCallTx = `{"ref_block_num":14710,"ref_block_prefix":3383196508,"expiration":"2020-03-19T04:18:42",
+	"operations":[[75,{"fee":{"amount":2000,"asset_id":"1.3.1"},"account":"1.2.4015","contract_id":"1.2.50","method_name":"transfer",
+	"data":"05696e6974306400000000000000010000000000000000","extensions":[]}]],"signatures":[]}`
+
+func newContractNode() *fakeNode {
+	node := newFakeNode()
+	node.SetAccount("token", map[string]string{"id": "1.2.50", "name": "token"})
+	node.Handle("get_objects", func(args gjson.Result) (interface{}, error) {
+		switch id := args.Get("0.0").String(); id {
+		case "1.2.50":
+			return []json.RawMessage{json.RawMessage(`{"id":"1.2.50","name":"token","code":"0061736d","abi":` + testTokenAbi + `}`)}, nil
+		case "1.2.51":
+			return nil, &rpc.RPCError{Code: 1, Message: "database error"}
+		case "1.2.52":
+			return []json.RawMessage{}, nil
+		default:
+			return []json.RawMessage{json.RawMessage(`{"id":"` + id + `","name":"cli-wallet-test","code":"","abi":{"version":"","actions":[]}}`)}, nil
+		}
+	})
+	node.Handle("get_required_fees", func(args gjson.Result) (interface{}, error) {
+		return []map[string]interface{}{{"amount": 2000, "asset_id": "1.3.1"}}, nil
+	})
+	node.Handle("get_block", func(args gjson.Result) (interface{}, error) {
+		return testBlock(testContractCallTx), nil
+	})
+	//rows 1 to 250, the odd ones serialized
+	node.Handle("get_table_rows_ex", func(args gjson.Result) (interface{}, error) {
+		lower, upper, limit := args.Get("2.lower_bound").Uint(), args.Get("2.upper_bound").Uint(), args.Get("2.limit").Uint()
+		if upper > 250 {
+			upper = 250
+		}
+		if lower < 1 {
+			lower = 1
+		}
+		rows := []interface{}{}
+		id := lower
+		for ; id <= upper && uint64(len(rows)) < limit; id++ {
+			if id%2 == 0 {
+				rows = append(rows, map[string]interface{}{"id": id, "owner": "alice", "balances": []uint64{id * 10}, "frozen": false})
+				continue
+			}
+			row := make([]byte, 26)
+			binary.LittleEndian.PutUint64(row, id)
+			copy(row[8:], []byte{0, 0, 0, 0, 0, 0x85, 0x5c, 0x34})
+			row[16] = 1
+			binary.LittleEndian.PutUint64(row[17:], id*10)
+			rows = append(rows, hex.EncodeToString(row))
+		}
+		return map[string]interface{}{"rows": rows, "more": id <= upper}, nil
+	})
+	return node
+}
+
+func Test_BuildContractCall(t *testing.T) {
+	restClient := newContractNode().Client()
+
+	unsigned, err := restClient.BuildContractCall(testAccountName, "token", "transfer",
+		map[string]interface{}{"to": "init0", "amount": map[string]interface{}{"amount": 100, "asset_id": 1}}, "")
+	require.Nil(t, err)
+	op := gjson.Get(unsigned, "operations.0")
+	require.Equal(t, int64(75), op.Get("0").Int())
+	require.Equal(t, "1.2.50", op.Get("1.contract_id").String())
+	require.Equal(t, "transfer", op.Get("1.method_name").String())
+	require.Equal(t, "05696e6974306400000000000000010000000000000000", op.Get("1.data").String())
+	require.Equal(t, int64(2000), op.Get("1.fee.amount").Int())
+	require.False(t, op.Get("1.amount").Exists())
+
+	//the unknown operation of gxclient-go is serialized for signing
+	_, err = api.Sign(testPriHex, testChainId, unsigned)
+	require.Nil(t, err)
+
+	txs, err := api.Deserialize(unsigned)
+	require.Nil(t, err)
+	require.Equal(t, types.OpCallContract, txs[0].OpType)
+	require.Equal(t, "transfer", txs[0].Contract.Method)
+	require.Equal(t, "1.2.50", txs[0].Contract.ContractId)
+
+	//json arguments and an amount sent to a payable method
+	unsigned, err = restClient.BuildContractCall(testAccountName, "token", "deposit", `{}`, "1.5 GXC")
+	require.Nil(t, err)
+	require.Equal(t, int64(150000), gjson.Get(unsigned, "operations.0.1.amount.amount").Int())
+
+	_, err = restClient.BuildContractCall(testAccountName, "token", "transfer", `{"to":"init0","amount":{"amount":100,"asset_id":1}}`, "1 GXC")
+	require.NotNil(t, err)
+	_, err = restClient.BuildContractCall(testAccountName, "token", "deposit", nil, "1.000001 GXC")
+	require.NotNil(t, err)
+	_, err = restClient.BuildContractCall(testAccountName, "token", "transfer", `{"to":"init0"}`, "")
+	require.NotNil(t, err)
+	_, err = restClient.BuildContractCall(testAccountName, "token", "withdraw", nil, "")
+	require.NotNil(t, err)
+	_, err = restClient.BuildContractCall(testAccountName, testAccountName, "transfer", nil, "")
+	require.NotNil(t, err)
+	//a failed or empty lookup of the contract is an error
+	_, err = restClient.BuildContractCall(testAccountName, "1.2.51", "transfer", nil, "")
+	require.NotNil(t, err)
+	_, err = restClient.BuildContractCall(testAccountName, "1.2.52", "transfer", nil, "")
+	require.NotNil(t, err)
+}
+
+func Test_ContractCallToTx(t *testing.T) {
+	txs, err := newContractNode().Client().GetBlockTxs(100)
+	require.Nil(t, err)
+	require.Equal(t, 1, len(txs))
+	tx := txs[0]
+	require.Equal(t, types.OpCallContract, tx.OpType)
+	require.Equal(t, "cli-wallet-test", tx.Inputs[0].Address)
+	require.Equal(t, "token", tx.Outputs[0].Address)
+	require.Equal(t, uint64(2000), tx.Fee.Value)
+	require.Equal(t, "token", tx.Contract.Contract)
+	require.Equal(t, "transfer", tx.Contract.Method)
+	require.JSONEq(t, `{"to":"init0","amount":{"amount":100,"asset_id":1},"memo":null}`, string(tx.Contract.Args))
+	require.Equal(t, types.TxStatusIrreversible, tx.Status)
+
+	//a contract that can not be read leaves the call undecoded
+	node := newContractNode()
+	node.Handle("get_block", func(args gjson.Result) (interface{}, error) {
+		return testBlock(strings.Replace(testContractCallTx, `"1.2.50"`, `"1.2.51"`, 1)), nil
+	})
+	txs, err = node.Client().GetBlockTxs(100)
+	require.Nil(t, err)
+	require.Equal(t, 1, len(txs))
+	tx = txs[0]
+	require.Equal(t, types.OpCallContract, tx.OpType)
+	require.Equal(t, "1.2.51", tx.Contract.ContractId)
+	require.Equal(t, "transfer", tx.Contract.Method)
+	require.Equal(t, "05696e6974306400000000000000010000000000000000", tx.Contract.Data)
+	require.Empty(t, tx.Contract.Args)
+	require.Contains(t, tx.Extra["contract_error"], "database error")
+}
+
+func Test_ContractVarint(t *testing.T) {
+	node := newContractNode()
+	restClient := node.Client()
+	for delta, data := range map[int64]string{0: "00", 1: "02", -1: "01", 64: "8001", -65: "8101", 2147483647: "feffffff0f", -2147483648: "ffffffff0f"} {
+		unsigned, err := restClient.BuildContractCall(testAccountName, "token", "vote", map[string]interface{}{"delta": delta}, "")
+		require.Nil(t, err)
+		require.Equal(t, data, gjson.Get(unsigned, "operations.0.1.data").String(), delta)
+
+		node.Handle("get_block", func(args gjson.Result) (interface{}, error) {
+			return testBlock(unsigned), nil
+		})
+		txs, err := restClient.GetBlockTxs(100)
+		require.Nil(t, err)
+		require.Equal(t, delta, gjson.GetBytes(txs[0].Contract.Args, "delta").Int(), delta)
+	}
+	_, err := restClient.BuildContractCall(testAccountName, "token", "vote", map[string]interface{}{"delta": 2147483648}, "")
+	require.NotNil(t, err)
+}
+
+type testAccountRow struct {
+	Id       uint64   `json:"id"`
+	Owner    string   `json:"owner"`
+	Balances []uint64 `json:"balances"`
+	Frozen   bool     `json:"frozen"`
+}
+
+func Test_GetContractTable(t *testing.T) {
+	node := newContractNode()
+	restClient := node.Client()
+
+	//all the rows, page by page
+	table, err := restClient.GetContractTable("token", "account", 0, 0, 0)
+	require.Nil(t, err)
+	require.Equal(t, 250, len(table.Rows))
+	require.False(t, table.More)
+	require.Equal(t, 3, node.Calls("get_table_rows_ex"))
+	var rows []testAccountRow
+	require.Nil(t, table.Decode(&rows))
+	require.Equal(t, testAccountRow{Id: 1, Owner: "alice", Balances: []uint64{10}, Frozen: false}, rows[0])
+	require.Equal(t, testAccountRow{Id: 250, Owner: "alice", Balances: []uint64{2500}, Frozen: false}, rows[249])
+
+	//limited, the next page starts after the last row
+	table, err = restClient.GetContractTable("token", "account", 10, 200, 150)
+	require.Nil(t, err)
+	require.Equal(t, 150, len(table.Rows))
+	require.True(t, table.More)
+	require.Equal(t, uint64(160), table.NextLowerBound)
+	var maps []map[string]interface{}
+	require.Nil(t, table.Decode(&maps))
+	require.Equal(t, json.Number("10"), maps[0]["id"])
+	require.Equal(t, json.Number("159"), maps[149]["id"])
+
+	table, err = restClient.GetContractTable("token", "account", 160, 200, 150)
+	require.Nil(t, err)
+	require.Equal(t, 41, len(table.Rows))
+	require.False(t, table.More)
+
+	_, err = restClient.GetContractTable("token", "accounts", 0, 0, 0)
+	require.NotNil(t, err)
+}
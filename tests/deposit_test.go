@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"gxclient-adapter/api"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testNoMemoTx = "{\"ref_block_num\":14710,\"ref_block_prefix\":3383196508,\"expiration\":\"2020-03-19T04:18:42\",\"operations\":[[0,{\"from\":\"1.2.4015\",\"to\":\"1.2.17\",\"amount\":{\"amount\":100000,\"asset_id\":\"1.3.1\"},\"fee\":{\"amount\":1000,\"asset_id\":\"1.3.1\"},\"extensions\":[]}]],\"signatures\":[]}"
+
+func testBlock(txs ...string) json.RawMessage {
+	ids := make([]string, len(txs))
+	for i := range txs {
+		ids[i] = "\"" + strings.Repeat(string(rune('a'+i)), 40) + "\""
+	}
+	return json.RawMessage("{\"previous\":\"0101813b00000000000000000000000000000000\",\"timestamp\":\"2020-03-19T04:10:00\",\"witness\":\"1.6.1\",\"transactions\":[" +
+		strings.Join(txs, ",") + "],\"transaction_ids\":[" + strings.Join(ids, ",") + "]}")
+}
+
+func Test_DepositRouter(t *testing.T) {
+	node := newFakeNode()
+	node.Handle("get_block", func(args gjson.Result) (interface{}, error) {
+		return testBlock(testMemoTx, testNoMemoTx), nil
+	})
+	restClient := node.Client()
+	require.Nil(t, restClient.SetMemoKeys(testMemoPriHex))
+
+	router, err := restClient.NewDepositRouter([]string{"init0"}, `^transfer (\w+)$`)
+	require.Nil(t, err)
+	require.Nil(t, router.RouteBlock(100))
+	require.Equal(t, uint32(101), router.NextBlock())
+
+	deposit := <-router.Deposits
+	require.Equal(t, "init0", deposit.Account)
+	require.Equal(t, "memo", deposit.Tag)
+	require.Equal(t, int64(100), deposit.Tx.BlockNumber)
+
+	unattributed := <-router.Unattributed
+	require.Equal(t, api.ReasonNoMemo, unattributed.Reason)
+
+	//outgoing transfers of other accounts are ignored
+	other, err := restClient.NewDepositRouter([]string{"cli-wallet-test"}, "")
+	require.Nil(t, err)
+	require.Nil(t, other.RouteBlock(100))
+	require.Equal(t, 0, len(other.Deposits)+len(other.Unattributed))
+}
+
+func Test_DepositRouterStop(t *testing.T) {
+	node := newFakeNode()
+	node.Handle("get_block", func(args gjson.Result) (interface{}, error) {
+		return testBlock(testMemoTx, testNoMemoTx), nil
+	})
+	restClient := node.Client()
+	require.Nil(t, restClient.SetMemoKeys(testMemoPriHex))
+	router, err := restClient.NewDepositRouter([]string{"init0"}, `^transfer (\w+)$`)
+	require.Nil(t, err)
+
+	//nobody reads the deposits
+	router.Deposits = make(chan *api.Deposit)
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- router.Run(100, stop)
+	}()
+	waitCalls(t, node, "get_block", 1)
+	require.Equal(t, uint32(100), router.NextBlock())
+	close(stop)
+	select {
+	case err := <-done:
+		require.Nil(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Run blocked on a full channel")
+	}
+	require.Equal(t, uint32(100), router.NextBlock())
+}
+
+func Test_ParseTag(t *testing.T) {
+	router, err := newFakeNode().Client().NewDepositRouter([]string{"init0"}, "")
+	require.Nil(t, err)
+	require.Equal(t, "10086", router.ParseTag(" 10086\n"))
+	require.Equal(t, "", router.ParseTag("two words"))
+
+	router, err = newFakeNode().Client().NewDepositRouter([]string{"init0"}, `^uid-\d+$`)
+	require.Nil(t, err)
+	require.Equal(t, "uid-42", router.ParseTag("uid-42"))
+	require.Equal(t, "", router.ParseTag("uid-x"))
+}
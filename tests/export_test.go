@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"gxclient-adapter/api"
+	"strings"
+	"testing"
+	"time"
+)
+
+var exportBase = time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)
+
+//150 operations of cli-wallet-test, one per hour in block 1000+i, every third is not a transfer
+func newExportNode() *fakeNode {
+	node := newHistoryNode()
+	node.Handle("get_account_history", func(args gjson.Result) (interface{}, error) {
+		start, limit := args.Get("3").String(), int(args.Get("2").Int())
+		from := 150
+		if start != "1.11.0" {
+			fmt.Sscanf(start, "1.11.%d", &from)
+		}
+		var ophs []json.RawMessage
+		for i := from; i >= 1 && len(ophs) < limit; i-- {
+			op := `[0,{"fee":{"amount":1000,"asset_id":"1.3.1"},"from":"1.2.17","to":"1.2.4015","amount":{"amount":100000,"asset_id":"1.3.1"},"extensions":[]}]`
+			if i%2 == 1 {
+				op = strings.Replace(strings.Replace(op, `"1.2.17"`, `"x"`, 1), `"1.2.4015"`, `"1.2.17"`, 1)
+				op = strings.Replace(op, `"x"`, `"1.2.4015"`, 1)
+			}
+			if i%3 == 0 {
+				op = `[3,{"fee":{"amount":1000,"asset_id":"1.3.1"}}]`
+			}
+			ophs = append(ophs, json.RawMessage(fmt.Sprintf(`{"id":"1.11.%d","block_num":%d,"trx_in_block":0,"op_in_trx":0,"virtual_op":1,"result":[0,{}],"op":%s}`, i, 1000+i, op)))
+		}
+		return ophs, nil
+	})
+	node.Handle("get_dynamic_global_properties", func(args gjson.Result) (interface{}, error) {
+		return json.RawMessage(`{"time":"2020-03-08T00:00:00","head_block_number":2000,"last_irreversible_block_num":1900}`), nil
+	})
+	node.Handle("get_block_header", func(args gjson.Result) (interface{}, error) {
+		at := exportBase.Add(time.Duration(args.Get("0").Int()-1000) * time.Hour)
+		return map[string]string{"previous": "", "timestamp": at.Format("2006-01-02T15:04:05"), "witness": "1.6.1"}, nil
+	})
+	return node
+}
+
+func Test_ExportHistory(t *testing.T) {
+	node := newExportNode()
+	restClient := node.Client()
+
+	var b bytes.Buffer
+	count, err := restClient.ExportHistory(&b, testAccountName, time.Time{}, time.Time{}, api.FormatCSV)
+	require.Nil(t, err)
+	require.Equal(t, 100, count)
+	rows, err := csv.NewReader(&b).ReadAll()
+	require.Nil(t, err)
+	require.Equal(t, 101, len(rows))
+	require.Equal(t, []string{"time", "block_no", "history_id", "direction", "counterparty", "amount", "asset", "fee", "fee_asset", "memo", "status"}, rows[0])
+	require.Equal(t, []string{"2020-03-07T05:00:00", "1149", "1.11.149", "outgoing", "init0", "1.00000", "GXC", "0.01000", "GXC", "", "irreversible"}, rows[1])
+	require.Equal(t, []string{"2020-03-07T04:00:00", "1148", "1.11.148", "incoming", "init0", "1.00000", "GXC", "", "", "", "irreversible"}, rows[2])
+	require.Equal(t, "1.11.1", rows[100][2])
+	require.Equal(t, 2, node.Calls("get_account_history"))
+
+	b.Reset()
+	from, to := exportBase.Add(10*time.Hour), exportBase.Add(20*time.Hour)
+	count, err = restClient.ExportHistory(&b, testAccountName, from, to, api.FormatJSONL)
+	require.Nil(t, err)
+	require.Equal(t, 7, count)
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	require.Equal(t, 7, len(lines))
+	var record api.ExportRecord
+	require.Nil(t, json.Unmarshal([]byte(lines[0]), &record))
+	require.Equal(t, "1.11.19", record.HistoryID)
+	require.Equal(t, "2020-03-01T19:00:00", record.Time)
+	require.Nil(t, json.Unmarshal([]byte(lines[6]), &record))
+	require.Equal(t, "1.11.10", record.HistoryID)
+	require.Equal(t, 4, node.Calls("get_account_history"))
+
+	//the first page reaches from, older pages are not fetched
+	count, err = restClient.ExportHistory(&b, testAccountName, exportBase.Add(120*time.Hour), time.Time{}, api.FormatJSONL)
+	require.Nil(t, err)
+	require.Equal(t, 20, count)
+	require.Equal(t, 5, node.Calls("get_account_history"))
+
+	_, err = restClient.ExportHistory(&b, testAccountName, time.Time{}, time.Time{}, "xml")
+	require.NotNil(t, err)
+}
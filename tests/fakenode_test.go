@@ -0,0 +1,196 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/tidwall/gjson"
+	"gxclient-adapter/api"
+	"gxclient-go/rpc"
+	"sync"
+)
+
+//fakeNode answers rpc calls offline with canned handlers
+type fakeNode struct {
+	mu        sync.Mutex
+	handlers  map[string]func(args gjson.Result) (interface{}, error)
+	calls     map[string]int
+	callbacks map[string]func(raw json.RawMessage)
+	//callbacks registered by SetCallback by id, numbered from 1 like the websocket transport
+	callbackIds map[uint64]func(raw json.RawMessage)
+	//answers of get_account_by_name by name
+	accounts map[string]interface{}
+	closed   bool
+}
+
+func newFakeNode() *fakeNode {
+	node := &fakeNode{
+		handlers:    map[string]func(args gjson.Result) (interface{}, error){},
+		calls:       map[string]int{},
+		callbacks:   map[string]func(raw json.RawMessage){},
+		callbackIds: map[uint64]func(raw json.RawMessage){},
+		accounts:    map[string]interface{}{},
+	}
+	accounts := map[string]string{
+		"1.2.4015": "cli-wallet-test",
+		"1.2.17":   "init0",
+		"1.2.0":    "committee-account",
+	}
+	for id, name := range accounts {
+		node.accounts[name] = map[string]string{"id": id, "name": name}
+	}
+	node.Handle("get_account_by_name", func(args gjson.Result) (interface{}, error) {
+		node.mu.Lock()
+		defer node.mu.Unlock()
+		return node.accounts[args.Get("0").String()], nil
+	})
+	node.Handle("get_required_fees", func(args gjson.Result) (interface{}, error) {
+		return []map[string]interface{}{{"amount": 1000, "asset_id": "1.3.1"}}, nil
+	})
+	node.Handle("get_block", func(args gjson.Result) (interface{}, error) {
+		return testBlock(testNoMemoTx), nil
+	})
+	node.Handle("lookup_asset_symbols", func(args gjson.Result) (interface{}, error) {
+		var assets []interface{}
+		for _, symbol := range args.Get("0").Array() {
+			switch symbol.String() {
+			case "GXC", "1.3.1":
+				assets = append(assets, json.RawMessage(`{"id":"1.3.1","symbol":"GXC","precision":5,"issuer":"1.2.0","dynamic_asset_data_id":"2.3.1",
+					"options":{"max_supply":"10000000000000","issuer_permissions":0,"flags":0,"core_exchange_rate":{"base":{"amount":1,"asset_id":"1.3.1"},
+					"quote":{"amount":1,"asset_id":"1.3.1"}},"description":""}}`))
+			default:
+				assets = append(assets, nil)
+			}
+		}
+		return assets, nil
+	})
+	node.Handle("get_accounts", func(args gjson.Result) (interface{}, error) {
+		var result []interface{}
+		for _, id := range args.Get("0").Array() {
+			result = append(result, map[string]string{"id": id.String(), "name": accounts[id.String()]})
+		}
+		return result, nil
+	})
+	node.Handle("get_objects", func(args gjson.Result) (interface{}, error) {
+		if args.Get("0.0").String() == "2.3.1" {
+			return []json.RawMessage{json.RawMessage(`{"id":"2.3.1","current_supply":"9000000000000","accumulated_fees":0,"fee_pool":0}`)}, nil
+		}
+		return []interface{}{nil}, nil
+	})
+	//the subscriptions take the id of a registered callback, set_subscribe_callback
+	//also takes notify_remove_create
+	for method, params := range map[string]int{"set_block_applied_callback": 1, "set_pending_transaction_callback": 1, "set_subscribe_callback": 2} {
+		method, params := method, params
+		node.Handle(method, func(args gjson.Result) (interface{}, error) {
+			if len(args.Array()) != params {
+				return nil, &rpc.RPCError{Code: 1, Message: fmt.Sprintf("%s takes %d parameters", method, params)}
+			}
+			node.mu.Lock()
+			defer node.mu.Unlock()
+			callback := node.callbackIds[args.Get("0").Uint()]
+			if callback == nil {
+				return nil, &rpc.RPCError{Code: 1, Message: "unknown callback"}
+			}
+			node.callbacks[method] = callback
+			return nil, nil
+		})
+	}
+	node.Handle("get_dynamic_global_properties", func(args gjson.Result) (interface{}, error) {
+		return json.RawMessage(`{"time":"2020-03-19T04:20:00","head_block_number":120,"head_block_id":"00000078","last_irreversible_block_num":100}`), nil
+	})
+	node.Handle("get_block_header", func(args gjson.Result) (interface{}, error) {
+		return json.RawMessage(`{"previous":"0101813b00000000000000000000000000000000","timestamp":"2020-03-19T04:10:00","witness":"1.6.1"}`), nil
+	})
+	return node
+}
+
+func (node *fakeNode) Handle(method string, handler func(args gjson.Result) (interface{}, error)) {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	node.handlers[method] = handler
+}
+
+//answer get_account_by_name for name with account, e.g. with its authorities
+func (node *fakeNode) SetAccount(name string, account interface{}) {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	node.accounts[name] = account
+}
+
+func (node *fakeNode) Calls(method string) int {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	return node.calls[method]
+}
+
+func (node *fakeNode) Client() *api.RestClient {
+	return api.NewRestClientWithCaller(node, "database", "history", "network_broadcast")
+}
+
+func (node *fakeNode) Call(apiID rpc.APIID, method string, args []interface{}, reply interface{}) error {
+	node.mu.Lock()
+	handler := node.handlers[method]
+	node.calls[method]++
+	closed := node.closed
+	node.mu.Unlock()
+	if closed {
+		return rpc.ErrShutdown
+	}
+	if handler == nil {
+		return fmt.Errorf("method %s not found", method)
+	}
+	argsBytes, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+	result, err := handler(gjson.ParseBytes(argsBytes))
+	if err != nil {
+		return err
+	}
+	if reply == nil {
+		return nil
+	}
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(resultBytes, reply)
+}
+
+//register callback and call method with its id only, like the websocket transport
+func (node *fakeNode) SetCallback(apiID rpc.APIID, method string, callback func(raw json.RawMessage)) error {
+	node.mu.Lock()
+	id := uint64(len(node.callbackIds) + 1)
+	node.callbackIds[id] = callback
+	node.mu.Unlock()
+	return node.Call(apiID, method, []interface{}{id}, nil)
+}
+
+//send a notice to the callback registered by method
+func (node *fakeNode) Notify(method string, raw string) {
+	node.mu.Lock()
+	callback := node.callbacks[method]
+	node.mu.Unlock()
+	if callback != nil {
+		callback(json.RawMessage(raw))
+	}
+}
+
+//reopen the connection after Close
+func (node *fakeNode) Open() {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	node.closed = false
+	node.callbacks = map[string]func(raw json.RawMessage){}
+	node.callbackIds = map[uint64]func(raw json.RawMessage){}
+}
+
+func (node *fakeNode) Connect() error {
+	return nil
+}
+
+func (node *fakeNode) Close() error {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	node.closed = true
+	return nil
+}
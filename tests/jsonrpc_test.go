@@ -0,0 +1,72 @@
+package tests
+
+import (
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"gxclient-adapter/jsonrpc"
+	"testing"
+)
+
+func Test_JSONRPC(t *testing.T) {
+	node := newFakeNode()
+	node.Handle("get_dynamic_global_properties", func(args gjson.Result) (interface{}, error) {
+		return map[string]interface{}{"head_block_number": 120, "last_irreversible_block_num": 100}, nil
+	})
+	h := jsonrpc.NewHandler(node.Client())
+
+	resp := gjson.ParseBytes(h.Handle([]byte(`{"jsonrpc":"2.0","method":"getBlockCount","id":1}`)))
+	require.Equal(t, int64(100), resp.Get("result").Int())
+	require.Equal(t, int64(1), resp.Get("id").Int())
+	require.False(t, resp.Get("error").Exists())
+
+	resp = gjson.ParseBytes(h.Handle([]byte(`{"jsonrpc":"2.0","method":"nope","id":"a"}`)))
+	require.Equal(t, int64(jsonrpc.CodeMethodNotFound), resp.Get("error.code").Int())
+	require.Equal(t, "a", resp.Get("id").String())
+
+	resp = gjson.ParseBytes(h.Handle([]byte(`{"jsonrpc":"2.0","method"`)))
+	require.Equal(t, int64(jsonrpc.CodeParseError), resp.Get("error.code").Int())
+
+	//notifications have no response
+	require.Nil(t, h.Handle([]byte(`{"jsonrpc":"2.0","method":"getBlockCount"}`)))
+
+	batch := `[
+		{"jsonrpc":"2.0","method":"getTokenDetail","params":["GXC"],"id":1},
+		{"jsonrpc":"2.0","method":"decodeTransaction","params":{"tx":"not json"},"id":2},
+		{"jsonrpc":"2.0","method":"getBlockCount"},
+		{"foo":"bar"}
+	]`
+	resp = gjson.ParseBytes(h.Handle([]byte(batch)))
+	require.Equal(t, 3, len(resp.Array()))
+	require.Equal(t, "1.3.1", resp.Get("0.result.token_identifier").String())
+	require.Equal(t, int64(jsonrpc.CodeInvalidParams), resp.Get("1.error.code").Int())
+	require.Equal(t, int64(jsonrpc.CodeInvalidRequest), resp.Get("2.error.code").Int())
+
+	resp = gjson.ParseBytes(h.Handle([]byte(`[]`)))
+	require.Equal(t, int64(jsonrpc.CodeInvalidRequest), resp.Get("error.code").Int())
+
+	//a malformed transaction fails its request only
+	batch = `[
+		{"jsonrpc":"2.0","method":"getTransactionFee","params":{"tx":"not json"},"id":1},
+		{"jsonrpc":"2.0","method":"getTransactionFee","params":{"tx":"{\"operations\":[]}"},"id":2},
+		{"jsonrpc":"2.0","method":"getBlockCount","id":3}
+	]`
+	resp = gjson.ParseBytes(h.Handle([]byte(batch)))
+	require.Equal(t, 3, len(resp.Array()))
+	require.True(t, resp.Get("0.error").Exists())
+	require.True(t, resp.Get("1.error").Exists())
+	require.Equal(t, int64(100), resp.Get("2.result").Int())
+
+	//a panic is an internal error of its request
+	node.Handle("get_dynamic_global_properties", func(args gjson.Result) (interface{}, error) {
+		panic("broken node")
+	})
+	batch = `[
+		{"jsonrpc":"2.0","method":"getBlockCount","id":1},
+		{"jsonrpc":"2.0","method":"getTokenDetail","params":["GXC"],"id":2}
+	]`
+	resp = gjson.ParseBytes(h.Handle([]byte(batch)))
+	require.Equal(t, 2, len(resp.Array()))
+	require.Equal(t, int64(jsonrpc.CodeInternalError), resp.Get("0.error.code").Int())
+	require.Equal(t, int64(1), resp.Get("0.id").Int())
+	require.Equal(t, "1.3.1", resp.Get("1.result.token_identifier").String())
+}
@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/require"
+	"gxclient-adapter/api"
+	"strings"
+	"testing"
+)
+
+type captureLogger struct {
+	entries []string
+	fields  []map[string]interface{}
+}
+
+func (l *captureLogger) Log(level api.Level, msg string, fields ...api.Field) {
+	l.entries = append(l.entries, level.String()+" "+msg)
+	values := map[string]interface{}{}
+	for _, field := range fields {
+		values[field.Key] = field.Value
+	}
+	l.fields = append(l.fields, values)
+}
+
+func Test_RedactLogger(t *testing.T) {
+	capture := &captureLogger{}
+	logger := api.RedactLogger(capture)
+	logger.Log(api.LevelInfo, "keys", api.F("memo_pri_hex", testMemoPriHex), api.F("key", testPriHex), api.F("wif", "x"),
+		api.F("value", testPri), api.F("pub_key", testPub), api.F("account", testAccountName), api.F("price", "0.5"),
+		api.F("priority", 1), api.F("memo_key", testPub), api.F("priv_key", "x"), api.F("PrivateKey", "x"), api.F("seed", "x"))
+	fields := capture.fields[0]
+	require.Equal(t, "[REDACTED]", fields["memo_pri_hex"])
+	require.Equal(t, "[REDACTED]", fields["key"])
+	require.Equal(t, "[REDACTED]", fields["wif"])
+	require.Equal(t, "[REDACTED]", fields["value"])
+	require.Equal(t, testPub, fields["pub_key"])
+	require.Equal(t, testAccountName, fields["account"])
+	require.Equal(t, "0.5", fields["price"])
+	require.Equal(t, 1, fields["priority"])
+	require.Equal(t, testPub, fields["memo_key"])
+	require.Equal(t, "[REDACTED]", fields["priv_key"])
+	require.Equal(t, "[REDACTED]", fields["PrivateKey"])
+	require.Equal(t, "[REDACTED]", fields["seed"])
+}
+
+func Test_TextLogger(t *testing.T) {
+	var b bytes.Buffer
+	logger := api.NewTextLogger(&b, api.LevelInfo)
+	logger.Log(api.LevelDebug, "hidden")
+	logger.Log(api.LevelWarn, "shown", api.F("method", "Test"))
+	require.Equal(t, 1, strings.Count(b.String(), "\n"))
+	require.Contains(t, b.String(), `level=warn msg="shown" method="Test"`)
+}
+
+func Test_ClientLogger(t *testing.T) {
+	node := newFakeNode()
+	restClient := node.Client()
+	router, err := restClient.NewDepositRouter([]string{"init0"}, "")
+	require.Nil(t, err)
+	//no logger, no output
+	require.Nil(t, router.RouteBlock(1))
+
+	capture := &captureLogger{}
+	restClient.SetLogger(capture)
+	require.Nil(t, router.RouteBlock(1))
+	require.Equal(t, []string{"warn unattributed deposit"}, capture.entries)
+	require.Equal(t, "init0", capture.fields[0]["account"])
+}
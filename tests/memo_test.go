@@ -0,0 +1,27 @@
+package tests
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	gxcTypes "gxclient-go/types"
+	"testing"
+)
+
+const testMemoTx = "{\"ref_block_num\":14710,\"ref_block_prefix\":3383196508,\"expiration\":\"2020-03-19T04:18:42\",\"operations\":[[0,{\"from\":\"1.2.4015\",\"to\":\"1.2.17\",\"amount\":{\"amount\":318000,\"asset_id\":\"1.3.1\"},\"fee\":{\"amount\":1210,\"asset_id\":\"1.3.1\"},\"memo\":{\"from\":\"GXC58owosbFrudGVp8VCuMvDWpenx7AZSLwxEtAVqjWeqZ4YVLLWb\",\"to\":\"GXC8AoHzhXhMRV9AFTihMAcQPNXKFEZCeYNYomdcc7vh8Gzp7b7xP\",\"nonce\":3768974234669558428,\"message\":\"78ac2144776911f195c934c000f3036c374015f991d3d4b928c418f98ab2926e\"},\"extensions\":[]}]],\"signatures\":null}"
+
+func Test_MemoKeys(t *testing.T) {
+	restClient := newFakeNode().Client()
+	var stx *gxcTypes.SignedTransaction
+	require.Nil(t, json.Unmarshal([]byte(testMemoTx), &stx))
+
+	txs, err := restClient.TransactionToTx(stx.Transaction, "", nil, -1)
+	require.Nil(t, err)
+	require.Equal(t, "", txs[0].Extra["memo"])
+
+	require.Nil(t, restClient.SetMemoKeys(testMemoPriHex))
+	txs, err = restClient.TransactionToTx(stx.Transaction, "", nil, -1)
+	require.Nil(t, err)
+	require.Equal(t, "transfer memo", txs[0].Extra["memo"])
+	require.Equal(t, "cli-wallet-test", txs[0].Inputs[0].Address)
+	require.Equal(t, "init0", txs[0].Outputs[0].Address)
+}
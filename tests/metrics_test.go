@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"gxclient-adapter/api"
+	"gxclient-adapter/metrics"
+	"gxclient-go/api/database"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_Metrics(t *testing.T) {
+	registry := metrics.NewRegistry()
+	requests := metrics.NewCounter(registry, "test_requests_total", "Requests.", "code")
+	inFlight := metrics.NewGauge(registry, "test_in_flight", "In flight.")
+	latency := metrics.NewHistogram(registry, "test_latency_seconds", "Latency.", []float64{0.1, 1})
+
+	requests.Inc("200")
+	requests.Add(2, "500")
+	inFlight.Set(3)
+	latency.Observe(0.05)
+	latency.Observe(0.5)
+
+	var b bytes.Buffer
+	registry.Write(&b)
+	text := b.String()
+	require.Contains(t, text, "# TYPE test_requests_total counter\n")
+	require.Contains(t, text, "test_requests_total{code=\"500\"} 2\n")
+	require.Contains(t, text, "test_in_flight 3\n")
+	require.Contains(t, text, "test_latency_seconds_bucket{le=\"0.1\"} 1\n")
+	require.Contains(t, text, "test_latency_seconds_bucket{le=\"+Inf\"} 2\n")
+	require.Contains(t, text, "test_latency_seconds_count 2\n")
+	require.Panics(t, func() { metrics.NewGauge(registry, "test_in_flight", "again") })
+}
+
+func Test_InstrumentCaller(t *testing.T) {
+	node := newFakeNode()
+	node.Handle("get_dynamic_global_properties", func(args gjson.Result) (interface{}, error) {
+		return map[string]interface{}{"time": "2020-03-19T04:10:00", "head_block_number": 120, "last_irreversible_block_num": 100}, nil
+	})
+	node.Handle("get_chain_id", func(args gjson.Result) (interface{}, error) {
+		return nil, errors.New("boom")
+	})
+	restClient := &api.RestClient{Database: database.NewAPI("database", metrics.InstrumentCaller(node))}
+
+	calls := metrics.RPCDuration.Count("get_dynamic_global_properties")
+	errs := metrics.RPCErrors.Value("get_chain_id")
+	_, err := restClient.GetBlockCount()
+	require.Nil(t, err)
+	_, err = restClient.Database.GetChainId()
+	require.NotNil(t, err)
+
+	require.Equal(t, calls+1, metrics.RPCDuration.Count("get_dynamic_global_properties"))
+	require.Equal(t, errs+1, metrics.RPCErrors.Value("get_chain_id"))
+	require.Equal(t, float64(0), metrics.RPCInFlight.Value())
+	require.Equal(t, float64(20), metrics.LIBBlockLag.Value())
+
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	require.True(t, strings.Contains(rec.Body.String(), "gxadapter_rpc_errors_total{method=\"get_chain_id\"}"))
+}
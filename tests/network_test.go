@@ -0,0 +1,131 @@
+package tests
+
+import (
+	"encoding/hex"
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"gxclient-adapter/api"
+	gxcTypes "gxclient-go/types"
+	"testing"
+)
+
+func Test_NetworkPrefix(t *testing.T) {
+	network := &api.NetworkConfig{Prefix: "TEST"}
+	pubBase58, err := network.PubKeyHexToBase58(testPubHexCom)
+	require.Nil(t, err)
+	require.Equal(t, "TEST"+testPub[3:], pubBase58)
+
+	pubHex, err := network.PubKeyBase58ToHex(pubBase58)
+	require.Nil(t, err)
+	require.Equal(t, testPubHexCom, pubHex)
+
+	_, err = network.PubKeyBase58ToHex(testPub)
+	require.Equal(t, gxcTypes.ErrPublicKeyChainPrefixMismatch, err)
+}
+
+func Test_UncompressedPubKey(t *testing.T) {
+	compressed, _ := hex.DecodeString(testPubHexCom)
+	pub, err := btcec.ParsePubKey(compressed, btcec.S256())
+	require.Nil(t, err)
+	pubBase58, err := api.PubKeyHexToBase58(hex.EncodeToString(pub.SerializeUncompressed()))
+	require.Nil(t, err)
+	require.Equal(t, testPub, pubBase58)
+}
+
+func Test_ConvertInvalidLength(t *testing.T) {
+	for _, pubHex := range []string{"", "02", testPubHexCom[:64], testPubHexCom + "00"} {
+		_, err := api.PubKeyHexToBase58(pubHex)
+		require.NotNil(t, err, pubHex)
+	}
+	//valid length but not on the curve
+	_, err := api.PubKeyHexToBase58("02" + testPriHex[:62] + "ff")
+	require.NotNil(t, err)
+
+	for _, pubBase58 := range []string{"", "GX", "GXC", "GXC1", testPub[:len(testPub)-1], testPub + "1"} {
+		_, err := api.PubKeyBase58ToHex(pubBase58)
+		require.NotNil(t, err, pubBase58)
+	}
+
+	for _, priHex := range []string{"", "8bf4", testPriHex + "00"} {
+		_, err := api.PriKeyHexToWif(priHex)
+		require.NotNil(t, err, priHex)
+	}
+	_, err = api.NewKeySigner("8bf4")
+	require.NotNil(t, err)
+}
+
+func Test_NetworkConfig(t *testing.T) {
+	node := newFakeNode()
+	node.Handle("get_chain_id", func(args gjson.Result) (interface{}, error) {
+		return api.TestNet.ChainId, nil
+	})
+	var feeArgs gjson.Result
+	node.Handle("get_required_fees", func(args gjson.Result) (interface{}, error) {
+		feeArgs = args
+		return []map[string]interface{}{{"amount": 1000, "asset_id": "1.3.0"}}, nil
+	})
+	restClient := node.Client()
+	require.Equal(t, api.MainNet, restClient.Network())
+
+	err := restClient.SetNetwork(api.MainNet)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "chain id mismatch")
+
+	require.Nil(t, restClient.SetNetwork(nil))
+	require.Equal(t, api.TestNet, restClient.Network())
+
+	network, err := api.NetworkByChainId(api.MainNet.ChainId)
+	require.Nil(t, err)
+	require.Equal(t, api.MainNet, network)
+	_, err = api.NetworkByChainId("00")
+	require.NotNil(t, err)
+	_, err = api.NetworkByName("devnet")
+	require.NotNil(t, err)
+
+	custom := api.CustomNetwork(api.TestNet.ChainId, "TEST")
+	custom.CoreAssetId = "1.3.0"
+	custom.FeeFromAccountId = "1.2.10"
+	require.Nil(t, restClient.SetNetwork(custom))
+	fee, err := restClient.GetRequiredFee(nil)
+	require.Nil(t, err)
+	require.Equal(t, uint64(1000), fee)
+	require.Equal(t, "1.3.0", feeArgs.Get("1").String())
+	require.Equal(t, "1.2.10", feeArgs.Get("0.0.1.from").String())
+
+	signature, err := custom.Sign(testPriHex, testUnsignTx)
+	require.Nil(t, err)
+	expected, err := api.Sign(testPriHex, api.TestNet.ChainId, testUnsignTx)
+	require.Nil(t, err)
+	require.Equal(t, expected, signature)
+
+	//the network of an unknown chain is not detected
+	other := newFakeNode()
+	other.Handle("get_chain_id", func(args gjson.Result) (interface{}, error) {
+		return "00", nil
+	})
+	otherClient := other.Client()
+	require.NotNil(t, otherClient.SetNetwork(nil))
+	require.Nil(t, otherClient.SetNetwork(api.CustomNetwork("00", "TEST")))
+	require.Equal(t, "custom", otherClient.Network().Name)
+
+	//the network of the command flags
+	selected, err := api.SelectNetwork("", "00", "", "CORE", "1.3.0")
+	require.Nil(t, err)
+	require.Nil(t, otherClient.SetNetwork(selected))
+	require.Equal(t, "GXC", otherClient.Network().Prefix)
+	require.Equal(t, "CORE", otherClient.Network().CoreAsset)
+	require.Equal(t, "1.3.0", otherClient.Network().CoreAssetId)
+	selected, err = api.SelectNetwork("testnet", "", "", "", "")
+	require.Nil(t, err)
+	require.Equal(t, api.TestNet, selected)
+	selected, err = api.SelectNetwork("", "", "", "", "")
+	require.Nil(t, err)
+	require.Nil(t, selected)
+	_, err = api.SelectNetwork("mainnet", "00", "", "", "")
+	require.NotNil(t, err)
+	_, err = api.SelectNetwork("", "", "TEST", "", "")
+	require.NotNil(t, err)
+	_, err = api.SelectNetwork("", "00", "", "", "1.3")
+	require.NotNil(t, err)
+}
@@ -0,0 +1,179 @@
+package tests
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"gxclient-adapter/api"
+	"gxclient-adapter/types"
+	"testing"
+	"time"
+)
+
+//treasury needs 2 of init0, cli-wallet-test and testOtherPub
+const testTreasury = `{"id":"1.2.30","name":"treasury",
+	"owner":{"weight_threshold":1,"account_auths":[],"key_auths":[["` + testPub + `",1]],"address_auths":[]},
+	"active":{"weight_threshold":2,"account_auths":[["1.2.17",1],["1.2.4015",1]],"key_auths":[["` + testOtherPub + `",1]],"address_auths":[]}}`
+
+//a transfer of 1 GXC from treasury to init0 and an unlock of treasury
+const testProposedTx = `{"ref_block_num":100,"ref_block_prefix":1001,"expiration":"2020-03-19T04:30:00","operations":[
+	[0,{"fee":{"amount":1000,"asset_id":"1.3.1"},"from":"1.2.30","to":"1.2.17","amount":{"amount":100000,"asset_id":"1.3.1"},"extensions":[]}],
+	[72,{"fee":{"amount":1000,"asset_id":"1.3.1"},"account":"1.2.30","lock_id":"1.27.3","extensions":[]}]],"extensions":[]}`
+
+func testProposal(keyApprovals ...string) json.RawMessage {
+	keys, _ := json.Marshal(append([]string{}, keyApprovals...))
+	return json.RawMessage(`{"id":"1.10.3","expiration_time":"2020-03-20T04:20:00","review_period_time":"2020-03-20T03:20:00",
+		"proposed_transaction":` + testProposedTx + `,"required_active_approvals":["1.2.30"],"available_active_approvals":["1.2.4015"],
+		"required_owner_approvals":[],"available_owner_approvals":[],"available_key_approvals":` + string(keys) + `,"proposer":"1.2.4015"}`)
+}
+
+func newProposalNode(keyApprovals ...string) *fakeNode {
+	node := newFakeNode()
+	node.SetAccount("treasury", json.RawMessage(testTreasury))
+	accounts := node.handlers["get_accounts"]
+	node.Handle("get_accounts", func(args gjson.Result) (interface{}, error) {
+		result, err := accounts(args)
+		if err != nil {
+			return nil, err
+		}
+		list := result.([]interface{})
+		for i, id := range args.Get("0").Array() {
+			if id.String() == "1.2.30" {
+				list[i] = json.RawMessage(testTreasury)
+			}
+		}
+		return list, nil
+	})
+	objects := node.handlers["get_objects"]
+	node.Handle("get_objects", func(args gjson.Result) (interface{}, error) {
+		if args.Get("0.0").String() == "1.10.3" {
+			return []json.RawMessage{testProposal(keyApprovals...)}, nil
+		}
+		return objects(args)
+	})
+	node.Handle("get_proposed_transactions", func(args gjson.Result) (interface{}, error) {
+		if args.Get("0").String() == "1.2.30" {
+			return []json.RawMessage{testProposal(keyApprovals...)}, nil
+		}
+		return []json.RawMessage{}, nil
+	})
+	return node
+}
+
+func Test_BuildProposal(t *testing.T) {
+	restClient := newProposalNode().Client()
+
+	unsigned, err := restClient.BuildProposal(testAccountName, testProposedTx, 24*time.Hour, 0)
+	require.Nil(t, err)
+	op := gjson.Get(unsigned, "operations.0")
+	require.Equal(t, int64(22), op.Get("0").Int())
+	require.Equal(t, "1.2.4015", op.Get("1.fee_paying_account").String())
+	require.Equal(t, "2020-03-20T04:20:00", op.Get("1.expiration_time").String())
+	require.Equal(t, int64(0), op.Get("1.proposed_ops.0.op.0").Int())
+	require.Equal(t, int64(72), op.Get("1.proposed_ops.1.op.0").Int())
+	require.Equal(t, "1.27.3", op.Get("1.proposed_ops.1.op.1.lock_id").String())
+	require.False(t, op.Get("1.review_period_seconds").Exists())
+	_, err = api.Sign(testPriHex, testChainId, unsigned)
+	require.Nil(t, err)
+
+	txs, err := api.Deserialize(unsigned)
+	require.Nil(t, err)
+	require.Equal(t, types.OpProposalCreate, txs[0].OpType)
+	require.Equal(t, "2020-03-20T04:20:00", txs[0].Proposal.Expiration)
+	proposed := txs[0].Proposal.Operations
+	require.Equal(t, 2, len(proposed))
+	require.Equal(t, "1.2.30", proposed[0].Inputs[0].Address)
+	require.Equal(t, "1.2.17", proposed[0].Outputs[0].Address)
+	require.Equal(t, uint64(100000), proposed[0].Outputs[0].Value)
+	require.Equal(t, types.OpBalanceUnlock, proposed[1].OpType)
+	require.Equal(t, 1, proposed[1].OpIndex)
+
+	unsigned, err = restClient.BuildProposal(testAccountName, testProposedTx, 24*time.Hour, time.Hour)
+	require.Nil(t, err)
+	require.Equal(t, int64(3600), gjson.Get(unsigned, "operations.0.1.review_period_seconds").Int())
+	_, err = api.Sign(testPriHex, testChainId, unsigned)
+	require.Nil(t, err)
+
+	_, err = restClient.BuildProposal(testAccountName, testProposedTx, time.Hour, time.Hour)
+	require.NotNil(t, err)
+	_, err = restClient.BuildProposal(testAccountName, `{"ref_block_num":100,"operations":[],"extensions":[]}`, time.Hour, 0)
+	require.NotNil(t, err)
+	//call_order_update is unknown to the adapter
+	_, err = restClient.BuildProposal(testAccountName, `{"ref_block_num":100,"operations":[[3,{"fee":{"amount":1000,"asset_id":"1.3.1"}}]],"extensions":[]}`, time.Hour, 0)
+	require.NotNil(t, err)
+}
+
+func Test_ProposalsForAddress(t *testing.T) {
+	proposals, err := newProposalNode().Client().ProposalsForAddress("treasury")
+	require.Nil(t, err)
+	require.Equal(t, 1, len(proposals))
+	proposal := proposals[0]
+	require.Equal(t, "1.10.3", proposal.Id)
+	require.Equal(t, "cli-wallet-test", proposal.Proposer)
+	require.Equal(t, uint32(3600), proposal.ReviewPeriodSeconds)
+	require.Equal(t, 2, len(proposal.Operations))
+	require.Equal(t, "treasury", proposal.Operations[0].Inputs[0].Address)
+	require.Equal(t, "init0", proposal.Operations[0].Outputs[0].Address)
+	require.Equal(t, "GXC", proposal.Operations[0].Outputs[0].TokenCode)
+	require.Equal(t, []string{"1.2.4015"}, proposal.Approvals.Active)
+
+	require.Equal(t, 1, len(proposal.Progress))
+	require.Equal(t, "treasury", proposal.Progress[0].Account)
+	require.Equal(t, "active", proposal.Progress[0].Authority)
+	require.Equal(t, uint32(2), proposal.Progress[0].Threshold)
+	require.Equal(t, uint32(1), proposal.Progress[0].Weight)
+	require.False(t, proposal.Approved)
+
+	//the key approval reaches the threshold
+	proposals, err = newProposalNode(testOtherPub).Client().ProposalsForAddress("treasury")
+	require.Nil(t, err)
+	require.Equal(t, uint32(2), proposals[0].Progress[0].Weight)
+	require.True(t, proposals[0].Approved)
+
+	proposals, err = newProposalNode().Client().ProposalsForAddress("init0")
+	require.Nil(t, err)
+	require.Equal(t, 0, len(proposals))
+}
+
+func Test_BuildProposalApproval(t *testing.T) {
+	restClient := newProposalNode().Client()
+
+	unsigned, err := restClient.BuildProposalApproval("init0", "1.10.3", nil, true)
+	require.Nil(t, err)
+	op := gjson.Get(unsigned, "operations.0")
+	require.Equal(t, int64(23), op.Get("0").Int())
+	require.Equal(t, "1.10.3", op.Get("1.proposal").String())
+	require.Equal(t, "1.2.17", op.Get("1.active_approvals_to_add.0").String())
+	require.Equal(t, 0, len(op.Get("1.active_approvals_to_remove").Array()))
+	_, err = api.Sign(testPriHex, testChainId, unsigned)
+	require.Nil(t, err)
+
+	txs, err := api.Deserialize(unsigned)
+	require.Nil(t, err)
+	require.Equal(t, types.OpProposalUpdate, txs[0].OpType)
+	require.Equal(t, "1.10.3", txs[0].Proposal.Id)
+	require.Equal(t, []string{"1.2.17"}, txs[0].Proposal.ApprovalsToAdd.Active)
+	require.Nil(t, txs[0].Proposal.ApprovalsToRemove)
+
+	unsigned, err = restClient.BuildProposalApproval(testAccountName, "1.10.3", []string{testOtherPub}, true)
+	require.Nil(t, err)
+	require.Equal(t, testOtherPub, gjson.Get(unsigned, "operations.0.1.key_approvals_to_add.0").String())
+	require.Equal(t, 0, len(gjson.Get(unsigned, "operations.0.1.active_approvals_to_add").Array()))
+	_, err = api.Sign(testPriHex, testChainId, unsigned)
+	require.Nil(t, err)
+
+	unsigned, err = restClient.BuildProposalApproval(testAccountName, "1.10.3", nil, false)
+	require.Nil(t, err)
+	require.Equal(t, "1.2.4015", gjson.Get(unsigned, "operations.0.1.active_approvals_to_remove.0").String())
+
+	_, err = restClient.BuildProposalApproval(testAccountName, "1.10.3", nil, true)
+	require.NotNil(t, err)
+	_, err = restClient.BuildProposalApproval("init0", "1.10.3", nil, false)
+	require.NotNil(t, err)
+	_, err = restClient.BuildProposalApproval("init0", "1.10.3", []string{testOtherPub, testOtherPub}, true)
+	require.NotNil(t, err)
+	_, err = restClient.BuildProposalApproval("init0", "1.10.9", nil, true)
+	require.NotNil(t, err)
+	_, err = restClient.BuildProposalApproval("init0", "1.2.30", nil, true)
+	require.NotNil(t, err)
+}
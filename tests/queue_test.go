@@ -0,0 +1,237 @@
+package tests
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"gxclient-adapter/api"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+//set the head block time and the last irreversible block of node
+func setHead(node *withdrawalNode, time string, lib int) {
+	node.Handle("get_dynamic_global_properties", func(args gjson.Result) (interface{}, error) {
+		return map[string]interface{}{"time": time, "head_block_number": lib + 20, "head_block_id": "00000078", "last_irreversible_block_num": lib}, nil
+	})
+}
+
+//set the time of every block header of node, the last irreversible block among them
+func setBlockTime(node *withdrawalNode, time string) {
+	node.Handle("get_block_header", func(args gjson.Result) (interface{}, error) {
+		return map[string]interface{}{"previous": "0101813b00000000000000000000000000000000", "timestamp": time, "witness": "1.6.1"}, nil
+	})
+}
+
+func newTransferQueue(t *testing.T, node *withdrawalNode, dir string) *api.TransferQueue {
+	restClient := node.Client()
+	require.Nil(t, restClient.SetNetwork(api.TestNet))
+	signer, err := api.NewKeySigner(testPriHex)
+	require.Nil(t, err)
+	queue, err := restClient.NewTransferQueue(signer, testPub, filepath.Join(dir, "transfers.json"))
+	require.Nil(t, err)
+	return queue
+}
+
+func Test_TransferQueue(t *testing.T) {
+	dir, err := ioutil.TempDir("", "queue")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	node := newWithdrawalNode()
+	queue := newTransferQueue(t, node, dir)
+	transfer, err := queue.Enqueue("t1", testAccountName, "init0", "GXC", 100000, nil)
+	require.Nil(t, err)
+	require.Equal(t, api.TransferQueued, transfer.State)
+	_, err = queue.Enqueue("t1", testAccountName, "init0", "GXC", 200000, nil)
+	require.NotNil(t, err)
+	_, err = queue.Enqueue("t2", testAccountName, "init0", "GXC", 0, nil)
+	require.NotNil(t, err)
+
+	require.Nil(t, queue.Process())
+	transfer = queue.Transfer("t1")
+	require.Equal(t, api.TransferBroadcast, transfer.State)
+	require.Equal(t, int64(110), transfer.BlockNumber)
+	require.Equal(t, 40, len(transfer.TxId))
+	expected, err := api.Sign(testPriHex, testChainId, transfer.UnsignedTx)
+	require.Nil(t, err)
+	require.Equal(t, expected, transfer.Signature)
+
+	//a restart keeps the transfer, it is not sent again
+	queue = newTransferQueue(t, node, dir)
+	require.Nil(t, queue.Process())
+	require.Equal(t, api.TransferBroadcast, queue.Transfer("t1").State)
+	again, err := queue.Enqueue("t1", testAccountName, "init0", "GXC", 100000, nil)
+	require.Nil(t, err)
+	require.Equal(t, transfer.TxId, again.TxId)
+
+	setHead(node, "2020-03-19T04:21:00", 110)
+	require.Nil(t, queue.Process())
+	require.Equal(t, api.TransferConfirmed, queue.Transfer("t1").State)
+	require.Equal(t, 1, node.Calls("broadcast_transaction_synchronous"))
+	require.Equal(t, 1, node.Calls("get_required_fees"))
+	require.Equal(t, 1, len(queue.Transfers()))
+}
+
+func Test_TransferQueueRetry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "queue")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	//the node got the transaction but the response was lost
+	node := newWithdrawalNode()
+	node.dropBroadcast = true
+	queue := newTransferQueue(t, node, dir)
+	_, err = queue.Enqueue("t1", testAccountName, "init0", "GXC", 100000, nil)
+	require.Nil(t, err)
+	require.NotNil(t, queue.Process())
+	require.Equal(t, api.TransferSigned, queue.Transfer("t1").State)
+
+	node.dropBroadcast = false
+	queue = newTransferQueue(t, node, dir)
+	require.Nil(t, queue.Process())
+	require.Equal(t, api.TransferBroadcast, queue.Transfer("t1").State)
+	require.Equal(t, 1, node.Calls("broadcast_transaction_synchronous"))
+}
+
+func Test_TransferQueueExpired(t *testing.T) {
+	dir, err := ioutil.TempDir("", "queue")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	//the node accepts the transaction but it is never in a block
+	node := newWithdrawalNode()
+	node.lost = true
+	queue := newTransferQueue(t, node, dir)
+	_, err = queue.Enqueue("t1", testAccountName, "init0", "GXC", 100000, nil)
+	require.Nil(t, err)
+	require.Nil(t, queue.Process())
+	expired := queue.Transfer("t1")
+	require.Equal(t, api.TransferBroadcast, expired.State)
+	require.Equal(t, "2020-03-19T04:30:00", expired.Expiration)
+	require.Nil(t, queue.Process())
+	require.Equal(t, 1, node.Calls("broadcast_transaction_synchronous"))
+
+	//the head block time passes the expiration, the transaction may still be in a reversible block
+	node.lost = false
+	setHead(node, "2020-03-19T04:30:00", 100)
+	require.Nil(t, queue.Process())
+	require.Equal(t, expired.TxId, queue.Transfer("t1").TxId)
+	require.Equal(t, 1, node.Calls("broadcast_transaction_synchronous"))
+
+	//built again once the last irreversible block passes the expiration
+	setBlockTime(node, "2020-03-19T04:30:00")
+	require.Nil(t, queue.Process())
+	transfer := queue.Transfer("t1")
+	require.Equal(t, api.TransferBroadcast, transfer.State)
+	require.Equal(t, []string{expired.TxId}, transfer.ExpiredTxIds)
+	require.NotEqual(t, expired.TxId, transfer.TxId)
+	require.Equal(t, "2020-03-19T04:40:00", transfer.Expiration)
+	require.Equal(t, 2, node.Calls("broadcast_transaction_synchronous"))
+}
+
+func Test_TransferQueueFailed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "queue")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	node := newWithdrawalNode()
+	node.reject = true
+	queue := newTransferQueue(t, node, dir)
+	_, err = queue.Enqueue("t1", testAccountName, "init0", "GXC", 100000, nil)
+	require.Nil(t, err)
+	_, err = queue.Enqueue("t2", testAccountName, "nobody", "GXC", 100000, nil)
+	require.Nil(t, err)
+	for i := 0; i < 3; i++ {
+		require.NotNil(t, queue.Process())
+	}
+	require.Equal(t, api.TransferFailed, queue.Transfer("t2").State)
+	require.Equal(t, 3, queue.Transfer("t2").Attempts)
+
+	//the rejected transaction fails once it expired
+	transfer := queue.Transfer("t1")
+	require.Equal(t, api.TransferSigned, transfer.State)
+	require.Equal(t, "insufficient balance", transfer.Error)
+	require.Nil(t, queue.Process())
+	require.Equal(t, 3, node.Calls("broadcast_transaction_synchronous"))
+	setHead(node, "2020-03-19T04:30:00", 100)
+	require.Nil(t, queue.Process())
+	require.Equal(t, api.TransferSigned, queue.Transfer("t1").State)
+	setBlockTime(node, "2020-03-19T04:30:00")
+	require.Nil(t, queue.Process())
+	require.Equal(t, api.TransferFailed, queue.Transfer("t1").State)
+	require.Equal(t, 3, node.Calls("broadcast_transaction_synchronous"))
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "transfers.json"))
+	require.Nil(t, err)
+	var saved []*api.Transfer
+	require.Nil(t, json.Unmarshal(data, &saved))
+	require.Equal(t, 2, len(saved))
+	require.Equal(t, api.TransferFailed, saved[0].State)
+}
+
+func Test_TransferQueueLookupRace(t *testing.T) {
+	dir, err := ioutil.TempDir("", "queue")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	node := newWithdrawalNode()
+	node.lost = true
+	queue := newTransferQueue(t, node, dir)
+	_, err = queue.Enqueue("t1", testAccountName, "init0", "GXC", 100000, nil)
+	require.Nil(t, err)
+	require.Nil(t, queue.Process())
+	sent := queue.Transfer("t1")
+	require.Equal(t, api.TransferBroadcast, sent.State)
+
+	//the lookup misses and the chain passes the expiration right after,
+	//the transaction may have been included meanwhile
+	node.Handle("get_block_header", func(args gjson.Result) (interface{}, error) {
+		timestamp := "2020-03-19T04:10:00"
+		if args.Get("0").Int() >= 200 {
+			timestamp = "2020-03-19T04:30:00"
+		}
+		return map[string]interface{}{"previous": "0101813b00000000000000000000000000000000", "timestamp": timestamp, "witness": "1.6.1"}, nil
+	})
+	lookup := node.handlers["get_transaction_by_txid"]
+	node.Handle("get_transaction_by_txid", func(args gjson.Result) (interface{}, error) {
+		setHead(node, "2020-03-19T04:30:00", 200)
+		return lookup(args)
+	})
+	require.Nil(t, queue.Process())
+	transfer := queue.Transfer("t1")
+	require.Equal(t, api.TransferBroadcast, transfer.State)
+	require.Equal(t, sent.TxId, transfer.TxId)
+	require.Equal(t, 0, len(transfer.ExpiredTxIds))
+}
+
+func Test_TransferQueueExpiredReply(t *testing.T) {
+	dir, err := ioutil.TempDir("", "queue")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	//the node replies expired while the last irreversible block is before the expiration
+	node := newWithdrawalNode()
+	node.expired = true
+	queue := newTransferQueue(t, node, dir)
+	_, err = queue.Enqueue("t1", testAccountName, "init0", "GXC", 100000, nil)
+	require.Nil(t, err)
+	require.Nil(t, queue.Process())
+	sent := queue.Transfer("t1")
+	require.Equal(t, api.TransferBroadcast, sent.State)
+	require.Equal(t, 0, len(sent.ExpiredTxIds))
+	require.Nil(t, queue.Process())
+	require.Equal(t, sent.TxId, queue.Transfer("t1").TxId)
+	require.Equal(t, 1, node.Calls("broadcast_transaction_synchronous"))
+
+	//built again once the last irreversible block passes the expiration
+	node.expired = false
+	setHead(node, "2020-03-19T04:30:00", 100)
+	setBlockTime(node, "2020-03-19T04:30:00")
+	require.Nil(t, queue.Process())
+	transfer := queue.Transfer("t1")
+	require.Equal(t, []string{sent.TxId}, transfer.ExpiredTxIds)
+	require.Equal(t, 2, node.Calls("broadcast_transaction_synchronous"))
+}
@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"gxclient-adapter/rest"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func postJSON(t *testing.T, url string, body interface{}) (int, gjson.Result) {
+	b, err := json.Marshal(body)
+	require.Nil(t, err)
+	resp, err := http.Post(url, "application/json", bytes.NewReader(b))
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	require.Nil(t, err)
+	return resp.StatusCode, gjson.ParseBytes(respBody)
+}
+
+func Test_RestHandler(t *testing.T) {
+	node := newFakeNode()
+	node.Handle("get_dynamic_global_properties", func(args gjson.Result) (interface{}, error) {
+		return map[string]interface{}{"head_block_number": 120, "last_irreversible_block_num": 100}, nil
+	})
+	server := httptest.NewServer(rest.NewHandler(node.Client()))
+	defer server.Close()
+
+	status, resp := postJSON(t, server.URL+"/convert_key", map[string]string{"type": "pri_hex_to_wif", "key": testPriHex})
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, testPri, resp.Get("result").String())
+
+	status, resp = postJSON(t, server.URL+"/convert_key", map[string]string{"type": "unknown", "key": testPriHex})
+	require.Equal(t, http.StatusBadRequest, status)
+	require.True(t, resp.Get("error").Exists())
+
+	status, resp = postJSON(t, server.URL+"/deserialize", map[string]string{"tx": testUnsignTx})
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, int64(318000), resp.Get("result.0.inputs.0.value").Int())
+
+	status, resp = postJSON(t, server.URL+"/token", map[string]string{"token": "GXC"})
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "1.3.1", resp.Get("result.token_identifier").String())
+
+	status, resp = postJSON(t, server.URL+"/block_count", nil)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, int64(100), resp.Get("result").Int())
+
+	//a malformed transaction is a bad request, an empty one an error
+	status, resp = postJSON(t, server.URL+"/transaction_fee", map[string]string{"tx": "not json"})
+	require.Equal(t, http.StatusBadRequest, status)
+	require.True(t, resp.Get("error").Exists())
+	status, resp = postJSON(t, server.URL+"/transaction_fee", map[string]string{"tx": `{"operations":[]}`})
+	require.Equal(t, http.StatusInternalServerError, status)
+	require.True(t, resp.Get("error").Exists())
+
+	resp2, err := http.Get(server.URL + "/block_count")
+	require.Nil(t, err)
+	resp2.Body.Close()
+	require.Equal(t, http.StatusMethodNotAllowed, resp2.StatusCode)
+}
@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"gxclient-adapter/api"
+	"gxclient-adapter/types"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+//fakeChain serves get_block and the head/LIB of a chain that can fork
+type fakeChain struct {
+	mu     sync.Mutex
+	ids    map[int64]string
+	txs    map[int64][]string
+	head   int64
+	lib    int64
+	branch string
+}
+
+func newFakeChain(node *fakeNode) *fakeChain {
+	chain := &fakeChain{ids: map[int64]string{}, txs: map[int64][]string{}, branch: "a"}
+	node.Handle("get_block", func(args gjson.Result) (interface{}, error) {
+		chain.mu.Lock()
+		defer chain.mu.Unlock()
+		num := args.Get("0").Int()
+		if num > chain.head {
+			return nil, nil
+		}
+		var block map[string]interface{}
+		json.Unmarshal(testBlock(chain.txs[num]...), &block)
+		block["block_id"] = chain.ids[num]
+		block["previous"] = chain.ids[num-1]
+		return block, nil
+	})
+	node.Handle("get_dynamic_global_properties", func(args gjson.Result) (interface{}, error) {
+		chain.mu.Lock()
+		defer chain.mu.Unlock()
+		return map[string]interface{}{"time": "2020-03-19T04:20:00", "head_block_number": chain.head, "last_irreversible_block_num": chain.lib}, nil
+	})
+	return chain
+}
+
+//produce a block on the current branch
+func (chain *fakeChain) produce(txs ...string) {
+	chain.mu.Lock()
+	defer chain.mu.Unlock()
+	chain.head++
+	chain.ids[chain.head] = fmt.Sprintf("%08x%s", chain.head, strings.Repeat(chain.branch, 32))
+	chain.txs[chain.head] = txs
+}
+
+//switch to another branch forking after block_no
+func (chain *fakeChain) fork(block_no int64, branch string) {
+	chain.mu.Lock()
+	defer chain.mu.Unlock()
+	chain.head = block_no
+	chain.branch = branch
+}
+
+func nextEvent(t *testing.T, scanner *api.HeadScanner, eventType api.EventType, block_no uint32) *api.ScanEvent {
+	require.NotEqual(t, 0, len(scanner.Events))
+	event := <-scanner.Events
+	require.Equal(t, eventType, event.Type)
+	require.Equal(t, block_no, event.BlockNumber)
+	return event
+}
+
+func Test_HeadScanner(t *testing.T) {
+	node := newFakeNode()
+	chain := newFakeChain(node)
+	chain.produce()
+	chain.produce(testNoMemoTx)
+	chain.produce(testMemoTx)
+	chain.lib = 1
+
+	scanner := node.Client().NewHeadScanner()
+	scanner.SetNextBlock(1)
+	require.Nil(t, scanner.Scan())
+	require.Equal(t, uint32(4), scanner.NextBlock())
+	event := nextEvent(t, scanner, api.EventTx, 2)
+	require.Equal(t, types.TxStatusIncluded, event.Tx.Status)
+	require.Equal(t, uint32(2), event.Tx.Confirmations)
+	emitted := nextEvent(t, scanner, api.EventTx, 3)
+	require.Equal(t, 0, len(scanner.Events))
+
+	//block 3 is replaced by a block of another branch
+	chain.fork(2, "b")
+	chain.produce()
+	chain.produce(testNoMemoTx)
+	require.Nil(t, scanner.Scan())
+	event = nextEvent(t, scanner, api.EventRollback, 3)
+	require.Equal(t, emitted.Tx.TxHash, event.Tx.TxHash)
+	require.Equal(t, emitted.BlockID, event.BlockID)
+	require.Equal(t, types.TxStatusRolledBack, event.Tx.Status)
+	require.Equal(t, types.TxStatusIncluded, emitted.Tx.Status)
+	event = nextEvent(t, scanner, api.EventTx, 4)
+	require.Equal(t, 0, len(scanner.Events))
+	require.Equal(t, uint32(5), scanner.NextBlock())
+
+	//the tip is replaced without a new block on top
+	chain.fork(3, "c")
+	chain.produce(testMemoTx)
+	require.Nil(t, scanner.Scan())
+	nextEvent(t, scanner, api.EventRollback, 4)
+	nextEvent(t, scanner, api.EventTx, 4)
+	require.Equal(t, 0, len(scanner.Events))
+
+	chain.lib = 3
+	require.Nil(t, scanner.Scan())
+	event = nextEvent(t, scanner, api.EventIrreversible, 2)
+	require.Equal(t, types.TxStatusIrreversible, event.Tx.Status)
+	require.Equal(t, 0, len(scanner.Events))
+}
+
+func Test_HeadScannerStop(t *testing.T) {
+	node := newFakeNode()
+	chain := newFakeChain(node)
+	chain.produce(testNoMemoTx)
+	chain.produce(testMemoTx)
+
+	//nobody reads the events
+	scanner := node.Client().NewHeadScanner()
+	scanner.Events = make(chan *api.ScanEvent)
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- scanner.Run(1, stop)
+	}()
+	waitCalls(t, node, "get_block", 1)
+	require.Equal(t, uint32(1), scanner.NextBlock())
+	close(stop)
+	select {
+	case err := <-done:
+		require.Nil(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Run blocked on a full channel")
+	}
+	require.Equal(t, uint32(1), scanner.NextBlock())
+
+	//the block not done is scanned again
+	scanner.Events = make(chan *api.ScanEvent, 10)
+	require.Nil(t, scanner.Scan())
+	nextEvent(t, scanner, api.EventTx, 1)
+	nextEvent(t, scanner, api.EventTx, 2)
+	require.Equal(t, uint32(3), scanner.NextBlock())
+}
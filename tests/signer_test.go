@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"gxclient-adapter/api"
+	gxcTypes "gxclient-go/types"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	testChainId  = "c2af30ef9340ff81fd61654295e98a1ff04b23189748f86727d0b26b40bb0ff4"
+	testUnsignTx = "{\"ref_block_num\":14710,\"ref_block_prefix\":3383196508,\"expiration\":\"2020-03-19T04:18:42\",\"operations\":[[0,{\"from\":\"1.2.4015\",\"to\":\"1.2.17\",\"amount\":{\"amount\":318000,\"asset_id\":\"1.3.1\"},\"fee\":{\"amount\":1210,\"asset_id\":\"1.3.1\"},\"memo\":{\"from\":\"GXC58owosbFrudGVp8VCuMvDWpenx7AZSLwxEtAVqjWeqZ4YVLLWb\",\"to\":\"GXC8AoHzhXhMRV9AFTihMAcQPNXKFEZCeYNYomdcc7vh8Gzp7b7xP\",\"nonce\":13402076872543869991,\"message\":\"2a127ecb4ed849f5806ea2bdabbdc1ae24c7ae268f5759169c032f68628b6e3e\"},\"extensions\":[]}]],\"signatures\":null}"
+)
+
+func Test_SignMatchesWif(t *testing.T) {
+	var stx *gxcTypes.SignedTransaction
+	require.Nil(t, json.Unmarshal([]byte(testUnsignTx), &stx))
+	require.Nil(t, stx.Sign([]string{testPri}, testChainId))
+
+	signature, err := api.Sign(testPriHex, testChainId, testUnsignTx)
+	require.Nil(t, err)
+	require.Equal(t, stx.Signatures[0], signature)
+}
+
+func Test_KeySigner(t *testing.T) {
+	signer, err := api.NewKeySigner(testPriHex)
+	require.Nil(t, err)
+	require.Equal(t, []string{testPub}, signer.PubKeys())
+
+	byBase58, err := api.SignWith(signer, testPub, testChainId, testUnsignTx)
+	require.Nil(t, err)
+	byHex, err := api.SignWith(signer, testPubHexCom, testChainId, testUnsignTx)
+	require.Nil(t, err)
+	require.Equal(t, byBase58, byHex)
+
+	_, err = api.SignWith(signer, "GXC8AoHzhXhMRV9AFTihMAcQPNXKFEZCeYNYomdcc7vh8Gzp7b7xP", testChainId, testUnsignTx)
+	require.NotNil(t, err)
+
+	//the keys of a network with another prefix
+	network := api.CustomNetwork(testChainId, "TST")
+	custom, err := api.NewKeySignerWithNetwork(network, testPriHex)
+	require.Nil(t, err)
+	customPub, err := network.PubKeyHexToBase58(testPubHexCom)
+	require.Nil(t, err)
+	require.Equal(t, []string{customPub}, custom.PubKeys())
+	byCustom, err := api.SignWith(custom, customPub, testChainId, testUnsignTx)
+	require.Nil(t, err)
+	require.Equal(t, byBase58, byCustom)
+	byHex, err = api.SignWith(custom, testPubHexCom, testChainId, testUnsignTx)
+	require.Nil(t, err)
+	require.Equal(t, byBase58, byHex)
+	_, err = api.SignWith(custom, testPub, testChainId, testUnsignTx)
+	require.NotNil(t, err)
+}
+
+func Test_SocketSigner(t *testing.T) {
+	dir, err := ioutil.TempDir("", "signer")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	keySigner, err := api.NewKeySigner(testPriHex)
+	require.Nil(t, err)
+	path := filepath.Join(dir, "signer.sock")
+	l, err := net.Listen("unix", path)
+	require.Nil(t, err)
+	defer l.Close()
+	go api.ServeSigner(l, keySigner)
+
+	expected, err := api.SignWith(keySigner, testPub, testChainId, testUnsignTx)
+	require.Nil(t, err)
+	signature, err := api.SignWith(api.NewSocketSigner(path), testPub, testChainId, testUnsignTx)
+	require.Nil(t, err)
+	require.Equal(t, expected, signature)
+
+	_, err = api.SignWith(api.NewSocketSigner(path), "GXC8AoHzhXhMRV9AFTihMAcQPNXKFEZCeYNYomdcc7vh8Gzp7b7xP", testChainId, testUnsignTx)
+	require.NotNil(t, err)
+}
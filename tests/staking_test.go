@@ -0,0 +1,147 @@
+package tests
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"gxclient-adapter/api"
+	"gxclient-adapter/types"
+	"gxclient-go/rpc"
+	"testing"
+)
+
+//staking program 1 and lock program 1 in the chain parameters
+const testGlobalProperties = `{"id":"2.0.0","parameters":{"extensions":[
+	[11,{"params":[["1",{"weight":1,"staking_days":7}],["2",{"weight":2,"staking_days":30}]]}],
+	[9,{"params":[["1",{"lock_days":90,"interest_rate":500,"is_valid":true}],["2",{"lock_days":360,"interest_rate":1000,"is_valid":false}]]}]]}}`
+
+const testStakingHistory = `[{"id":"1.11.600","block_num":110,"trx_in_block":1,"op_in_trx":0,"virtual_op":1,"result":[0,{}],
+	"op":[80,{"fee":{"amount":1000,"asset_id":"1.3.1"},"owner":"1.2.4015","trust_node":"1.6.1","amount":{"amount":10000000,"asset_id":"1.3.1"},
+	"program_id":"1","weight":1,"staking_days":7,"extensions":[]}]},
+	{"id":"1.11.601","block_num":110,"trx_in_block":2,"op_in_trx":0,"virtual_op":2,"result":[0,{}],
+	"op":[72,{"fee":{"amount":1000,"asset_id":"1.3.1"},"account":"1.2.4015","lock_id":"1.27.3","extensions":[]}]}]`
+
+func newStakingNode() *fakeNode {
+	node := newFakeNode()
+	node.Handle("get_witness_by_account", func(args gjson.Result) (interface{}, error) {
+		if args.Get("0").String() == "1.2.17" {
+			return map[string]interface{}{"id": "1.6.1", "is_valid": true}, nil
+		}
+		return nil, nil
+	})
+	node.Handle("get_global_properties", func(args gjson.Result) (interface{}, error) {
+		return json.RawMessage(testGlobalProperties), nil
+	})
+	node.Handle("get_staking_objects", func(args gjson.Result) (interface{}, error) {
+		return json.RawMessage(`[{"id":"1.27.1","owner":"1.2.4015","trust_node":"1.6.1","amount":{"amount":10000000,"asset_id":"1.3.1"},
+			"create_date_time":"2020-03-01T00:00:00","program_id":"1","staking_days":7,"weight":1,"is_valid":true},
+			{"id":"1.27.2","owner":"1.2.4015","trust_node":"1.6.1","amount":{"amount":10000000,"asset_id":"1.3.1"},
+			"create_date_time":"2020-03-18T00:00:00","program_id":"2","staking_days":30,"weight":2,"is_valid":true}]`), nil
+	})
+	node.Handle("get_objects", func(args gjson.Result) (interface{}, error) {
+		switch args.Get("0.0").String() {
+		case "1.27.4":
+			return []json.RawMessage{json.RawMessage("null")}, nil
+		case "1.27.5":
+			return nil, &rpc.RPCError{Code: 1, Message: "database error"}
+		}
+		return []json.RawMessage{json.RawMessage(`{"id":"1.27.3","account":"1.2.4015","amount":{"amount":100000,"asset_id":"1.3.1"}}`)}, nil
+	})
+	node.Handle("get_account_history", func(args gjson.Result) (interface{}, error) {
+		return json.RawMessage(testStakingHistory), nil
+	})
+	return node
+}
+
+func Test_BuildStaking(t *testing.T) {
+	restClient := newStakingNode().Client()
+
+	unsigned, err := restClient.BuildStakingCreate(testAccountName, "init0", "1", "100 GXC")
+	require.Nil(t, err)
+	op := gjson.Get(unsigned, "operations.0")
+	require.Equal(t, int64(80), op.Get("0").Int())
+	require.Equal(t, "1.6.1", op.Get("1.trust_node").String())
+	require.Equal(t, int64(10000000), op.Get("1.amount.amount").Int())
+	require.Equal(t, int64(7), op.Get("1.staking_days").Int())
+	require.Equal(t, int64(1000), op.Get("1.fee.amount").Int())
+	_, err = api.Sign(testPriHex, testChainId, unsigned)
+	require.Nil(t, err)
+
+	_, err = restClient.BuildStakingCreate(testAccountName, "init0", "3", "100 GXC")
+	require.NotNil(t, err)
+	_, err = restClient.BuildStakingCreate(testAccountName, testAccountName, "1", "100 GXC")
+	require.NotNil(t, err)
+	_, err = restClient.BuildStakingCreate(testAccountName, "1.6.1", "1", "0 GXC")
+	require.NotNil(t, err)
+
+	unsigned, err = restClient.BuildStakingUpdate(testAccountName, "1.27.1", "1.6.2")
+	require.Nil(t, err)
+	require.Equal(t, int64(81), gjson.Get(unsigned, "operations.0.0").Int())
+	require.Equal(t, "1.6.2", gjson.Get(unsigned, "operations.0.1.trust_node").String())
+	_, err = restClient.BuildStakingUpdate(testAccountName, "1.27.9", "1.6.2")
+	require.NotNil(t, err)
+
+	//staking 1.27.2 is staked for 30 days
+	unsigned, err = restClient.BuildStakingClaim(testAccountName, "1.27.1")
+	require.Nil(t, err)
+	require.Equal(t, int64(82), gjson.Get(unsigned, "operations.0.0").Int())
+	_, err = restClient.BuildStakingClaim(testAccountName, "1.27.2")
+	require.NotNil(t, err)
+}
+
+func Test_BuildBalanceLock(t *testing.T) {
+	restClient := newStakingNode().Client()
+
+	programs, err := restClient.LockPrograms()
+	require.Nil(t, err)
+	require.Equal(t, 2, len(programs))
+	require.Equal(t, uint32(90), programs[0].LockDays)
+
+	unsigned, err := restClient.BuildBalanceLock(testAccountName, "1", "1 GXC", "savings")
+	require.Nil(t, err)
+	op := gjson.Get(unsigned, "operations.0")
+	require.Equal(t, int64(71), op.Get("0").Int())
+	require.Equal(t, "2020-03-19T04:20:00", op.Get("1.create_date_time").String())
+	require.Equal(t, int64(500), op.Get("1.interest_rate").Int())
+	_, err = api.Sign(testPriHex, testChainId, unsigned)
+	require.Nil(t, err)
+
+	txs, err := api.Deserialize(unsigned)
+	require.Nil(t, err)
+	require.Equal(t, types.OpBalanceLock, txs[0].OpType)
+	require.Equal(t, uint64(100000), txs[0].Inputs[0].Value)
+	require.Equal(t, uint32(90), txs[0].Staking.LockDays)
+	require.Equal(t, "savings", txs[0].Staking.Memo)
+
+	_, err = restClient.BuildBalanceLock(testAccountName, "2", "1 GXC", "")
+	require.NotNil(t, err)
+
+	unsigned, err = restClient.BuildBalanceUnlock(testAccountName, "1.27.3")
+	require.Nil(t, err)
+	require.Equal(t, int64(72), gjson.Get(unsigned, "operations.0.0").Int())
+	_, err = restClient.BuildBalanceUnlock("init0", "1.27.3")
+	require.NotNil(t, err)
+	_, err = restClient.BuildBalanceUnlock(testAccountName, "1.27.4")
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "not exist")
+	_, err = restClient.BuildBalanceUnlock(testAccountName, "1.27.5")
+	require.NotNil(t, err)
+}
+
+func Test_StakingHistory(t *testing.T) {
+	txs, err := newStakingNode().Client().TxsForAddress(testAccountName, "", 10)
+	require.Nil(t, err)
+	require.Equal(t, 2, len(txs))
+
+	require.Equal(t, types.OpStakingCreate, txs[0].OpType)
+	require.Equal(t, "cli-wallet-test", txs[0].Inputs[0].Address)
+	require.Equal(t, uint64(10000000), txs[0].Inputs[0].Value)
+	require.Equal(t, "1.6.1", txs[0].Staking.TrustNode)
+	require.Equal(t, "1.11.600", txs[0].HistoryID)
+	require.Equal(t, "2020-03-19T04:10:00", txs[0].TxAt)
+	require.Equal(t, 1, txs[0].TrxInBlock)
+
+	require.Equal(t, types.OpBalanceUnlock, txs[1].OpType)
+	require.Equal(t, "1.27.3", txs[1].Staking.Id)
+	require.Equal(t, uint64(1000), txs[1].Fee.Value)
+}
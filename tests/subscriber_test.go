@@ -0,0 +1,174 @@
+package tests
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"gxclient-adapter/types"
+	"gxclient-go/rpc"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func receiveTx(t *testing.T, txs chan *types.Tx) *types.Tx {
+	select {
+	case tx := <-txs:
+		return tx
+	case <-time.After(2 * time.Second):
+		t.Fatal("no tx received")
+		return nil
+	}
+}
+
+func waitCalls(t *testing.T, node *fakeNode, method string, calls int) {
+	for i := 0; i < 200 && node.Calls(method) < calls; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Equal(t, calls, node.Calls(method))
+}
+
+func Test_Subscriber(t *testing.T) {
+	var mu sync.Mutex
+	history := testHistory
+	dataNode := newHistoryNode()
+	dataNode.Handle("get_account_history", func(args gjson.Result) (interface{}, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return json.RawMessage(history), nil
+	})
+	restClient := dataNode.Client()
+
+	subNode := newFakeNode()
+	subNode.Handle("database", func(args gjson.Result) (interface{}, error) {
+		return 2, nil
+	})
+	subNode.Handle("get_full_accounts", func(args gjson.Result) (interface{}, error) {
+		return nil, nil
+	})
+
+	_, err := restClient.NewSubscriber("https://node1.gxb.io")
+	require.NotNil(t, err)
+	sub, err := restClient.NewSubscriber("wss://node1.gxb.io")
+	require.Nil(t, err)
+	sub.Dial = func() (rpc.CallCloser, error) {
+		subNode.Open()
+		return subNode, nil
+	}
+	sub.Interval = 20 * time.Millisecond
+	require.NotNil(t, sub.Run(nil))
+
+	sub.SubscribeBlocks()
+	sub.SubscribePending()
+	sub.SubscribeAccounts(testAccountName)
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() { done <- sub.Run(stop) }()
+	waitCalls(t, subNode, "get_full_accounts", 1)
+
+	subNode.Notify("set_block_applied_callback", `["0000006400000000000000000000000000000000"]`)
+	tx := receiveTx(t, sub.Blocks)
+	require.Equal(t, int64(100), tx.BlockNumber)
+	require.Equal(t, types.TxStatusIrreversible, tx.Status)
+
+	subNode.Notify("set_pending_transaction_callback", "["+testNoMemoTx+"]")
+	tx = receiveTx(t, sub.Pending)
+	require.Equal(t, types.TxStatusPending, tx.Status)
+	require.Equal(t, 40, len(tx.TxHash))
+	require.Equal(t, uint64(100000), tx.Outputs[0].Value)
+
+	//a new operation changes the account statistics
+	mu.Lock()
+	history = "[" + strings.Replace(testHistory[1:len(testHistory)-1], "1.11.500", "1.11.501", 1) + "," + testHistory[1:]
+	mu.Unlock()
+	subNode.Notify("set_subscribe_callback", `[[{"id":"2.6.4015","owner":"1.2.4015","most_recent_op":"2.9.3"}]]`)
+	tx = receiveTx(t, sub.Accounts)
+	require.Equal(t, "1.11.501", tx.HistoryID)
+
+	//subscribed again after the connection is lost, the missed blocks are scanned
+	subNode.Close()
+	waitCalls(t, subNode, "set_block_applied_callback", 2)
+	require.Equal(t, 4, subNode.Calls("set_subscribe_callback"))
+	subNode.Notify("set_block_applied_callback", `["0000006600000000000000000000000000000000"]`)
+	require.Equal(t, int64(101), receiveTx(t, sub.Blocks).BlockNumber)
+	require.Equal(t, int64(102), receiveTx(t, sub.Blocks).BlockNumber)
+	require.Equal(t, 0, len(sub.Accounts))
+
+	close(stop)
+	require.Nil(t, <-done)
+}
+
+//history of transfers 1.11.1 to 1.11.newest, paged like the node
+func pagedHistory(newest *uint64, mu *sync.Mutex) func(args gjson.Result) (interface{}, error) {
+	return func(args gjson.Result) (interface{}, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		instance := func(id string) uint64 {
+			n, _ := strconv.ParseUint(id[strings.LastIndex(id, ".")+1:], 10, 64)
+			return n
+		}
+		stop, limit, start := instance(args.Get("1").String()), int(args.Get("2").Int()), instance(args.Get("3").String())
+		if start == 0 || start > *newest {
+			start = *newest
+		}
+		ops := []json.RawMessage{}
+		for i := start; i > stop && len(ops) < limit; i-- {
+			ops = append(ops, json.RawMessage(strings.Replace(testHistory[1:len(testHistory)-1], "1.11.500", "1.11."+strconv.FormatUint(i, 10), 1)))
+		}
+		return ops, nil
+	}
+}
+
+func Test_SubscriberResync(t *testing.T) {
+	var mu sync.Mutex
+	newest := uint64(1)
+	dataNode := newHistoryNode()
+	dataNode.Handle("get_account_history", pagedHistory(&newest, &mu))
+	restClient := dataNode.Client()
+
+	subNode := newFakeNode()
+	subNode.Handle("database", func(args gjson.Result) (interface{}, error) {
+		return 2, nil
+	})
+	subNode.Handle("get_full_accounts", func(args gjson.Result) (interface{}, error) {
+		return nil, nil
+	})
+	sub, err := restClient.NewSubscriber("wss://node1.gxb.io")
+	require.Nil(t, err)
+	sub.Dial = func() (rpc.CallCloser, error) {
+		subNode.Open()
+		return subNode, nil
+	}
+	sub.Interval = 20 * time.Millisecond
+	sub.Accounts = make(chan *types.Tx)
+	sub.SubscribeAccounts(testAccountName)
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() { done <- sub.Run(stop) }()
+	waitCalls(t, subNode, "get_full_accounts", 1)
+
+	//more operations than a page while nobody listened
+	mu.Lock()
+	newest = 151
+	mu.Unlock()
+	subNode.Notify("set_subscribe_callback", `[[{"id":"2.6.4015","owner":"1.2.4015","most_recent_op":"2.9.151"}]]`)
+	for i := 2; i <= 151; i++ {
+		require.Equal(t, "1.11."+strconv.Itoa(i), receiveTx(t, sub.Accounts).HistoryID)
+	}
+
+	//a consumer that stopped reading does not block Run from stopping
+	mu.Lock()
+	newest = 152
+	mu.Unlock()
+	subNode.Notify("set_subscribe_callback", `[[{"id":"2.6.4015","owner":"1.2.4015","most_recent_op":"2.9.152"}]]`)
+	time.Sleep(50 * time.Millisecond)
+	close(stop)
+	select {
+	case err := <-done:
+		require.Nil(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Run blocked on a full channel")
+	}
+}
@@ -0,0 +1,176 @@
+package tests
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"gxclient-adapter/api"
+	"gxclient-adapter/types"
+	gxcTypes "gxclient-go/types"
+	"strings"
+	"testing"
+)
+
+const testHistory = `[{"id":"1.11.500","block_num":110,"trx_in_block":0,"op_in_trx":0,"virtual_op":1,"result":[0,{}],
+	"op":[0,{"fee":{"amount":1000,"asset_id":"1.3.1"},"from":"1.2.4015","to":"1.2.17","amount":{"amount":100000,"asset_id":"1.3.1"},"extensions":[]}]}]`
+
+func newHistoryNode() *fakeNode {
+	node := newFakeNode()
+	node.Handle("get_account_by_name", func(args gjson.Result) (interface{}, error) {
+		return map[string]string{"id": "1.2.4015", "name": args.Get("0").String()}, nil
+	})
+	node.Handle("get_account_history", func(args gjson.Result) (interface{}, error) {
+		return json.RawMessage(testHistory), nil
+	})
+	return node
+}
+
+func Test_TxStatusFields(t *testing.T) {
+	restClient := newHistoryNode().Client()
+
+	txs, err := restClient.GetBlockTxs(100)
+	require.Nil(t, err)
+	tx := txs[0]
+	require.Equal(t, types.TxStatusIrreversible, tx.Status)
+	require.Equal(t, int64(100), tx.BlockNumber)
+	require.Equal(t, uint32(21), tx.Confirmations)
+	require.Equal(t, "2020-03-19T04:10:00", tx.ConfirmedAt)
+	require.Equal(t, uint64(1000), tx.Fee.Value)
+	require.Equal(t, "GXC", tx.Fee.TokenCode)
+	require.Equal(t, 0, tx.TrxInBlock)
+	require.Equal(t, 0, tx.OpIndex)
+	require.Equal(t, "1000", tx.Extra["feeAmount"])
+
+	txs, err = restClient.GetTransactionByBlockNumAndId(110, 0)
+	require.Nil(t, err)
+	require.Equal(t, types.TxStatusIncluded, txs[0].Status)
+	require.Equal(t, uint32(11), txs[0].Confirmations)
+
+	_, err = restClient.GetTransactionByBlockNumAndId(110, 1)
+	require.NotNil(t, err)
+
+	//the position of a tx by id is the one of the id in its block
+	node := newHistoryNode()
+	node.Handle("get_block", func(args gjson.Result) (interface{}, error) {
+		return testBlock(testMemoTx, testNoMemoTx), nil
+	})
+	node.Handle("get_transaction_by_txid", func(args gjson.Result) (interface{}, error) {
+		return map[string]interface{}{"transaction": json.RawMessage(testNoMemoTx), "block_number": 110}, nil
+	})
+	txs, err = node.Client().GetTransaction(strings.Repeat("b", 40))
+	require.Nil(t, err)
+	require.Equal(t, 1, txs[0].TrxInBlock)
+	require.Equal(t, "2020-03-19T04:10:00", txs[0].ConfirmedAt)
+}
+
+func Test_TxsForAddressFields(t *testing.T) {
+	restClient := newHistoryNode().Client()
+	for _, txsFor := range []func(string, string, int) ([]*types.Tx, error){restClient.TxsForAddress, restClient.TxsForAddressFull} {
+		txs, err := txsFor(testAccountName, "", 10)
+		require.Nil(t, err)
+		require.Equal(t, 1, len(txs))
+		tx := txs[0]
+		require.Equal(t, "1.11.500", tx.HistoryID)
+		require.Equal(t, int64(110), tx.BlockNumber)
+		require.Equal(t, types.TxStatusIncluded, tx.Status)
+		require.Equal(t, uint64(1000), tx.Fee.Value)
+		require.Equal(t, "2020-03-19T04:10:00", tx.ConfirmedAt)
+		require.Equal(t, types.DirectionOutgoing, tx.Direction)
+		require.Equal(t, []types.NetAmount{{Value: -101000, TokenCode: "GXC", TokenIdentifier: "1.3.1", TokenDecimal: 5}}, tx.NetAmounts)
+	}
+}
+
+func Test_SetDirection(t *testing.T) {
+	newTx := func(from, to string) *types.Tx {
+		return &types.Tx{
+			Inputs:  []types.UTXO{{Value: 500, Address: from, TokenCode: "USDT", TokenIdentifier: "1.3.2", TokenDecimal: 6}},
+			Outputs: []types.UTXO{{Value: 500, Address: to, TokenCode: "USDT", TokenIdentifier: "1.3.2", TokenDecimal: 6}},
+			Fee:     &types.Fee{Value: 20, TokenCode: "GXC", TokenIdentifier: "1.3.1", TokenDecimal: 5},
+		}
+	}
+	gxcFee := types.NetAmount{Value: -20, TokenCode: "GXC", TokenIdentifier: "1.3.1", TokenDecimal: 5}
+
+	tx := newTx("init0", "dev")
+	api.SetDirection(tx, "dev")
+	require.Equal(t, types.DirectionIncoming, tx.Direction)
+	require.Equal(t, []types.NetAmount{{Value: 500, TokenCode: "USDT", TokenIdentifier: "1.3.2", TokenDecimal: 6}}, tx.NetAmounts)
+
+	tx = newTx("init0", "dev")
+	api.SetDirection(tx, "init0")
+	require.Equal(t, types.DirectionOutgoing, tx.Direction)
+	require.Equal(t, []types.NetAmount{{Value: -500, TokenCode: "USDT", TokenIdentifier: "1.3.2", TokenDecimal: 6}, gxcFee}, tx.NetAmounts)
+
+	tx = newTx("dev", "dev")
+	api.SetDirection(tx, "dev")
+	require.Equal(t, types.DirectionSelf, tx.Direction)
+	require.Equal(t, []types.NetAmount{gxcFee}, tx.NetAmounts)
+
+	tx = newTx("init0", "dev")
+	api.SetDirection(tx, "nathan")
+	require.Equal(t, types.TxDirection(""), tx.Direction)
+	require.Nil(t, tx.NetAmounts)
+}
+
+func Test_SignTransactionStatus(t *testing.T) {
+	node := newFakeNode()
+	node.Handle("broadcast_transaction_synchronous", func(args gjson.Result) (interface{}, error) {
+		return map[string]interface{}{"id": "0101813c34fb033b7ba7a30c675bfa1b949357d8", "block_num": 119, "trx_num": 2, "expired": false}, nil
+	})
+	tx, err := node.Client().SignTransaction(testUnsignTx, "1f00")
+	require.Nil(t, err)
+	require.Equal(t, types.TxStatusIncluded, tx.Status)
+	require.Equal(t, uint32(2), tx.Confirmations)
+	require.Equal(t, 2, tx.TrxInBlock)
+	require.Equal(t, "0101813c34fb033b7ba7a30c675bfa1b949357d8", tx.TxHash)
+	require.Equal(t, "2020-03-19T04:10:00", tx.TxAt)
+	require.Equal(t, "2020-03-19T04:10:00", tx.ConfirmedAt)
+}
+
+func Test_BroadcastTransaction(t *testing.T) {
+	node := newFakeNode()
+	var signatures []string
+	node.Handle("broadcast_transaction_synchronous", func(args gjson.Result) (interface{}, error) {
+		for _, signature := range args.Get("0.signatures").Array() {
+			signatures = append(signatures, signature.String())
+		}
+		return map[string]interface{}{"id": "0101813c34fb033b7ba7a30c675bfa1b949357d8", "block_num": 0, "trx_num": 0, "expired": false}, nil
+	})
+	signed := strings.Replace(testUnsignTx, `"signatures":null`, `"signatures":["1f00","1f01"]`, 1)
+	tx, err := node.Client().BroadcastTransaction(signed)
+	require.Nil(t, err)
+	require.Equal(t, types.TxStatusPending, tx.Status)
+	require.Equal(t, []string{"1f00", "1f01"}, signatures)
+
+	_, err = node.Client().BroadcastTransaction(testUnsignTx)
+	require.NotNil(t, err)
+
+	//an operation without a summary still returns the sent transaction
+	limitOrder := `{"ref_block_num":14710,"ref_block_prefix":3383196508,"expiration":"2020-03-19T04:18:42",
+		"operations":[[1,{"fee":{"amount":100,"asset_id":"1.3.1"},"seller":"1.2.4015","amount_to_sell":{"amount":1000,"asset_id":"1.3.1"},
+		"min_to_receive":{"amount":10,"asset_id":"1.3.2"},"expiration":"2020-03-20T04:18:42","fill_or_kill":false,"extensions":[]}]],
+		"extensions":[],"signatures":["1f00"]}`
+	tx, err = node.Client().BroadcastTransaction(limitOrder)
+	require.Nil(t, err)
+	require.Equal(t, "0101813c34fb033b7ba7a30c675bfa1b949357d8", tx.TxHash)
+	require.Equal(t, types.TxStatusPending, tx.Status)
+}
+
+//the operation ids of the chain, gxclient-go repeats 5 after account_create,
+//switch to its constants once they differ
+func Test_OpTypes(t *testing.T) {
+	require.Equal(t, gxcTypes.AccountCreateOpType, gxcTypes.AssetCreateOpType)
+	for opType, id := range map[gxcTypes.OpType]uint16{
+		api.AccountUpdateOpType:    6,
+		api.AssetCreateOpType:      10,
+		api.AssetUpdateOpType:      11,
+		api.AssetIssueOpType:       14,
+		api.AssetReserveOpType:     15,
+		api.AssetFundFeePoolOpType: 16,
+		api.ProposalCreateOpType:   22,
+		api.ProposalUpdateOpType:   23,
+		api.BalanceLockOpType:      71,
+		api.BalanceUnlockOpType:    72,
+	} {
+		require.Equal(t, gxcTypes.OpType(id), opType)
+	}
+}
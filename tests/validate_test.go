@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"gxclient-adapter/api"
+	"gxclient-go/rpc"
+	"strings"
+	"testing"
+)
+
+//a private key cli-wallet-test does not use
+const testUnusedPriHex = "1111111111111111111111111111111111111111111111111111111111111111"
+
+//a transfer of 1 GXC from cli-wallet-test to init0
+func testTransferTx(expiration string, fee uint64) string {
+	return fmt.Sprintf(`{"ref_block_num":100,"ref_block_prefix":1001,"expiration":"%s","operations":[[0,{"fee":{"amount":%d,"asset_id":"1.3.1"},
+		"from":"1.2.4015","to":"1.2.17","amount":{"amount":100000,"asset_id":"1.3.1"},"extensions":[]}]],"extensions":[],"signatures":[]}`, expiration, fee)
+}
+
+//the active key of cli-wallet-test is testPub, the node validates any signed transaction
+func newValidateNode() *fakeNode {
+	node := newFakeNode()
+	node.Handle("get_chain_id", func(args gjson.Result) (interface{}, error) {
+		return testChainId, nil
+	})
+	node.Handle("get_global_properties", func(args gjson.Result) (interface{}, error) {
+		return json.RawMessage(`{"id":"2.0.0","parameters":{"maximum_time_until_expiration":86400}}`), nil
+	})
+	node.Handle("get_potential_signatures", func(args gjson.Result) (interface{}, error) {
+		return []string{testPub, testOtherPub}, nil
+	})
+	node.Handle("get_required_signatures", func(args gjson.Result) (interface{}, error) {
+		required := []string{}
+		for _, key := range args.Get("1").Array() {
+			if key.String() == testPub {
+				required = append(required, testPub)
+			}
+		}
+		return required, nil
+	})
+	node.Handle("validate_transaction", func(args gjson.Result) (interface{}, error) {
+		if len(args.Get("0.signatures").Array()) == 0 {
+			return nil, &rpc.RPCError{Code: 1, Message: "Missing Active Authority 1.2.4015"}
+		}
+		return args.Get("0").Value(), nil
+	})
+	return node
+}
+
+func newValidateClient(t *testing.T, node *fakeNode) *api.RestClient {
+	restClient := node.Client()
+	require.Nil(t, restClient.SetNetwork(api.TestNet))
+	return restClient
+}
+
+func Test_ValidateTransaction(t *testing.T) {
+	restClient := newValidateClient(t, newValidateNode())
+
+	unsigned := testTransferTx("2020-03-19T04:30:00", 1000)
+	signature, err := api.Sign(testPriHex, testChainId, unsigned)
+	require.Nil(t, err)
+	result, err := restClient.ValidateTransaction(unsigned, []string{signature})
+	require.Nil(t, err)
+	require.True(t, result.Valid)
+	require.Equal(t, 40, len(result.TxId))
+	require.Equal(t, []string{testPub}, result.SignedKeys)
+	require.Equal(t, []string{testPub}, result.RequiredKeys)
+	require.Equal(t, "2020-03-19T04:30:00", result.Expiration)
+	require.Equal(t, "", result.ExpirationError)
+	require.Equal(t, 0, len(result.FeeMismatches))
+	require.Equal(t, 0, len(result.MissingKeys))
+
+	result, err = restClient.ValidateTransaction(unsigned, nil)
+	require.Nil(t, err)
+	require.False(t, result.Valid)
+	require.Equal(t, "Missing Active Authority 1.2.4015", result.Error)
+	require.Equal(t, []string{testPub}, result.MissingKeys)
+
+	unusedSignature, err := api.Sign(testUnusedPriHex, testChainId, unsigned)
+	require.Nil(t, err)
+	result, err = restClient.ValidateTransaction(unsigned, []string{signature, unusedSignature})
+	require.Nil(t, err)
+	require.False(t, result.Valid)
+	require.Equal(t, 1, len(result.UnnecessaryKeys))
+	require.NotEqual(t, testPub, result.UnnecessaryKeys[0])
+
+	_, err = restClient.ValidateTransaction(unsigned, []string{signature, signature})
+	require.NotNil(t, err)
+	_, err = restClient.ValidateTransaction(unsigned, []string{"zz"})
+	require.NotNil(t, err)
+
+	//the missing keys do not depend on the wording of the rejection
+	node := newValidateNode()
+	node.Handle("validate_transaction", func(args gjson.Result) (interface{}, error) {
+		return nil, &rpc.RPCError{Code: 1, Message: "tx_missing_active_auth"}
+	})
+	result, err = newValidateClient(t, node).ValidateTransaction(unsigned, nil)
+	require.Nil(t, err)
+	require.Equal(t, "tx_missing_active_auth", result.Error)
+	require.Equal(t, []string{testPub}, result.MissingKeys)
+}
+
+func Test_ValidateTransactionFees(t *testing.T) {
+	restClient := newValidateClient(t, newValidateNode())
+
+	unsigned := testTransferTx("2020-03-19T04:30:00", 500)
+	signature, err := api.Sign(testPriHex, testChainId, unsigned)
+	require.Nil(t, err)
+	result, err := restClient.ValidateTransaction(unsigned, []string{signature})
+	require.Nil(t, err)
+	require.False(t, result.Valid)
+	require.Equal(t, 1, len(result.FeeMismatches))
+	require.Equal(t, api.FeeMismatch{OpIndex: 0, AssetId: "1.3.1", Fee: 500, Required: 1000}, *result.FeeMismatches[0])
+
+	//a higher fee is paid in full
+	unsigned = testTransferTx("2020-03-19T04:30:00", 2000)
+	signature, err = api.Sign(testPriHex, testChainId, unsigned)
+	require.Nil(t, err)
+	result, err = restClient.ValidateTransaction(unsigned, []string{signature})
+	require.Nil(t, err)
+	require.True(t, result.Valid)
+	require.Equal(t, uint64(2000), result.FeeMismatches[0].Fee)
+}
+
+func Test_ValidateTransactionExpiration(t *testing.T) {
+	node := newValidateNode()
+	restClient := newValidateClient(t, node)
+
+	//the head block time is 2020-03-19T04:20:00
+	for _, expiration := range []string{"2020-03-19T04:20:00", "2020-03-20T04:20:01"} {
+		unsigned := testTransferTx(expiration, 1000)
+		signature, err := api.Sign(testPriHex, testChainId, unsigned)
+		require.Nil(t, err)
+		result, err := restClient.ValidateTransaction(unsigned, []string{signature})
+		require.Nil(t, err)
+		require.False(t, result.Valid)
+		require.True(t, strings.Contains(result.ExpirationError, expiration))
+	}
+
+	//a failure to reach the node is not a rejection
+	node.Handle("validate_transaction", func(args gjson.Result) (interface{}, error) {
+		return nil, rpc.ErrShutdown
+	})
+	unsigned := testTransferTx("2020-03-19T04:30:00", 1000)
+	signature, err := api.Sign(testPriHex, testChainId, unsigned)
+	require.Nil(t, err)
+	_, err = restClient.ValidateTransaction(unsigned, []string{signature})
+	require.NotNil(t, err)
+}
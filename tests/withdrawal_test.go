@@ -0,0 +1,264 @@
+package tests
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"gxclient-adapter/api"
+	"gxclient-adapter/types"
+	"gxclient-go/rpc"
+	"gxclient-go/transaction"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+//the node includes every broadcast transaction in block 110
+type withdrawalNode struct {
+	*fakeNode
+	//drop the connection after the node received the transaction
+	dropBroadcast bool
+	//accept the transactions without including them
+	lost bool
+	//reject the transactions
+	reject bool
+	//reply that the transactions expired without including them
+	expired  bool
+	included map[string]string
+}
+
+//id of the signed transaction raw
+func testTxId(raw string) (string, error) {
+	stx, err := api.ParseTransaction(raw)
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	if err := transaction.NewEncoder(&b).Encode(stx.Transaction); err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(b.Bytes())
+	return hex.EncodeToString(hash[:20]), nil
+}
+
+func newWithdrawalNode() *withdrawalNode {
+	node := &withdrawalNode{fakeNode: newFakeNode(), included: map[string]string{}}
+	node.Handle("get_chain_id", func(args gjson.Result) (interface{}, error) {
+		return testChainId, nil
+	})
+	node.Handle("get_transaction_by_txid", func(args gjson.Result) (interface{}, error) {
+		trx, ok := node.included[args.Get("0").String()]
+		if !ok {
+			return nil, nil
+		}
+		return map[string]interface{}{"transaction": json.RawMessage(trx), "block_number": 110}, nil
+	})
+	node.Handle("broadcast_transaction_synchronous", func(args gjson.Result) (interface{}, error) {
+		if node.reject {
+			return nil, &rpc.RPCError{Code: 1, Message: "insufficient balance"}
+		}
+		txId, err := testTxId(args.Get("0").Raw)
+		if err != nil {
+			return nil, err
+		}
+		if node.lost || node.expired {
+			return map[string]interface{}{"id": txId, "block_num": 0, "trx_num": 0, "expired": node.expired}, nil
+		}
+		node.included[txId] = args.Get("0").Raw
+		if node.dropBroadcast {
+			return nil, rpc.ErrShutdown
+		}
+		return map[string]interface{}{"id": txId, "block_num": 110, "trx_num": 0, "expired": false}, nil
+	})
+	return node
+}
+
+func newWithdrawalStore(t *testing.T, dir string) *api.FileWithdrawalStore {
+	store, err := api.NewFileWithdrawalStore(filepath.Join(dir, "withdrawals.json"))
+	require.Nil(t, err)
+	return store
+}
+
+func Test_BuildWithdrawal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "withdrawal")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	node := newWithdrawalNode()
+	restClient := node.Client()
+	_, err = restClient.BuildWithdrawal("w1", testAccountName, "init0", "GXC", 100000, nil)
+	require.NotNil(t, err)
+	restClient.SetWithdrawalStore(newWithdrawalStore(t, dir))
+
+	withdrawal, err := restClient.BuildWithdrawal("w1", testAccountName, "init0", "GXC", 100000, nil)
+	require.Nil(t, err)
+	require.Equal(t, 40, len(withdrawal.TxId))
+	require.Equal(t, "2020-03-19T04:30:00", withdrawal.Expiration)
+	require.Equal(t, types.TxStatus(""), withdrawal.Status)
+	require.Equal(t, 1, node.Calls("get_required_fees"))
+
+	//the key is built once
+	again, err := restClient.BuildWithdrawal("w1", testAccountName, "init0", "GXC", 100000, nil)
+	require.Nil(t, err)
+	require.Equal(t, withdrawal.TxId, again.TxId)
+	require.Equal(t, withdrawal.UnsignedTx, again.UnsignedTx)
+	require.Equal(t, 1, node.Calls("get_required_fees"))
+
+	_, err = restClient.BuildWithdrawal("w1", testAccountName, "init0", "GXC", 200000, nil)
+	require.NotNil(t, err)
+	_, err = restClient.BuildWithdrawal("", testAccountName, "init0", "GXC", 100000, nil)
+	require.NotNil(t, err)
+
+	//a restart keeps the key
+	restarted := node.Client()
+	restarted.SetWithdrawalStore(newWithdrawalStore(t, dir))
+	again, err = restarted.BuildWithdrawal("w1", testAccountName, "init0", "GXC", 100000, nil)
+	require.Nil(t, err)
+	require.Equal(t, withdrawal.TxId, again.TxId)
+	require.Equal(t, 1, node.Calls("get_required_fees"))
+}
+
+func Test_BroadcastWithdrawal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "withdrawal")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	node := newWithdrawalNode()
+	restClient := node.Client()
+	restClient.SetWithdrawalStore(newWithdrawalStore(t, dir))
+
+	withdrawal, err := restClient.BuildWithdrawal("w1", testAccountName, "init0", "", 100000, nil)
+	require.Nil(t, err)
+	signature, err := api.Sign(testPriHex, testChainId, withdrawal.UnsignedTx)
+	require.Nil(t, err)
+	withdrawal, err = restClient.BroadcastWithdrawal("w1", signature)
+	require.Nil(t, err)
+	require.Equal(t, types.TxStatusIncluded, withdrawal.Status)
+	require.Equal(t, int64(110), withdrawal.BlockNumber)
+	require.True(t, withdrawal.Broadcast)
+
+	//the transaction in a block is not sent again
+	withdrawal, err = restClient.BroadcastWithdrawal("w1", signature)
+	require.Nil(t, err)
+	require.Equal(t, types.TxStatusIncluded, withdrawal.Status)
+	require.Equal(t, 1, node.Calls("broadcast_transaction_synchronous"))
+	withdrawal, err = restClient.BuildWithdrawal("w1", testAccountName, "init0", "", 100000, nil)
+	require.Nil(t, err)
+	require.Equal(t, int64(110), withdrawal.BlockNumber)
+
+	_, err = restClient.BroadcastWithdrawal("w9", signature)
+	require.NotNil(t, err)
+}
+
+func Test_BroadcastWithdrawalRetry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "withdrawal")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	node := newWithdrawalNode()
+	restClient := node.Client()
+	restClient.SetWithdrawalStore(newWithdrawalStore(t, dir))
+
+	//the node got the transaction but the response was lost
+	withdrawal, err := restClient.BuildWithdrawal("w1", testAccountName, "init0", "GXC", 100000, nil)
+	require.Nil(t, err)
+	node.dropBroadcast = true
+	_, err = restClient.BroadcastWithdrawal("w1", "1f00")
+	require.NotNil(t, err)
+
+	restarted := node.Client()
+	restarted.SetWithdrawalStore(newWithdrawalStore(t, dir))
+	withdrawal, err = restarted.WithdrawalStatus("w1")
+	require.Nil(t, err)
+	require.True(t, withdrawal.Broadcast)
+	require.Equal(t, "1f00", withdrawal.Signature)
+	require.Equal(t, types.TxStatusIncluded, withdrawal.Status)
+	withdrawal, err = restarted.BroadcastWithdrawal("w1", "1f00")
+	require.Nil(t, err)
+	require.Equal(t, int64(110), withdrawal.BlockNumber)
+	require.Equal(t, 1, node.Calls("broadcast_transaction_synchronous"))
+}
+
+func Test_WithdrawalExpired(t *testing.T) {
+	dir, err := ioutil.TempDir("", "withdrawal")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	node := newWithdrawalNode()
+	restClient := node.Client()
+	restClient.SetWithdrawalStore(newWithdrawalStore(t, dir))
+
+	withdrawal, err := restClient.BuildWithdrawal("w1", testAccountName, "init0", "GXC", 100000, nil)
+	require.Nil(t, err)
+
+	//the chain passes the expiration right after the lookup missed, the properties of before count
+	header := node.handlers["get_block_header"]
+	node.Handle("get_block_header", func(args gjson.Result) (interface{}, error) {
+		if args.Get("0").Int() >= 220 {
+			return json.RawMessage(`{"previous":"000000db00000000000000000000000000000000","timestamp":"2020-03-19T04:30:00","witness":"1.6.1"}`), nil
+		}
+		return header(args)
+	})
+	lookup := node.handlers["get_transaction_by_txid"]
+	node.Handle("get_transaction_by_txid", func(args gjson.Result) (interface{}, error) {
+		setHead(node, "2020-03-19T04:30:00", 220)
+		return lookup(args)
+	})
+	withdrawal, err = restClient.WithdrawalStatus("w1")
+	require.Nil(t, err)
+	require.Equal(t, types.TxStatus(""), withdrawal.Status)
+	node.Handle("get_transaction_by_txid", lookup)
+	node.Handle("get_block_header", header)
+
+	//the head block time passes the expiration, the transaction may still be in a reversible block
+	node.Handle("get_dynamic_global_properties", func(args gjson.Result) (interface{}, error) {
+		return json.RawMessage(`{"time":"2020-03-19T04:30:00","head_block_number":240,"head_block_id":"000000f0","last_irreversible_block_num":220}`), nil
+	})
+	withdrawal, err = restClient.WithdrawalStatus("w1")
+	require.Nil(t, err)
+	require.Equal(t, types.TxStatus(""), withdrawal.Status)
+
+	//the last irreversible block passes the expiration
+	node.Handle("get_block_header", func(args gjson.Result) (interface{}, error) {
+		require.Equal(t, int64(220), args.Get("0").Int())
+		return json.RawMessage(`{"previous":"000000db00000000000000000000000000000000","timestamp":"2020-03-19T04:30:00","witness":"1.6.1"}`), nil
+	})
+	withdrawal, err = restClient.WithdrawalStatus("w1")
+	require.Nil(t, err)
+	require.Equal(t, types.TxStatusFailed, withdrawal.Status)
+	withdrawal, err = restClient.BroadcastWithdrawal("w1", "1f00")
+	require.Nil(t, err)
+	require.Equal(t, types.TxStatusFailed, withdrawal.Status)
+	require.False(t, withdrawal.Broadcast)
+	require.Equal(t, 0, node.Calls("broadcast_transaction_synchronous"))
+
+	//a key is never built again
+	withdrawal, err = restClient.BuildWithdrawal("w1", testAccountName, "init0", "GXC", 100000, nil)
+	require.Nil(t, err)
+	require.Equal(t, types.TxStatusFailed, withdrawal.Status)
+	require.Equal(t, 1, node.Calls("get_required_fees"))
+}
+
+func Test_BroadcastWithdrawalExpiredReply(t *testing.T) {
+	dir, err := ioutil.TempDir("", "withdrawal")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	//the node replies expired while the last irreversible block is before the expiration
+	node := newWithdrawalNode()
+	node.expired = true
+	restClient := node.Client()
+	restClient.SetWithdrawalStore(newWithdrawalStore(t, dir))
+	_, err = restClient.BuildWithdrawal("w1", testAccountName, "init0", "GXC", 100000, nil)
+	require.Nil(t, err)
+	withdrawal, err := restClient.BroadcastWithdrawal("w1", "1f00")
+	require.Nil(t, err)
+	require.Equal(t, types.TxStatusPending, withdrawal.Status)
+	withdrawal, err = restClient.WithdrawalStatus("w1")
+	require.Nil(t, err)
+	require.Equal(t, types.TxStatusPending, withdrawal.Status)
+}
@@ -7,4 +7,25 @@ type Asset struct {
 	TokenIdentifier string `json:"token_identifier"`
 	TokenDecimal    uint8  `json:"token_decimal"`
 	Balance         uint64 `json:"balance"`
+
+	//only in token details
+	Issuer string `json:"issuer,omitempty"`
+	//supplies in the smallest unit of the token
+	MaxSupply     uint64   `json:"max_supply,omitempty"`
+	CurrentSupply uint64   `json:"current_supply,omitempty"`
+	Flags         []string `json:"flags,omitempty"`
+	//flags the issuer is allowed to change
+	IssuerPermissions []string `json:"issuer_permissions,omitempty"`
+	Description       string   `json:"description,omitempty"`
+	FeePool           *FeePool `json:"fee_pool,omitempty"`
+}
+
+//FeePool pays fees in the core asset for fees paid in the token
+type FeePool struct {
+	//core asset in the pool, in its smallest unit
+	Balance uint64 `json:"balance"`
+	//fees paid in the token, in its smallest unit
+	AccumulatedFees uint64 `json:"accumulated_fees"`
+	//decimal amount of the core asset per token
+	CoreExchangeRate string `json:"core_exchange_rate"`
 }
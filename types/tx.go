@@ -1,5 +1,7 @@
 package types
 
+import "encoding/json"
+
 type UTXO struct {
 	Value           uint64 `json:"value,omitempty"`
 	Address         string `json:"address,omitempty"`
@@ -8,12 +10,206 @@ type UTXO struct {
 	TokenDecimal    uint8  `json:"token_decimal,omitempty"`
 }
 
+type Fee struct {
+	Value           uint64 `json:"value"`
+	TokenCode       string `json:"token_code,omitempty"`
+	TokenIdentifier string `json:"token_identifier,omitempty"`
+	TokenDecimal    uint8  `json:"token_decimal,omitempty"`
+}
+
+type TxStatus string
+
+const (
+	//signed but not in a block yet
+	TxStatusPending TxStatus = "pending"
+	//in a block above the last irreversible block
+	TxStatusIncluded TxStatus = "included"
+	//in an irreversible block
+	TxStatusIrreversible TxStatus = "irreversible"
+	//expired or rejected
+	TxStatusFailed TxStatus = "failed"
+	//its block was dropped by a fork, it may be included again in another block
+	TxStatusRolledBack TxStatus = "rolled_back"
+)
+
+type TxDirection string
+
+//direction of a transfer relative to the queried address
+const (
+	DirectionIncoming TxDirection = "incoming"
+	DirectionOutgoing TxDirection = "outgoing"
+	//transfer to itself, only the fee changes the balance
+	DirectionSelf TxDirection = "self"
+)
+
+//NetAmount is the balance change of an asset, negative when it decreases
+type NetAmount struct {
+	Value           int64  `json:"value"`
+	TokenCode       string `json:"token_code,omitempty"`
+	TokenIdentifier string `json:"token_identifier,omitempty"`
+	TokenDecimal    uint8  `json:"token_decimal,omitempty"`
+}
+
+//operation names of Tx.OpType
+const (
+	OpCallContract  = "call_contract"
+	OpStakingCreate = "staking_create"
+	OpStakingUpdate = "staking_update"
+	OpStakingClaim  = "staking_claim"
+	OpBalanceLock   = "balance_lock"
+	OpBalanceUnlock = "balance_unlock"
+
+	OpAssetCreate      = "asset_create"
+	OpAssetUpdate      = "asset_update"
+	OpAssetIssue       = "asset_issue"
+	OpAssetReserve     = "asset_reserve"
+	OpAssetFundFeePool = "asset_fund_fee_pool"
+
+	OpAccountUpdate = "account_update"
+
+	OpProposalCreate = "proposal_create"
+	OpProposalUpdate = "proposal_update"
+)
+
+//ContractCall is a decoded call_contract operation
+type ContractCall struct {
+	Contract   string `json:"contract,omitempty"`
+	ContractId string `json:"contract_id"`
+	Method     string `json:"method"`
+	//arguments decoded with the contract ABI, empty when they can not be decoded
+	Args json.RawMessage `json:"args,omitempty"`
+	//hex of the serialized arguments
+	Data string `json:"data"`
+}
+
+//Staking is a decoded staking or balance lock operation, the amount is in the inputs
+type Staking struct {
+	//staking object of updates and claims, locked balance of unlocks
+	Id        string `json:"id,omitempty"`
+	ProgramId string `json:"program_id,omitempty"`
+	//witness voted for by the staking
+	TrustNode    string `json:"trust_node,omitempty"`
+	Weight       uint32 `json:"weight,omitempty"`
+	StakingDays  uint32 `json:"staking_days,omitempty"`
+	LockDays     uint32 `json:"lock_days,omitempty"`
+	InterestRate uint32 `json:"interest_rate,omitempty"`
+	Memo         string `json:"memo,omitempty"`
+}
+
+//AssetChange is a decoded asset_create, asset_update or asset_fund_fee_pool operation,
+//issued and reserved amounts are in the inputs
+type AssetChange struct {
+	//empty for asset_create
+	AssetId   string   `json:"asset_id,omitempty"`
+	Symbol    string   `json:"symbol,omitempty"`
+	Precision uint8    `json:"precision,omitempty"`
+	MaxSupply uint64   `json:"max_supply,omitempty"`
+	Flags     []string `json:"flags,omitempty"`
+	//account id of the new issuer of asset_update
+	NewIssuer   string `json:"new_issuer,omitempty"`
+	Description string `json:"description,omitempty"`
+	//core asset added to the fee pool by asset_fund_fee_pool
+	FeePoolAmount uint64 `json:"fee_pool_amount,omitempty"`
+}
+
+//Authority is a weighted multi-key authority of an account, it is
+//satisfied by signatures and approvals reaching the threshold
+type Authority struct {
+	Threshold uint32 `json:"threshold"`
+	//public keys by weight
+	Keys map[string]uint16 `json:"keys,omitempty"`
+	//accounts by weight, names or ids
+	Accounts map[string]uint16 `json:"accounts,omitempty"`
+}
+
+//AccountUpdate is a decoded account_update operation, nil fields are unchanged
+type AccountUpdate struct {
+	Owner   *Authority `json:"owner,omitempty"`
+	Active  *Authority `json:"active,omitempty"`
+	MemoKey string     `json:"memo_key,omitempty"`
+}
+
+//ProposalApprovals are approvals of a proposal, account ids and public keys
+type ProposalApprovals struct {
+	Active []string `json:"active,omitempty"`
+	Owner  []string `json:"owner,omitempty"`
+	Keys   []string `json:"keys,omitempty"`
+}
+
+//ApprovalProgress is how close the approvals of a pending proposal are to the
+//threshold of an authority it requires
+type ApprovalProgress struct {
+	Account string `json:"account"`
+	//active or owner
+	Authority string `json:"authority"`
+	Threshold uint32 `json:"threshold"`
+	//weight of the keys and accounts approving, the threshold when the account approved itself
+	Weight   uint32 `json:"weight"`
+	Approved bool   `json:"approved"`
+}
+
+//Proposal is a decoded proposal_create or proposal_update operation, or a pending proposal
+type Proposal struct {
+	//empty for proposal_create
+	Id       string `json:"id,omitempty"`
+	Proposer string `json:"proposer,omitempty"`
+	//the proposal is removed when it is not executed before
+	Expiration          string `json:"expiration,omitempty"`
+	ReviewPeriodSeconds uint32 `json:"review_period_seconds,omitempty"`
+	//the proposed operations the way txs show them, not in proposal_update
+	Operations []*Tx `json:"operations,omitempty"`
+
+	//only in proposal_update operations
+	ApprovalsToAdd    *ProposalApprovals `json:"approvals_to_add,omitempty"`
+	ApprovalsToRemove *ProposalApprovals `json:"approvals_to_remove,omitempty"`
+
+	//only in pending proposals, the approvals given and the progress of each required authority
+	Approvals *ProposalApprovals  `json:"approvals,omitempty"`
+	Progress  []*ApprovalProgress `json:"progress,omitempty"`
+	//all the required authorities approved, a pending proposal stays when it is in
+	//its review period or its execution failed
+	Approved bool `json:"approved,omitempty"`
+}
+
 type Tx struct {
-	TxHash      string            `json:"tx_hash,omitempty"`
-	Inputs      []UTXO            `json:"inputs"`
-	Outputs     []UTXO            `json:"outputs"`
-	TxAt        string            `json:"tx_at,omitempty"`
-	BlockNumber int64             `json:"block_no,omitempty"`
-	ConfirmedAt string            `json:"confirmed_at,omitempty"`
-	Extra       map[string]string `json:"extra"`
+	TxHash      string `json:"tx_hash,omitempty"`
+	Inputs      []UTXO `json:"inputs"`
+	Outputs     []UTXO `json:"outputs"`
+	TxAt        string `json:"tx_at,omitempty"`
+	BlockNumber int64  `json:"block_no,omitempty"`
+	//time of the block including the tx
+	ConfirmedAt string `json:"confirmed_at,omitempty"`
+
+	Fee *Fee `json:"fee,omitempty"`
+	//index of the tx in the block, -1 when unknown
+	TrxInBlock int `json:"trx_in_block"`
+	//index of the operation in the tx
+	OpIndex int `json:"op_index"`
+	//operation history id, only in the account history
+	HistoryID     string   `json:"history_id,omitempty"`
+	Status        TxStatus `json:"status,omitempty"`
+	Confirmations uint32   `json:"confirmations"`
+
+	//relative to the queried address, only in the account history
+	Direction TxDirection `json:"direction,omitempty"`
+	//balance changes of the queried address per asset, the fee included when it paid
+	NetAmounts []NetAmount `json:"net_amounts,omitempty"`
+
+	//operation name, empty for transfers
+	OpType string `json:"op_type,omitempty"`
+	//only in call_contract operations, the inputs hold the caller and the amount
+	//sent, the outputs the contract
+	Contract *ContractCall `json:"contract,omitempty"`
+	//only in staking and balance lock operations, the inputs and the outputs
+	//hold the owner, the amount stays owned while staked or locked
+	Staking *Staking `json:"staking,omitempty"`
+	//only in asset operations other than issues and reserves
+	AssetChange *AssetChange `json:"asset_change,omitempty"`
+	//only in account_update operations
+	AccountUpdate *AccountUpdate `json:"account_update,omitempty"`
+	//only in proposal_create and proposal_update operations
+	Proposal *Proposal `json:"proposal,omitempty"`
+
+	//kept for backward compatibility, use the typed fields instead
+	Extra map[string]string `json:"extra"`
 }